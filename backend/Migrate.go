@@ -0,0 +1,74 @@
+package main
+
+import (
+	//Import standard library
+	"database/sql"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	//Import user's defined package
+	"gobank-server/migration"
+)
+
+// runMigrate handles "migrate up|down|status"
+func runMigrate(db *sql.DB, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: gobank-server migrate up|down|status")
+		return
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migration.Up(db)
+		if err != nil {
+			fmt.Println("Error at: runMigrate -> Error applying migrations")
+			fmt.Println(err)
+			return
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("Database is already up to date")
+			return
+		}
+		for _, m := range applied {
+			fmt.Printf("Applied migration %d_%s\n", m.Version, m.Name)
+		}
+
+	case "down":
+		m, found, err := migration.Down(db)
+		if err != nil {
+			fmt.Println("Error at: runMigrate -> Error rolling back migration")
+			fmt.Println(err)
+			return
+		}
+
+		if !found {
+			fmt.Println("No migration to roll back")
+			return
+		}
+		fmt.Printf("Rolled back migration %d_%s\n", m.Version, m.Name)
+
+	case "status":
+		states, err := migration.Status(db)
+		if err != nil {
+			fmt.Println("Error at: runMigrate -> Error reading migration status")
+			fmt.Println(err)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, state := range states {
+			appliedAt := "pending"
+			if state.AppliedAt.Valid {
+				appliedAt = state.AppliedAt.Time.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", state.Version, state.Name, appliedAt)
+		}
+		w.Flush()
+
+	default:
+		fmt.Println("Usage: gobank-server migrate up|down|status")
+	}
+}
@@ -0,0 +1,247 @@
+package achievement
+
+import (
+	//Import standard library
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+// Events an achievement can be unlocked by
+const (
+	EventTransfer = "transfer"
+	EventTopup    = "topup"
+)
+
+// Definition is an achievement a customer can unlock. After each of the events in On, Check
+// tells whether the customer has earned it. IDs are stored with each unlock, they must never
+// change
+type Definition struct {
+	ID          string
+	Name        string
+	Description string
+	On          []string
+	Check       func(t Tracker, customer string, at time.Time) (bool, error)
+}
+
+// Registry lists every achievement, in the order they are shown
+var Registry = []Definition{
+	{
+		ID:          "first_transfer",
+		Name:        "First transfer",
+		Description: "Made your first transfer",
+		On:          []string{EventTransfer},
+		Check: func(t Tracker, customer string, at time.Time) (bool, error) {
+			return true, nil
+		},
+	},
+	{
+		ID:          "saver_30_days",
+		Name:        "Steady saver",
+		Description: "Topped up your accounts 30 days in a row",
+		On:          []string{EventTopup},
+		Check: func(t Tracker, customer string, at time.Time) (bool, error) {
+			return t.topupStreak(customer, at, 30)
+		},
+	},
+	{
+		ID:          "ten_payees",
+		Name:        "Well connected",
+		Description: "Sent money to 10 different saved payees",
+		On:          []string{EventTransfer},
+		Check: func(t Tracker, customer string, at time.Time) (bool, error) {
+			return t.usedPayees(customer, 10)
+		},
+	},
+	{
+		ID:          "level_5",
+		Name:        "Rising star",
+		Description: "Reached level 5",
+		On:          []string{EventTransfer, EventTopup},
+		Check: func(t Tracker, customer string, at time.Time) (bool, error) {
+			return t.reachedLevel(customer, 5)
+		},
+	},
+}
+
+// Tracker unlocks achievements after money moves. It runs after EXP is awarded, so levels
+// reached by the same transfer or topup already count
+type Tracker struct {
+	Achievements store.AchievementStore
+	Exp          store.ExpStore
+	Accounts     store.AccountStore
+	Ledger       store.LedgerStore
+	Payees       store.PayeeStore
+	Levels       utility.LevelCurve
+}
+
+// Transfer checks the achievements a transfer made by the customer may unlock
+func (t Tracker) Transfer(customer string, transaction model.Transaction) ([]model.Achievement, error) {
+	return t.handle(customer, EventTransfer, transaction.Date)
+}
+
+// Topup checks the achievements a topup made by the customer may unlock
+func (t Tracker) Topup(customer string, at time.Time) ([]model.Achievement, error) {
+	return t.handle(customer, EventTopup, at)
+}
+
+// handle runs the checks of the event's achievements the customer has not unlocked yet and
+// returns the ones unlocked now
+func (t Tracker) handle(customer, event string, at time.Time) ([]model.Achievement, error) {
+	achievements, err := t.Achievements.Achievements(customer)
+	if err != nil {
+		return nil, err
+	}
+	unlocked := map[string]bool{}
+	for _, achievement := range achievements {
+		unlocked[achievement.ID] = true
+	}
+
+	var earned []model.Achievement
+	for _, definition := range Registry {
+		if unlocked[definition.ID] || !triggers(definition, event) {
+			continue
+		}
+
+		ok, err := definition.Check(t, customer, at)
+		if err != nil {
+			return earned, err
+		}
+		if !ok {
+			continue
+		}
+
+		achievement := model.Achievement{ID: definition.ID, Customer: customer, UnlockedAt: at}
+		stored, err := t.Achievements.UnlockAchievement(achievement)
+		if err != nil {
+			return earned, err
+		}
+		//A request racing this one may have unlocked it first
+		if stored {
+			earned = append(earned, achievement)
+		}
+	}
+
+	return earned, nil
+}
+
+// Badges returns every achievement of the registry, the unlocked ones first, oldest first
+func (t Tracker) Badges(customer string) ([]model.Badge, error) {
+	achievements, err := t.Achievements.Achievements(customer)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := map[string]Definition{}
+	for _, definition := range Registry {
+		definitions[definition.ID] = definition
+	}
+
+	badges := []model.Badge{}
+	unlocked := map[string]bool{}
+	for _, achievement := range achievements {
+		//Achievements taken out of the registry are not shown anymore
+		definition, ok := definitions[achievement.ID]
+		if !ok {
+			continue
+		}
+		unlockedAt := achievement.UnlockedAt
+		badges = append(badges, badge(definition, &unlockedAt))
+		unlocked[achievement.ID] = true
+	}
+	for _, definition := range Registry {
+		if !unlocked[definition.ID] {
+			badges = append(badges, badge(definition, nil))
+		}
+	}
+
+	return badges, nil
+}
+
+func badge(definition Definition, unlockedAt *time.Time) model.Badge {
+	return model.Badge{
+		ID:          definition.ID,
+		Name:        definition.Name,
+		Description: definition.Description,
+		UnlockedAt:  unlockedAt,
+	}
+}
+
+func triggers(definition Definition, event string) bool {
+	for _, on := range definition.On {
+		if on == event {
+			return true
+		}
+	}
+	return false
+}
+
+// topupStreak reports whether the customer topped up one of its accounts on each of the last
+// days days, up to at's. Days are UTC days, like the dates of transactions
+func (t Tracker) topupStreak(customer string, at time.Time, days int) (bool, error) {
+	accounts, err := t.Accounts.Accounts(customer)
+	if err != nil {
+		return false, err
+	}
+
+	first := model.Day(at).AddDate(0, 0, 1-days)
+	active := map[time.Time]bool{}
+	for _, account := range accounts {
+		filter := ledger.TransactionFilter{Limit: 100, From: first, Direction: "in"}
+		for {
+			transactions, err := t.Ledger.Transactions(account.ID, filter)
+			if err != nil {
+				return false, err
+			}
+			for _, transaction := range transactions {
+				if transaction.Type == "topup" {
+					active[model.Day(transaction.Date)] = true
+				}
+			}
+			if len(transactions) < filter.Limit {
+				break
+			}
+			filter.Cursor = transactions[len(transactions)-1].ID
+		}
+	}
+
+	return len(active) >= days, nil
+}
+
+// usedPayees reports whether the customer has sent money to at least count of its saved
+// payees. Deleted payees do not count anymore
+func (t Tracker) usedPayees(customer string, count int) (bool, error) {
+	payees, err := t.Payees.Payees(customer)
+	if err != nil {
+		return false, err
+	}
+
+	used := 0
+	for _, payee := range payees {
+		if payee.LastUsedAt != nil {
+			used++
+		}
+	}
+
+	return used >= count, nil
+}
+
+// reachedLevel reports whether the EXP the customer was awarded reaches the level. Only the
+// events in the EXP store count, so the level cannot be reached by any other way of setting EXP
+func (t Tracker) reachedLevel(customer string, level int) (bool, error) {
+	events, err := t.Exp.ExpEvents(customer, time.Time{})
+	if err != nil {
+		return false, err
+	}
+
+	exp := 0
+	for _, event := range events {
+		exp += event.Points
+	}
+
+	return t.Levels.LevelForExp(exp) >= level, nil
+}
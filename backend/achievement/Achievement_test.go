@@ -0,0 +1,153 @@
+package achievement
+
+import (
+	//Import standard library
+	"fmt"
+	"testing"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/store/memory"
+	"gobank-server/utility"
+)
+
+// newTracker returns a tracker on empty stores and one customer holding 1000.00
+func newTracker(t *testing.T) (Tracker, store.Stores, string) {
+	t.Helper()
+
+	stores := memory.New()
+	tracker := Tracker{
+		Achievements: stores.Achievements,
+		Exp:          stores.Exp,
+		Accounts:     stores.Accounts,
+		Ledger:       stores.Ledger,
+		Payees:       stores.Payees,
+		Levels:       utility.DefaultLevelCurve,
+	}
+	alice := newCustomer(t, stores, "alice@example.com")
+	_, err := stores.Ledger.Topup(alice, 100000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tracker, stores, alice
+}
+
+func newCustomer(t *testing.T, stores store.Stores, email string) string {
+	t.Helper()
+
+	user, err := stores.Users.CreateUser(model.User{Email: email, Fullname: email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+func unlockedIDs(achievements []model.Achievement) []string {
+	ids := []string{}
+	for _, achievement := range achievements {
+		ids = append(ids, achievement.ID)
+	}
+	return ids
+}
+
+func TestUnlockOnce(t *testing.T) {
+	tracker, stores, alice := newTracker(t)
+	now := time.Now()
+
+	//Topups do not unlock transfer achievements
+	unlocked, err := tracker.Topup(alice, now)
+	if err != nil || len(unlocked) > 0 {
+		t.Fatalf("topup unlocked %v, %v", unlockedIDs(unlocked), err)
+	}
+
+	unlocked, err = tracker.Transfer(alice, model.Transaction{Date: now})
+	if err != nil || fmt.Sprint(unlockedIDs(unlocked)) != "[first_transfer]" {
+		t.Fatalf("transfer unlocked %v, %v", unlockedIDs(unlocked), err)
+	}
+	unlocked, err = tracker.Transfer(alice, model.Transaction{Date: now})
+	if err != nil || len(unlocked) > 0 {
+		t.Fatalf("second transfer unlocked %v, %v", unlockedIDs(unlocked), err)
+	}
+
+	//Level 5 starts at 2000 EXP, either event notices it
+	_, err = stores.Exp.AddExpEvent(model.ExpEvent{Customer: alice, Kind: model.ExpStreak, Points: 2000, At: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlocked, err = tracker.Topup(alice, now)
+	if err != nil || fmt.Sprint(unlockedIDs(unlocked)) != "[level_5]" {
+		t.Fatalf("topup unlocked %v, %v", unlockedIDs(unlocked), err)
+	}
+
+	badges, err := tracker.Badges(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, badge := range badges {
+		got = append(got, fmt.Sprintf("%s:%t", badge.ID, badge.UnlockedAt != nil))
+	}
+	want := "[first_transfer:true level_5:true saver_30_days:false ten_payees:false]"
+	if fmt.Sprint(got) != want {
+		t.Fatalf("badges = %v, want %s", got, want)
+	}
+}
+
+func TestLevelCountsExpEventsOnly(t *testing.T) {
+	tracker, stores, _ := newTracker(t)
+
+	//A user row holding EXP no event awarded does not reach level 5
+	user, err := stores.Users.CreateUser(model.User{Email: "bob@example.com", Fullname: "Bob", Exp: 1000000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlocked, err := tracker.Transfer(user.ID, model.Transaction{Date: time.Now()})
+	if err != nil || fmt.Sprint(unlockedIDs(unlocked)) != "[first_transfer]" {
+		t.Fatalf("transfer unlocked %v, %v", unlockedIDs(unlocked), err)
+	}
+}
+
+func TestTenPayees(t *testing.T) {
+	tracker, stores, alice := newTracker(t)
+	now := time.Now()
+
+	//Saved payees only count once money was sent to them
+	for i := 0; i < 10; i++ {
+		account := newCustomer(t, stores, fmt.Sprintf("payee%d@example.com", i))
+		payee, err := stores.Payees.CreatePayee(model.Payee{Customer: alice, Nickname: account, Account: account, AddedAt: now})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 9 {
+			ok, err := tracker.usedPayees(alice, 10)
+			if err != nil || ok {
+				t.Fatalf("9 used payees: %t, %v", ok, err)
+			}
+		}
+		err = stores.Payees.PayeeUsed(payee.ID, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unlocked, err := tracker.Transfer(alice, model.Transaction{Date: now})
+	if err != nil || fmt.Sprint(unlockedIDs(unlocked)) != "[first_transfer ten_payees]" {
+		t.Fatalf("transfer unlocked %v, %v", unlockedIDs(unlocked), err)
+	}
+}
+
+func TestTopupStreak(t *testing.T) {
+	tracker, _, alice := newTracker(t)
+	now := time.Now()
+
+	//Alice only topped up today
+	for days, want := range map[int]bool{1: true, 2: false, 30: false} {
+		ok, err := tracker.topupStreak(alice, now, days)
+		if err != nil || ok != want {
+			t.Fatalf("streak of %d days = %t, %v, want %t", days, ok, err, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+// Handler serves the auth endpoints (both user and admin) with the stores it is given. Users'
+// levels follow Levels
+type Handler struct {
+	Users    store.UserStore
+	Accounts store.AccountStore
+	Admins   store.AdminStore
+	Sessions store.SessionStore
+	Levels   utility.LevelCurve
+}
+
+// info is what a client keeps about the customer or admin along with their tokens
+func (h Handler) info(id, role string) (model.Info, error) {
+	info := model.Info{ID: id, Role: role, Currency: model.DefaultCurrency}
+
+	//Admin don't have any thing right now to update -> May change later
+	if role == "user" {
+		user, err := h.Users.UserByID(id)
+		if err != nil {
+			return info, err
+		}
+		info.Fullname = user.Fullname
+		info.Exp = user.Exp
+		info, err = h.withAccounts(info)
+		if err != nil {
+			return info, err
+		}
+	}
+
+	//Calculate level
+	return h.withLevel(info), nil
+}
+
+// withAccounts adds the customer's open accounts to info, and the total of those held in
+// info.Currency as the balance. Money in other currencies is never converted for display
+func (h Handler) withAccounts(info model.Info) (model.Info, error) {
+	accounts, err := h.Accounts.Accounts(info.ID)
+	if err != nil {
+		return info, err
+	}
+
+	info.Accounts = []model.Account{}
+	info.Balance = 0
+	for _, account := range accounts {
+		if account.State == model.AccountOpen {
+			info.Accounts = append(info.Accounts, account)
+		}
+		if account.State == model.AccountOpen && account.Currency == info.Currency {
+			info.Balance += account.Balance
+		}
+	}
+
+	return info, nil
+}
+
+// withLevel works out the level of info.Exp and where it starts and ends
+func (h Handler) withLevel(info model.Info) model.Info {
+	info.Level, info.LevelMin, info.LevelMax = h.Levels.ProgressToNext(info.Exp)
+	return info
+}
@@ -2,35 +2,22 @@ package auth
 
 import (
 	//Import standard library
-	"crypto/sha256"
-	"database/sql"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
 	//Import user's defined package
-	"gobank/model"
-	"gobank/utility"
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
 )
 
-func Login(w http.ResponseWriter, r *http.Request) {
-	var clientMessage, serverMessage string
-
+func (h Handler) Login(w http.ResponseWriter, r *http.Request) {
 	//Read data from request body
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
-		serverMessage = "Error at: Login -> Error reading request body"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.InternalError(w, "Error at: Login -> Error reading request body", err)
 		return
 	}
 
@@ -38,26 +25,10 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	var loginInfo map[string]string
 	err = json.Unmarshal(data, &loginInfo)
 	if err != nil {
-		serverMessage = "Error at: Login -> Error unmarshal request body"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
 		return
 	}
 
-	/*Check for validity*/
-	db := utility.GetDB()
-
-	//Hash password for comparision
-	sum := sha256.Sum256([]byte(loginInfo["password"]))
-	password := hex.EncodeToString(sum[:])
-
 	/*Check validity*/
 	params := r.URL.Query()
 	role := params.Get("role")
@@ -67,99 +38,91 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		user  model.User
 	)
 
-	//Query to the respectful TABLE based on client's role
+	//Query to the respectful store based on client's role
 	if role == "admin" {
-		sqlQuery := `
-			SELECT * FROM admins
-			WHERE email = $1 
-		`
-		err = db.QueryRow(sqlQuery, loginInfo["email"]).Scan(&admin.ID, &admin.Email, &admin.Password, &admin.Fullname)
+		admin, err = h.Admins.AdminByEmail(loginInfo["email"])
 	} else if role == "user" {
-		sqlQuery := `
-			SELECT * FROM users
-			WHERE email = $1
-		`
-		err = db.QueryRow(sqlQuery, loginInfo["email"]).Scan(
-			&user.ID, &user.Email, &user.Password, &user.Fullname, &user.Balance, &user.Exp, &user.State,
-		)
+		user, err = h.Users.UserByEmail(loginInfo["email"])
 	} else {
-		clientMessage = "Invalid role"
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(clientMessage))
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRole, "Invalid role")
 		return
 	}
 
 	if err != nil {
 		//If not find the user, send messasage to client
-		if err == sql.ErrNoRows {
-			clientMessage = "This email has not been registered in the server"
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte(clientMessage))
+		if _, ok := err.(store.NotFoundError); ok {
+			utility.WriteProblem(w, http.StatusUnauthorized, utility.CodeInvalidCredential, "Wrong email or password")
 			return
 		}
 		/*Other error*/
-		serverMessage = "Error at: Login -> Error querying database"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.InternalError(w, "Error at: Login -> Error querying database", err)
 		return
 	}
 
 	//Compare password
-	if (role == "admin" && admin.Password != password) || (role == "user" && user.Password != password) {
-		clientMessage = "Wrong password"
-		w.WriteHeader(http.StatusNotAcceptable)
-		w.Write([]byte(clientMessage))
+	hash := user.Password
+	if role == "admin" {
+		hash = admin.Password
+	}
+	match, needsRehash, err := utility.ComparePassword(hash, loginInfo["password"])
+	if err != nil {
+		utility.InternalError(w, "Error at: Login -> Error comparing password", err)
 		return
 	}
 
+	if !match {
+		utility.WriteProblem(w, http.StatusUnauthorized, utility.CodeInvalidCredential, "Wrong email or password")
+		return
+	}
+
+	//Upgrade legacy or outdated hash now that we know the plain password
+	if needsRehash {
+		err = h.rehashPassword(role, user.ID, admin.ID, loginInfo["password"])
+		if err != nil {
+			//Not fatal, the old hash still works and will be upgraded on next login
+			fmt.Println("Error at: Login -> Error upgrading password hash")
+			fmt.Println(err)
+		}
+	}
+
 	/*If password match*/
 
-	//Generate token
-	var token string
+	//Start a new session, it gives an access token and a refresh token
+	var token, refreshToken string
 	if role == "admin" {
-		token, err = utility.GenerateToken(admin.ID, "admin")
+		token, refreshToken, err = utility.CreateSession(h.Sessions, admin.ID, "admin", r.Header.Get("device"))
 	} else if role == "user" {
-		token, err = utility.GenerateToken(user.ID, "user")
+		token, refreshToken, err = utility.CreateSession(h.Sessions, user.ID, "user", r.Header.Get("device"))
 	}
 	if err != nil {
-		serverMessage = "Error at: Login -> Error generating token"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.InternalError(w, "Error at: Login -> Error creating session", err)
 		return
 	}
 
 	//Generate credential struct and marshal to data
 	var credential model.Credential
 	if role == "user" {
-		level := utility.CalculateLevel(user.Exp)
 		credential = model.Credential{
-			Token: token,
+			Token:        token,
+			RefreshToken: refreshToken,
 			Info: model.Info{
 				ID:       user.ID,
 				Fullname: user.Fullname,
 				Role:     "user",
-				Balance:  user.Balance,
-				Level:    level,
+				Currency: model.DefaultCurrency,
 				Exp:      user.Exp,
 			},
 		}
+		credential.Info = h.withLevel(credential.Info)
+		credential.Info, err = h.withAccounts(credential.Info)
+		if err != nil {
+			utility.InternalError(w, "Error at: Login -> Error querying accounts", err)
+			return
+		}
 	} else if role == "admin" {
 		credential = model.Credential{
-			Token: token,
+			Token:        token,
+			RefreshToken: refreshToken,
 			Info: model.Info{
 				ID:       admin.ID,
 				Fullname: admin.Fullname,
@@ -169,126 +132,73 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	}
 	data, err = json.MarshalIndent(credential, "", " ")
 	if err != nil {
-		serverMessage = "Error at: Login -> Error marshal data before sending to client"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.InternalError(w, "Error at: Login -> Error marshal data before sending to client", err)
 		return
 	}
 
 	//Send data back to client
-	w.WriteHeader(http.StatusAccepted)
+	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(data))
 }
 
-func SendCredential(w http.ResponseWriter, r *http.Request) {
-	var serverMessage, clientMessage string
-
-	//Verify token
-	err := utility.VerifyToken(r.Header.Get("token"))
+func (h Handler) rehashPassword(role, userID, adminID, password string) error {
+	hash, err := utility.HashPassword(password)
 	if err != nil {
-		if _, ok := err.(utility.ExpiredTokenError); ok {
-			clientMessage = "Your token has expired"
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(clientMessage))
-			return
-		}
-
-		if _, ok := err.(utility.TokenTamperedError); ok {
-			clientMessage = "Cannot verify who you are! Your token may have been tampered"
-			w.WriteHeader(http.StatusNotAcceptable)
-			w.Write([]byte(clientMessage))
-			return
-		}
+		return err
+	}
 
-		/*Other errors*/
-		serverMessage = "Error at: SendCredential -> Error verifying token"
-		clientMessage = "Internal server error"
+	if role == "admin" {
+		return h.Admins.UpdateAdminPassword(adminID, hash)
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	return h.Users.UpdateUserPassword(userID, hash)
+}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+func (h Handler) SendCredential(w http.ResponseWriter, r *http.Request) {
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: SendCredential -> Error reading request body", err)
 		return
 	}
 
-	//Extracting claim
-	claims, err := utility.ExtractingClaims(r.Header.Get("token"))
+	//Unmarshal request body
+	var refreshToken string
+	err = json.Unmarshal(data, &refreshToken)
 	if err != nil {
-		serverMessage = "Error at: SendCredential -> Error extracting claims"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
 		return
 	}
 
-	//Get credential from database
-	db := utility.GetDB()
-	credential := model.Credential{
-		Token: r.Header.Get("token"),
-		Info: model.Info{
-			ID:   claims.ID,
-			Role: claims.Role,
-		},
-	}
-
-	//Admin don't have any thing right now to update -> May change later
-	if claims.Role == "user" {
-		sqlQuery := `
-			SELECT fullname, balance, exp FROM users
-			WHERE id = $1
-		`
-		err = db.QueryRow(sqlQuery, claims.ID).Scan(&credential.Info.Fullname, &credential.Info.Balance, &credential.Info.Exp)
-		if err != nil {
-			serverMessage = "Error at: SendCredential -> Error executing sql query to find credential data"
-			clientMessage = "Internal server error"
-
-			//Log error to server
-			fmt.Println(serverMessage)
-			fmt.Println(err)
-
-			//Send message to client
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(clientMessage))
+	//Trade the refresh token for a new pair of tokens
+	claims, token, refreshToken, err := utility.RotateSession(h.Sessions, refreshToken)
+	if err != nil {
+		if _, ok := err.(utility.InvalidRefreshTokenError); ok {
+			utility.WriteProblem(w, http.StatusUnauthorized, utility.CodeSessionExpired, "Your session has expired! Please log in again")
 			return
 		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: SendCredential -> Error rotating session", err)
+		return
 	}
 
-	//Calculate level
-	credential.Info.Level = utility.CalculateLevel(credential.Info.Exp)
+	//Get credential from database
+	info, err := h.info(claims.ID, claims.Role)
+	if err != nil {
+		utility.InternalError(w, "Error at: SendCredential -> Error querying credential data", err)
+		return
+	}
+	credential := model.Credential{Token: token, RefreshToken: refreshToken, Info: info}
 
 	//Package data
-	data, err := json.MarshalIndent(credential, "", " ")
+	data, err = json.MarshalIndent(credential, "", " ")
 	if err != nil {
-		serverMessage = "Error at: SendCredential -> Error marshal data"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.InternalError(w, "Error at: SendCredential -> Error marshal data", err)
 		return
 	}
 
 	//Send data back to client
-	w.WriteHeader(http.StatusFound)
+	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 }
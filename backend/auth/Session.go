@@ -0,0 +1,96 @@
+package auth
+
+import (
+	//Import standard library
+	"encoding/json"
+	"io"
+	"net/http"
+
+	//Import user's defined package
+	"gobank-server/middleware"
+	"gobank-server/utility"
+)
+
+func (h Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Revoke the access token by its jti, then the session so its refresh token stops working too
+	err := utility.RevokeToken(h.Sessions, claims)
+	if err != nil {
+		utility.InternalError(w, "Error at: Logout -> Error revoking token", err)
+		return
+	}
+
+	err = utility.RevokeSession(h.Sessions, claims.ID, claims.Role, claims.SessionID)
+	if err != nil {
+		if _, ok := err.(utility.SessionNotFoundError); !ok {
+			utility.InternalError(w, "Error at: Logout -> Error revoking session", err)
+			return
+		}
+	}
+
+	//Send successful message to client
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Logged out successfully"))
+}
+
+func (h Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Get active sessions from database
+	sessions, err := utility.ListSessions(h.Sessions, claims.ID, claims.Role, claims.SessionID)
+	if err != nil {
+		utility.InternalError(w, "Error at: ListSessions -> Error querying sessions", err)
+		return
+	}
+
+	//Package data
+	data, err := json.MarshalIndent(sessions, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: ListSessions -> Error marshal data", err)
+		return
+	}
+
+	//Send data back to client
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (h Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: RevokeSession -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body
+	var sessionID string
+	err = json.Unmarshal(data, &sessionID)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	//Revoke the session, only if it belongs to the caller
+	err = utility.RevokeSession(h.Sessions, claims.ID, claims.Role, sessionID)
+	if err != nil {
+		if _, ok := err.(utility.SessionNotFoundError); ok {
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodeSessionNotFound, "No active session was found with this ID")
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: RevokeSession -> Error revoking session", err)
+		return
+	}
+
+	//Send successful message to client
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Session revoked successfully"))
+}
@@ -2,63 +2,21 @@ package auth
 
 import (
 	//Import standard library
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 
 	//Import user's defined package
-	"gobank/utility"
+	"gobank-server/middleware"
+	"gobank-server/model"
+	"gobank-server/utility"
 )
 
-func ChangePassword(w http.ResponseWriter, r *http.Request) {
-	var serverMessage, clientMessage string
-
-	//Verify token
-	err := utility.VerifyToken(r.Header.Get("token"))
-	if err != nil {
-		if _, ok := err.(utility.ExpiredTokenError); ok {
-			clientMessage = "Your token has expired"
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(clientMessage))
-			return
-		}
-
-		if _, ok := err.(utility.TokenTamperedError); ok {
-			clientMessage = "Invalid token. Your token has been tampered"
-			w.WriteHeader(http.StatusNotAcceptable)
-			w.Write([]byte(clientMessage))
-			return
-		}
-
-		serverMessage = "Error at: ChangePassword -> Error verifying token"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
-	}
-
+func (h Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	//Reading request body
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
-		serverMessage = "Error at: ChangePassword -> Error reading request body"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.InternalError(w, "Error at: ChangePassword -> Error reading request body", err)
 		return
 	}
 
@@ -66,119 +24,91 @@ func ChangePassword(w http.ResponseWriter, r *http.Request) {
 	var password string
 	err = json.Unmarshal(data, &password)
 	if err != nil {
-		serverMessage = "Error at: ChangePassword -> Error unmarshal request body"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
 		return
 	}
 
-	//Extracting claims to get ID
-	var claims utility.Claim
-	claims, err = utility.ExtractingClaims(r.Header.Get("token"))
-	if err != nil {
-		serverMessage = "Error at: ChangePassword -> Error extracting claims"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
-	}
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
 
-	//Get client's role and look into database
-	params := r.URL.Query()
-	role := params.Get("role")
-	db := utility.GetDB()
+	//Look into database based on the role in the token, not on what the client claims to be
+	role := claims.Role
 	var passInDB string
 
 	if role == "admin" {
-		sqlQuery := `
-			SELECT password FROM admins 
-			WHERE id = $1
-		`
-		err = db.QueryRow(sqlQuery, claims.ID).Scan(&passInDB)
+		var admin model.Admin
+		admin, err = h.Admins.AdminByID(claims.ID)
+		passInDB = admin.Password
 	} else if role == "user" {
-		sqlQuery := `
-			SELECT password FROM users
-			WHERE id = $1
-		`
-		err = db.QueryRow(sqlQuery, claims.ID).Scan(&passInDB)
+		var user model.User
+		user, err = h.Users.UserByID(claims.ID)
+		passInDB = user.Password
 	} else {
-		clientMessage = "Invalid role"
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(clientMessage))
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRole, "Invalid role")
 		return
 	}
 
 	if err != nil {
-		//No need to check for sql.ErrNoRows, since token is valid -> ID exists
-		serverMessage = "Error at: ChangePassword -> Error executing sql query to find password"
-		clientMessage = "Internal server error"
+		//No need to check for store.NotFoundError, since token is valid -> ID exists
+		utility.InternalError(w, "Error at: ChangePassword -> Error executing sql query to find password", err)
+		return
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	//Check if new password is the same as old password or not
+	same, _, err := utility.ComparePassword(passInDB, password)
+	if err != nil {
+		utility.InternalError(w, "Error at: ChangePassword -> Error comparing password", err)
+		return
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+	if same {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeSamePassword, "New password is the same as old password")
 		return
 	}
 
-	//Check if new password is the same as old password or not
-	sum := sha256.Sum256([]byte(password))
-	hashedNewPass := hex.EncodeToString(sum[:])
-	if hashedNewPass == passInDB {
-		clientMessage = "New password is the same as old password"
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(clientMessage))
+	//Hash new password before storing
+	hashedNewPass, err := utility.HashPassword(password)
+	if err != nil {
+		utility.InternalError(w, "Error at: ChangePassword -> Error hashing password", err)
 		return
 	}
 
 	//If new pass != old pass, update new pass to database
 	if role == "admin" {
-		sqlQuery := `
-			UPDATE admins
-			SET password = $1
-			WHERE id = $2
-		`
-		_, err = db.Exec(sqlQuery, hashedNewPass, claims.ID)
+		err = h.Admins.UpdateAdminPassword(claims.ID, hashedNewPass)
 	} else if role == "user" {
-		sqlQuery := `
-			UPDATE users
-			SET password = $1
-			WHERE id = $2
-		`
-		_, err = db.Exec(sqlQuery, hashedNewPass, claims.ID)
+		err = h.Users.UpdateUserPassword(claims.ID, hashedNewPass)
 	}
 
 	if err != nil {
-		serverMessage = "Error at: ChangePassword -> Error update new password"
-		clientMessage = "Internal server error"
+		utility.InternalError(w, "Error at: ChangePassword -> Error update new password", err)
+		return
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	//Send message to client after update new password
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Password update successfully"))
+}
+
+// SendInfo sends the caller's up-to-date information without touching their tokens, so a
+// client can show it without refreshing its credential
+func (h Handler) SendInfo(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+	info, err := h.info(claims.ID, claims.Role)
+	if err != nil {
+		utility.InternalError(w, "Error at: SendInfo -> Error querying information", err)
+		return
+	}
+
+	//Package data
+	data, err := json.MarshalIndent(info, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: SendInfo -> Error marshal data", err)
 		return
 	}
 
-	//Send message to client after update new password
-	clientMessage = "Password update successfully"
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(clientMessage))
+	w.Write(data)
 }
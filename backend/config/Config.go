@@ -0,0 +1,439 @@
+package config
+
+import (
+	//Import standard library
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+// Config is every setting the server needs to start. It is built in layers, each one
+// overriding the previous: defaults, then the JSON config file, then GOBANK_* environment
+// variables, then command line flags
+type Config struct {
+	Database  Database  `json:"database"`
+	Server    Server    `json:"server"`
+	Token     Token     `json:"token"`
+	Account   Account   `json:"account"`
+	FX        FX        `json:"fx"`
+	Scheduler Scheduler `json:"scheduler"`
+	Payees    Payees    `json:"payees"`
+	Rewards   Rewards   `json:"rewards"`
+	Exp       Exp       `json:"exp"`
+	Perks     Perks     `json:"perks"`
+	Level     Level     `json:"level"`
+}
+
+type Database struct {
+	DSN             string   `json:"dsn"`
+	MaxOpenConns    int      `json:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime"`
+}
+
+type Server struct {
+	Addr string `json:"addr"`
+	//How long a response is kept for its Idempotency-Key, a retry after that is a new request
+	IdempotencyTTL Duration `json:"idempotency_ttl"`
+}
+
+type Token struct {
+	//Signing keys in the form "id:secret,id:secret", the active key signs new tokens
+	Keys       string   `json:"keys"`
+	ActiveKey  string   `json:"active_key"`
+	AccessTTL  Duration `json:"access_ttl"`
+	RefreshTTL Duration `json:"refresh_ttl"`
+}
+
+// Account numbers start with the branch code then the product code, both optional
+type Account struct {
+	Branch  string `json:"branch"`
+	Product string `json:"product"`
+}
+
+// Prefix is what every new account number starts with
+func (a Account) Prefix() string {
+	return a.Branch + a.Product
+}
+
+// FX rates are read from RatesFile, a JSON object like {"USD/EUR": "0.92"}. Without a file the
+// built-in rates are used. Fee is taken off every rate, before the customer's tier discount
+type FX struct {
+	RatesFile string        `json:"rates_file"`
+	QuoteTTL  Duration      `json:"quote_ttl"` //How long a quoted rate is honoured
+	Fee       model.Percent `json:"fee"`
+}
+
+// Scheduler looks for due scheduled transfers every Interval. A run that fails is tried again
+// after RetryDelay, up to MaxAttempts times
+type Scheduler struct {
+	Interval    Duration `json:"interval"`
+	RetryDelay  Duration `json:"retry_delay"`
+	MaxAttempts int      `json:"max_attempts"`
+}
+
+// Accounts first saved as a payee or paid less than CoolingOff ago can only be sent
+// CoolingOffLimit in total. What the account received is counted, so the limit is in the
+// currency of the account paid, whatever currency the paying accounts hold
+type Payees struct {
+	CoolingOff      Duration    `json:"cooling_off"`
+	CoolingOffLimit model.Money `json:"cooling_off_limit"`
+}
+
+// Rewards points are earned by transfers of at least MinTransfer to other customers, at the
+// rate of the customer's tier. They expire after Expiry and each one is worth PointValue once
+// redeemed
+type Rewards struct {
+	Expiry      Duration    `json:"expiry"`
+	MinTransfer model.Money `json:"min_transfer"`
+	PointValue  model.Money `json:"point_value"`
+}
+
+// EXP is awarded by the rules in RulesFile, see exp.Rules. Without a file the built-in rules
+// are used
+type Exp struct {
+	RulesFile string `json:"rules_file"`
+}
+
+// Perks of each level are read from TiersFile, a JSON array of tiers, see perks.Table. Without
+// a file the built-in tiers are used
+type Perks struct {
+	TiersFile string `json:"tiers_file"`
+}
+
+// Level is how much EXP levels take. Curve is "quadratic" (level n starts at Base*(n*n-n)),
+// "linear" (every level takes Base) or "table" (Thresholds lists the EXP each level starts at)
+type Level struct {
+	Curve      string `json:"curve"`
+	Base       int    `json:"base"`
+	MaxLevel   int    `json:"max_level"`
+	Thresholds []int  `json:"thresholds"`
+}
+
+// Duration is a time.Duration written as a string ("15m", "720h") in the config file
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	err := json.Unmarshal(data, &text)
+	if err != nil {
+		return fmt.Errorf("duration must be a string like \"15m\": %w", err)
+	}
+
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+}
+
+func Default() Config {
+	return Config{
+		Database: Database{
+			DSN:             "host=localhost port=5432 user=postgres dbname=gobank sslmode=disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxIdleTime: Duration(5 * time.Minute),
+			ConnMaxLifetime: Duration(5 * time.Minute),
+		},
+		Server: Server{
+			Addr:           "localhost:8800",
+			IdempotencyTTL: Duration(24 * time.Hour),
+		},
+		Token: Token{
+			AccessTTL:  Duration(15 * time.Minute),
+			RefreshTTL: Duration(30 * 24 * time.Hour),
+		},
+		FX: FX{
+			QuoteTTL: Duration(time.Minute),
+			Fee:      100,
+		},
+		Scheduler: Scheduler{
+			Interval:    Duration(time.Minute),
+			RetryDelay:  Duration(time.Hour),
+			MaxAttempts: 3,
+		},
+		Payees: Payees{
+			CoolingOff:      Duration(24 * time.Hour),
+			CoolingOffLimit: 100000,
+		},
+		Rewards: Rewards{
+			Expiry:      Duration(365 * 24 * time.Hour),
+			MinTransfer: 1000,
+			PointValue:  1,
+		},
+		Level: Level{
+			Curve:    "quadratic",
+			Base:     100,
+			MaxLevel: 100,
+		},
+	}
+}
+
+// Load builds the config from every layer and validates it. args are the command line
+// arguments without the program name, the arguments left after the flags are returned
+func Load(args []string) (Config, []string, error) {
+	cfg := Default()
+
+	//Flags are parsed first since they may point to the config file, but applied last
+	fs := flag.NewFlagSet("gobank", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("GOBANK_CONFIG"), "path to a JSON config file")
+	var flags Config
+	fs.StringVar(&flags.Database.DSN, "db-dsn", "", "PostgreSQL connection string")
+	fs.IntVar(&flags.Database.MaxOpenConns, "db-max-open-conns", 0, "maximum number of open database connections")
+	fs.IntVar(&flags.Database.MaxIdleConns, "db-max-idle-conns", 0, "maximum number of idle database connections")
+	fs.StringVar(&flags.Server.Addr, "addr", "", "address the server listens on")
+	fs.StringVar(&flags.Token.ActiveKey, "token-active-key", "", "ID of the key used to sign new tokens")
+	accessTTL := fs.Duration("access-token-ttl", 0, "lifetime of access tokens")
+	refreshTTL := fs.Duration("refresh-token-ttl", 0, "lifetime of refresh tokens")
+	err := fs.Parse(args)
+	if err != nil {
+		return cfg, nil, err
+	}
+
+	//Config file
+	if *path != "" {
+		err = loadFile(&cfg, *path)
+		if err != nil {
+			return cfg, nil, err
+		}
+	}
+
+	//Environment variables
+	err = loadEnv(&cfg)
+	if err != nil {
+		return cfg, nil, err
+	}
+
+	//Only flags that were given on the command line override the other layers
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "db-dsn":
+			cfg.Database.DSN = flags.Database.DSN
+		case "db-max-open-conns":
+			cfg.Database.MaxOpenConns = flags.Database.MaxOpenConns
+		case "db-max-idle-conns":
+			cfg.Database.MaxIdleConns = flags.Database.MaxIdleConns
+		case "addr":
+			cfg.Server.Addr = flags.Server.Addr
+		case "token-active-key":
+			cfg.Token.ActiveKey = flags.Token.ActiveKey
+		case "access-token-ttl":
+			cfg.Token.AccessTTL = Duration(*accessTTL)
+		case "refresh-token-ttl":
+			cfg.Token.RefreshTTL = Duration(*refreshTTL)
+		}
+	})
+
+	return cfg, fs.Args(), cfg.Validate()
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	//Fields missing from the file keep their default
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(cfg)
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func loadEnv(cfg *Config) error {
+	texts := map[string]*string{
+		"GOBANK_DB_DSN":           &cfg.Database.DSN,
+		"GOBANK_ADDR":             &cfg.Server.Addr,
+		"GOBANK_TOKEN_KEYS":       &cfg.Token.Keys,
+		"GOBANK_TOKEN_ACTIVE_KEY": &cfg.Token.ActiveKey,
+		"GOBANK_ACCOUNT_BRANCH":   &cfg.Account.Branch,
+		"GOBANK_ACCOUNT_PRODUCT":  &cfg.Account.Product,
+		"GOBANK_FX_RATES_FILE":    &cfg.FX.RatesFile,
+		"GOBANK_EXP_RULES_FILE":   &cfg.Exp.RulesFile,
+		"GOBANK_PERKS_TIERS_FILE": &cfg.Perks.TiersFile,
+		"GOBANK_LEVEL_CURVE":      &cfg.Level.Curve,
+	}
+	for name, field := range texts {
+		if value, ok := os.LookupEnv(name); ok {
+			*field = value
+		}
+	}
+
+	ints := map[string]*int{
+		"GOBANK_DB_MAX_OPEN_CONNS":      &cfg.Database.MaxOpenConns,
+		"GOBANK_DB_MAX_IDLE_CONNS":      &cfg.Database.MaxIdleConns,
+		"GOBANK_SCHEDULER_MAX_ATTEMPTS": &cfg.Scheduler.MaxAttempts,
+		"GOBANK_LEVEL_BASE":             &cfg.Level.Base,
+		"GOBANK_LEVEL_MAX":              &cfg.Level.MaxLevel,
+	}
+	for name, field := range ints {
+		if value, ok := os.LookupEnv(name); ok {
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be a number", name)
+			}
+			*field = number
+		}
+	}
+
+	durations := map[string]*Duration{
+		"GOBANK_DB_CONN_MAX_IDLE_TIME": &cfg.Database.ConnMaxIdleTime,
+		"GOBANK_DB_CONN_MAX_LIFETIME":  &cfg.Database.ConnMaxLifetime,
+		"GOBANK_IDEMPOTENCY_TTL":       &cfg.Server.IdempotencyTTL,
+		"GOBANK_ACCESS_TOKEN_TTL":      &cfg.Token.AccessTTL,
+		"GOBANK_REFRESH_TOKEN_TTL":     &cfg.Token.RefreshTTL,
+		"GOBANK_FX_QUOTE_TTL":          &cfg.FX.QuoteTTL,
+		"GOBANK_SCHEDULER_INTERVAL":    &cfg.Scheduler.Interval,
+		"GOBANK_SCHEDULER_RETRY_DELAY": &cfg.Scheduler.RetryDelay,
+		"GOBANK_PAYEE_COOLING_OFF":     &cfg.Payees.CoolingOff,
+		"GOBANK_REWARDS_EXPIRY":        &cfg.Rewards.Expiry,
+	}
+	for name, field := range durations {
+		if value, ok := os.LookupEnv(name); ok {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s must be a duration like 15m", name)
+			}
+			*field = Duration(duration)
+		}
+	}
+
+	amounts := map[string]*model.Money{
+		"GOBANK_PAYEE_COOLING_OFF_LIMIT": &cfg.Payees.CoolingOffLimit,
+		"GOBANK_REWARDS_MIN_TRANSFER":    &cfg.Rewards.MinTransfer,
+		"GOBANK_REWARDS_POINT_VALUE":     &cfg.Rewards.PointValue,
+	}
+	for name, field := range amounts {
+		if value, ok := os.LookupEnv(name); ok {
+			amount, err := model.ParseMoney(value)
+			if err != nil {
+				return fmt.Errorf("%s must be an amount like 1000.00", name)
+			}
+			*field = amount
+		}
+	}
+
+	if value, ok := os.LookupEnv("GOBANK_FX_FEE"); ok {
+		fee, err := model.ParsePercent(value)
+		if err != nil {
+			return fmt.Errorf("GOBANK_FX_FEE must be a percentage like 1.00")
+		}
+		cfg.FX.Fee = fee
+	}
+
+	return nil
+}
+
+// Validate reports every invalid setting at once, so a bad deploy is fixed in one go
+func (cfg Config) Validate() error {
+	var errs []error
+
+	if cfg.Database.DSN == "" {
+		errs = append(errs, errors.New("database DSN is required"))
+	}
+	if cfg.Database.MaxOpenConns <= 0 {
+		errs = append(errs, errors.New("database max open connections must be positive"))
+	}
+	if cfg.Database.MaxIdleConns < 0 || cfg.Database.MaxIdleConns > cfg.Database.MaxOpenConns {
+		errs = append(errs, errors.New("database max idle connections must be between 0 and max open connections"))
+	}
+	if cfg.Database.ConnMaxIdleTime < 0 || cfg.Database.ConnMaxLifetime < 0 {
+		errs = append(errs, errors.New("database connection lifetimes must not be negative"))
+	}
+
+	if _, _, err := net.SplitHostPort(cfg.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("listen address %q is not valid: %w", cfg.Server.Addr, err))
+	}
+	if cfg.Server.IdempotencyTTL <= 0 {
+		errs = append(errs, errors.New("idempotency key TTL must be positive"))
+	}
+
+	if cfg.Token.Keys == "" {
+		errs = append(errs, errors.New("token signing keys are required (GOBANK_TOKEN_KEYS)"))
+	}
+	if cfg.Token.AccessTTL <= 0 {
+		errs = append(errs, errors.New("access token TTL must be positive"))
+	}
+	if cfg.Token.RefreshTTL <= cfg.Token.AccessTTL {
+		errs = append(errs, errors.New("refresh token TTL must be longer than access token TTL"))
+	}
+
+	if !isDigits(cfg.Account.Branch, 4) {
+		errs = append(errs, errors.New("account branch code must be at most 4 digits"))
+	}
+	if !isDigits(cfg.Account.Product, 2) {
+		errs = append(errs, errors.New("account product code must be at most 2 digits"))
+	}
+
+	if cfg.FX.QuoteTTL <= 0 {
+		errs = append(errs, errors.New("FX quote TTL must be positive"))
+	}
+	if cfg.FX.Fee < 0 || cfg.FX.Fee >= model.Hundred {
+		errs = append(errs, errors.New("FX fee must be at least 0% and below 100%"))
+	}
+
+	if cfg.Scheduler.Interval <= 0 || cfg.Scheduler.RetryDelay <= 0 {
+		errs = append(errs, errors.New("scheduler interval and retry delay must be positive"))
+	}
+	if cfg.Scheduler.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("scheduler max attempts must be positive"))
+	}
+
+	if cfg.Payees.CoolingOff < 0 || cfg.Payees.CoolingOffLimit < 0 {
+		errs = append(errs, errors.New("payee cooling-off period and limit must not be negative"))
+	}
+
+	if cfg.Rewards.Expiry <= 0 || cfg.Rewards.PointValue <= 0 {
+		errs = append(errs, errors.New("rewards expiry and point value must be positive"))
+	}
+	if cfg.Rewards.MinTransfer < 0 {
+		errs = append(errs, errors.New("rewards minimum transfer must not be negative"))
+	}
+
+	switch cfg.Level.Curve {
+	case "quadratic", "linear":
+		if cfg.Level.Base <= 0 || cfg.Level.MaxLevel <= 0 || cfg.Level.MaxLevel > 10000 {
+			errs = append(errs, errors.New("level base must be positive and max level between 1 and 10000"))
+		}
+	case "table":
+		if len(cfg.Level.Thresholds) == 0 || cfg.Level.Thresholds[0] != 0 {
+			errs = append(errs, errors.New("level thresholds must start at 0"))
+		}
+		for i := 1; i < len(cfg.Level.Thresholds); i++ {
+			if cfg.Level.Thresholds[i] <= cfg.Level.Thresholds[i-1] {
+				errs = append(errs, errors.New("level thresholds must be increasing"))
+				break
+			}
+		}
+	default:
+		errs = append(errs, fmt.Errorf("level curve %q must be quadratic, linear or table", cfg.Level.Curve))
+	}
+
+	return errors.Join(errs...)
+}
+
+func isDigits(text string, maxLength int) bool {
+	return len(text) <= maxLength && strings.Trim(text, "0123456789") == ""
+}
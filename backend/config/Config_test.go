@@ -0,0 +1,88 @@
+package config
+
+import (
+	//Import standard library
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadLayers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{
+		"server": {"addr": "0.0.0.0:9000", "idempotency_ttl": "1h"},
+		"database": {"max_open_conns": 50, "max_idle_conns": 10},
+		"token": {"keys": "file:secret", "access_ttl": "5m"}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Environment overrides the file, flags override the environment
+	t.Setenv("GOBANK_CONFIG", path)
+	t.Setenv("GOBANK_TOKEN_KEYS", "env:secret")
+	t.Setenv("GOBANK_DB_MAX_OPEN_CONNS", "40")
+	t.Setenv("GOBANK_IDEMPOTENCY_TTL", "48h")
+	cfg, args, err := Load([]string{"-db-max-open-conns", "30", "migrate", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.Addr != "0.0.0.0:9000" {
+		t.Errorf("addr = %q, want the file's", cfg.Server.Addr)
+	}
+	if cfg.Token.Keys != "env:secret" {
+		t.Errorf("keys = %q, want the environment's", cfg.Token.Keys)
+	}
+	if cfg.Database.MaxOpenConns != 30 || cfg.Database.MaxIdleConns != 10 {
+		t.Errorf("pool = %d/%d, want 30/10", cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns)
+	}
+	if time.Duration(cfg.Token.AccessTTL) != 5*time.Minute || time.Duration(cfg.Token.RefreshTTL) != 30*24*time.Hour {
+		t.Errorf("TTLs = %v/%v, want the file's access TTL and the default refresh TTL", cfg.Token.AccessTTL, cfg.Token.RefreshTTL)
+	}
+	if time.Duration(cfg.Server.IdempotencyTTL) != 48*time.Hour {
+		t.Errorf("idempotency TTL = %v, want the environment's", cfg.Server.IdempotencyTTL)
+	}
+	if len(args) != 2 || args[0] != "migrate" {
+		t.Errorf("args = %v, want the subcommand", args)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Token.Keys = "k:secret"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config with keys is invalid: %v", err)
+	}
+
+	cfg.Server.Addr = "no-port"
+	cfg.Database.MaxIdleConns = 100
+	cfg.Token.RefreshTTL = cfg.Token.AccessTTL
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("invalid config passed validation")
+	}
+	//A level table must start at 0 and keep increasing
+	cfg = Default()
+	cfg.Token.Keys = "k:secret"
+	cfg.Level = Level{Curve: "table", Thresholds: []int{0, 100, 100}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("level table with a repeated threshold passed validation")
+	}
+
+	//Reward points must be worth something
+	cfg = Default()
+	cfg.Token.Keys = "k:secret"
+	cfg.Rewards.PointValue = 0
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("rewards without a point value passed validation")
+	}
+
+	//Idempotency keys must be kept for some time
+	cfg = Default()
+	cfg.Token.Keys = "k:secret"
+	cfg.Server.IdempotencyTTL = 0
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("idempotency keys kept for no time passed validation")
+	}
+}
@@ -0,0 +1,192 @@
+package exp
+
+import (
+	//Import standard library
+	"strconv"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// Engine awards EXP by its rules after money moves. Every award is stored as an event, so
+// caps and bonuses are worked out from the events of the previous days. Days are UTC days, like
+// the dates of transactions
+type Engine struct {
+	Rules    Rules
+	Events   store.ExpStore
+	Accounts store.AccountStore
+	Ledger   store.LedgerStore
+}
+
+// Transfer awards EXP for a transfer the customer made. Transfers between the customer's own
+// accounts and transfers sent back to an account that just paid the customer's earn nothing
+func (e Engine) Transfer(customer string, transaction model.Transaction) error {
+	if transaction.Amount < e.Rules.Transfer.MinAmount {
+		return nil
+	}
+
+	credit, err := e.Accounts.AccountByID(transaction.CreditAccount)
+	if err != nil {
+		return err
+	}
+	if credit.Customer == customer {
+		return nil
+	}
+
+	pingPong, err := e.PingPong(transaction)
+	if err != nil || pingPong {
+		return err
+	}
+
+	return e.earn(customer, model.ExpTransfer, e.Rules.Transfer, strconv.FormatInt(transaction.ID, 10), transaction.Date)
+}
+
+// PingPong reports whether the transfer sends money back to an account that paid the debit
+// account in the last PingPongDays days. Money going back and forth between the same two
+// accounts earns no EXP, cashback nor reward points
+func (e Engine) PingPong(transaction model.Transaction) (bool, error) {
+	if e.Rules.PingPongDays <= 0 {
+		return false, nil
+	}
+
+	back, err := e.Ledger.Transactions(transaction.DebitAccount, ledger.TransactionFilter{
+		Limit:        1,
+		From:         model.Day(transaction.Date).AddDate(0, 0, -e.Rules.PingPongDays),
+		Counterparty: transaction.CreditAccount,
+		Direction:    "in",
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return len(back) > 0, nil
+}
+
+// Topup awards EXP for money the customer put into one of its accounts
+func (e Engine) Topup(customer, account string, amount model.Money, at time.Time) error {
+	if amount < e.Rules.Topup.MinAmount {
+		return nil
+	}
+
+	return e.earn(customer, model.ExpTopup, e.Rules.Topup, account, at)
+}
+
+// earn awards the rule's points, then the bonuses the activity may have unlocked
+func (e Engine) earn(customer, kind string, rule Rule, reference string, at time.Time) error {
+	awarded, err := e.award(customer, kind, rule, reference, at)
+	if err != nil || !awarded {
+		return err
+	}
+
+	err = e.streak(customer, at)
+	if err != nil {
+		return err
+	}
+
+	return e.accountAge(customer, at)
+}
+
+// award stores an event with the rule's points, cut down to what is left under the daily caps.
+// It reports whether any point was awarded
+func (e Engine) award(customer, kind string, rule Rule, reference string, at time.Time) (bool, error) {
+	if rule.Points <= 0 {
+		return false, nil
+	}
+
+	today, err := e.Events.ExpEvents(customer, model.Day(at))
+	if err != nil {
+		return false, err
+	}
+
+	total, ofKind := 0, 0
+	for _, event := range today {
+		total += event.Points
+		if event.Kind == kind {
+			ofKind += event.Points
+		}
+	}
+
+	points := rule.Points
+	if rule.DailyCap > 0 {
+		points = min(points, rule.DailyCap-ofKind)
+	}
+	if e.Rules.DailyCap > 0 {
+		points = min(points, e.Rules.DailyCap-total)
+	}
+	if points <= 0 {
+		return false, nil
+	}
+
+	_, err = e.Events.AddExpEvent(model.ExpEvent{Customer: customer, Kind: kind, Points: points, Reference: reference, At: at})
+	return err == nil, err
+}
+
+// streak awards a bonus once the customer earned EXP from transfers or topups every day for
+// Streak.Days days. The next bonus needs a whole new streak
+func (e Engine) streak(customer string, at time.Time) error {
+	rule := e.Rules.Streak
+	if rule.Points <= 0 {
+		return nil
+	}
+
+	today := model.Day(at)
+	first := today.AddDate(0, 0, 1-rule.Days)
+	events, err := e.Events.ExpEvents(customer, first)
+	if err != nil {
+		return err
+	}
+
+	active := map[time.Time]bool{}
+	for _, event := range events {
+		switch event.Kind {
+		case model.ExpStreak:
+			return nil
+		case model.ExpTransfer, model.ExpTopup:
+			active[model.Day(event.At)] = true
+		}
+	}
+	if len(active) < rule.Days {
+		return nil
+	}
+
+	_, err = e.award(customer, model.ExpStreak, rule, today.Format("2006-01-02"), at)
+	return err
+}
+
+// accountAge awards a bonus every AccountAge.Days days after the customer's checking account
+// was opened, the first time the customer is active after each anniversary
+func (e Engine) accountAge(customer string, at time.Time) error {
+	rule := e.Rules.AccountAge
+	if rule.Points <= 0 {
+		return nil
+	}
+
+	//The checking account has the customer's number
+	account, err := e.Accounts.AccountByID(customer)
+	if err != nil {
+		return err
+	}
+
+	opened := model.Day(account.OpenedAt)
+	milestone := int(model.Day(at).Sub(opened).Hours()/24) / rule.Days
+	if milestone < 1 {
+		return nil
+	}
+
+	//Only one award per anniversary, it is looked for since the anniversary
+	events, err := e.Events.ExpEvents(customer, opened.AddDate(0, 0, milestone*rule.Days))
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if event.Kind == model.ExpAccountAge {
+			return nil
+		}
+	}
+
+	_, err = e.award(customer, model.ExpAccountAge, rule, strconv.Itoa(milestone), at)
+	return err
+}
@@ -0,0 +1,141 @@
+package exp
+
+import (
+	//Import standard library
+	"testing"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/store/memory"
+)
+
+// newEngine returns an engine with the default rules and two customers holding 1000.00 each
+func newEngine(t *testing.T) (Engine, store.Stores, string, string) {
+	t.Helper()
+
+	stores := memory.New()
+	var ids []string
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		user, err := stores.Users.CreateUser(model.User{Email: email, Fullname: email})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = stores.Ledger.Topup(user.ID, 100000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, user.ID)
+	}
+
+	engine := Engine{Rules: DefaultRules(), Events: stores.Exp, Accounts: stores.Accounts, Ledger: stores.Ledger}
+	return engine, stores, ids[0], ids[1]
+}
+
+// transfer moves amount between the customers' checking accounts and awards EXP for it
+func transfer(t *testing.T, engine Engine, stores store.Stores, from, to string, amount model.Money, at time.Time) {
+	t.Helper()
+
+	transaction, err := stores.Ledger.Transfer(model.Transaction{
+		Date: at, DebitAccount: from, CreditAccount: to, Amount: amount, CreditAmount: amount,
+		Currency: model.DefaultCurrency, CreditCurrency: model.DefaultCurrency,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = engine.Transfer(from, transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func userExp(t *testing.T, stores store.Stores, id string) int {
+	t.Helper()
+
+	user, err := stores.Users.UserByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.Exp
+}
+
+func TestTransferDailyCap(t *testing.T) {
+	engine, stores, alice, bob := newEngine(t)
+	now := time.Now()
+
+	//Small transfers earn nothing, the others stop earning at the transfer cap
+	transfer(t, engine, stores, alice, bob, 500, now)
+	for i := 0; i < 7; i++ {
+		transfer(t, engine, stores, alice, bob, 1000, now)
+	}
+	if got := userExp(t, stores, alice); got != 50 {
+		t.Fatalf("exp = %d, want 50", got)
+	}
+
+	//The cap is per day
+	transfer(t, engine, stores, alice, bob, 1000, now.AddDate(0, 0, 1))
+	if got := userExp(t, stores, alice); got != 60 {
+		t.Fatalf("exp = %d, want 60", got)
+	}
+
+	//The user's EXP is the sum of its events
+	events, err := stores.Exp.ExpEvents(alice, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, event := range events {
+		total += event.Points
+	}
+	if total != 60 || len(events) != 6 {
+		t.Fatalf("events = %+v", events)
+	}
+}
+
+func TestPingPongEarnsNothing(t *testing.T) {
+	engine, stores, alice, bob := newEngine(t)
+	now := time.Now()
+
+	transfer(t, engine, stores, alice, bob, 1000, now)
+	transfer(t, engine, stores, bob, alice, 1000, now)
+	if userExp(t, stores, alice) != 10 || userExp(t, stores, bob) != 0 {
+		t.Fatalf("exp = %d and %d, want 10 and 0", userExp(t, stores, alice), userExp(t, stores, bob))
+	}
+
+	//Moving money between one's own accounts earns nothing either
+	savings, err := stores.Accounts.OpenAccount(model.Account{Customer: bob, Type: model.Savings, Currency: model.DefaultCurrency})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer(t, engine, stores, bob, savings.ID, 1000, now)
+	if got := userExp(t, stores, bob); got != 0 {
+		t.Fatalf("exp = %d, want 0", got)
+	}
+}
+
+func TestStreakAndAccountAge(t *testing.T) {
+	engine, stores, alice, _ := newEngine(t)
+	engine.Rules.AccountAge.Days = 3
+	now := time.Now()
+
+	//A topup every day for a week: 7 topups, an account age bonus on day 3 and 6, a streak bonus on day 7
+	for day := 0; day < 7; day++ {
+		err := engine.Topup(alice, alice, 1000, now.AddDate(0, 0, day))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := userExp(t, stores, alice); got != 7*5+2*100+30 {
+		t.Fatalf("exp = %d, want %d", got, 7*5+2*100+30)
+	}
+
+	//The next streak starts over
+	err := engine.Topup(alice, alice, 1000, now.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := userExp(t, stores, alice); got != 8*5+2*100+30 {
+		t.Fatalf("exp = %d, want %d", got, 8*5+2*100+30)
+	}
+}
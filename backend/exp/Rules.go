@@ -0,0 +1,89 @@
+package exp
+
+import (
+	//Import standard library
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+// Rule awards Points for one kind of event, at most DailyCap points a day (0 for no cap).
+// Transfers and topups below MinAmount, in the account's currency, earn nothing. Days is the
+// length of a streak, or the time between two account age awards
+type Rule struct {
+	Points    int         `json:"points"`
+	DailyCap  int         `json:"daily_cap"`
+	MinAmount model.Money `json:"min_amount"`
+	Days      int         `json:"days"`
+}
+
+// Rules are what the engine awards EXP for. DailyCap bounds all of a user's points in a day.
+// A transfer earns nothing when the receiving account sent money back to the paying one in
+// the last PingPongDays days
+type Rules struct {
+	DailyCap     int  `json:"daily_cap"`
+	Transfer     Rule `json:"transfer"`
+	Topup        Rule `json:"topup"`
+	Streak       Rule `json:"streak"`
+	AccountAge   Rule `json:"account_age"`
+	PingPongDays int  `json:"ping_pong_days"`
+}
+
+// DefaultRules are used when no rules file is configured
+func DefaultRules() Rules {
+	return Rules{
+		DailyCap:     200,
+		Transfer:     Rule{Points: 10, DailyCap: 50, MinAmount: 1000},
+		Topup:        Rule{Points: 5, DailyCap: 10, MinAmount: 1000},
+		Streak:       Rule{Points: 30, Days: 7},
+		AccountAge:   Rule{Points: 100, Days: 365},
+		PingPongDays: 7,
+	}
+}
+
+// LoadRules reads rules from a JSON file shaped like Rules, rules missing from the file keep
+// their default
+func LoadRules(path string) (Rules, error) {
+	rules := DefaultRules()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, fmt.Errorf("reading EXP rules file: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&rules)
+	if err != nil {
+		return rules, fmt.Errorf("parsing EXP rules file %s: %w", path, err)
+	}
+
+	return rules, rules.Validate()
+}
+
+func (r Rules) Validate() error {
+	var errs []error
+
+	if r.DailyCap < 0 || r.PingPongDays < 0 {
+		errs = append(errs, errors.New("EXP daily cap and ping-pong days must not be negative"))
+	}
+	names := []string{model.ExpTransfer, model.ExpTopup, model.ExpStreak, model.ExpAccountAge}
+	for i, rule := range []Rule{r.Transfer, r.Topup, r.Streak, r.AccountAge} {
+		if rule.Points < 0 || rule.DailyCap < 0 || rule.MinAmount < 0 || rule.Days < 0 {
+			errs = append(errs, fmt.Errorf("EXP rule %s must not have negative values", names[i]))
+		}
+	}
+	if r.Streak.Points > 0 && r.Streak.Days < 2 {
+		errs = append(errs, errors.New("EXP streak must last at least 2 days"))
+	}
+	if r.AccountAge.Points > 0 && r.AccountAge.Days < 1 {
+		errs = append(errs, errors.New("EXP account age days must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,43 @@
+package fx
+
+import (
+	//Import standard library
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+// Exchange quotes conversions at the provider's current rate less Fee. A quote locks the rate
+// until it expires, so the customer confirms the exact amount that will be credited
+type Exchange struct {
+	Rates    Provider
+	QuoteTTL time.Duration
+	Fee      model.Percent
+}
+
+// Quote fills in the rate, the converted amount, the expiry and a new ID. quote carries the
+// customer, both accounts, both currencies and the amount to debit. Its fee is Fee less the
+// customer's discount
+func (e Exchange) Quote(quote model.Quote, discount model.Percent, now time.Time) (model.Quote, error) {
+	rate, err := e.Rates.Rate(quote.From, quote.To)
+	if err != nil {
+		return quote, err
+	}
+	quote.Fee = e.Fee.Less(discount)
+	rate = rate.LessFee(quote.Fee)
+
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	if err != nil {
+		return quote, err
+	}
+
+	quote.ID = hex.EncodeToString(id)
+	quote.Rate = rate
+	quote.Converted = rate.Convert(quote.Amount)
+	quote.ExpiresAt = now.Add(e.QuoteTTL)
+	return quote, nil
+}
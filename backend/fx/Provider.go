@@ -0,0 +1,97 @@
+package fx
+
+import (
+	//Import standard library
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+// Provider is where exchange rates come from. Rate returns how many units of to one unit of
+// from buys, and RateUnavailableError when the pair is not quoted
+type Provider interface {
+	Rate(from, to model.Currency) (model.Rate, error)
+}
+
+type RateUnavailableError struct {
+	From model.Currency
+	To   model.Currency
+}
+
+func (e RateUnavailableError) Error() string {
+	return fmt.Sprintf("No exchange rate from %s to %s", e.From, e.To)
+}
+
+// Static is a fixed table of rates keyed by pair, e.g. "USD/EUR". A pair that is missing is
+// answered with the inverse of the opposite pair when that one is known
+type Static map[string]model.Rate
+
+// DefaultRates stand in for a real rate feed until one is configured
+func DefaultRates() Static {
+	rates := Static{}
+	for pair, text := range map[string]string{
+		"USD/EUR": "0.92",
+		"USD/GBP": "0.79",
+		"USD/AUD": "1.52",
+		"USD/CAD": "1.36",
+		"USD/CHF": "0.88",
+		"USD/SGD": "1.34",
+	} {
+		rate, err := model.ParseRate(text)
+		if err != nil {
+			panic(err)
+		}
+		rates[pair] = rate
+	}
+
+	return rates
+}
+
+// LoadFile reads a JSON object of pairs and rates written as strings:
+//
+//	{"USD/EUR": "0.92", "GBP/EUR": "1.17"}
+func LoadFile(path string) (Static, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading FX rates file: %w", err)
+	}
+
+	var rates map[string]model.Rate
+	err = json.Unmarshal(data, &rates)
+	if err != nil {
+		return nil, fmt.Errorf("parsing FX rates file %s: %w", path, err)
+	}
+
+	static := Static{}
+	for pair, rate := range rates {
+		from, to, found := strings.Cut(pair, "/")
+		if !found {
+			return nil, fmt.Errorf("FX pair %q must be in the form FROM/TO", pair)
+		}
+		if _, err := model.ParseCurrency(from); err != nil {
+			return nil, fmt.Errorf("FX pair %q: %w", pair, err)
+		}
+		if _, err := model.ParseCurrency(to); err != nil {
+			return nil, fmt.Errorf("FX pair %q: %w", pair, err)
+		}
+		static[strings.ToUpper(pair)] = rate
+	}
+
+	return static, nil
+}
+
+func (s Static) Rate(from, to model.Currency) (model.Rate, error) {
+	if rate, ok := s[string(from)+"/"+string(to)]; ok {
+		return rate, nil
+	}
+
+	if rate, ok := s[string(to)+"/"+string(from)]; ok {
+		return rate.Inverse(), nil
+	}
+
+	return 0, RateUnavailableError{From: from, To: to}
+}
@@ -0,0 +1,87 @@
+package fx
+
+import (
+	//Import standard library
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+func TestStaticRate(t *testing.T) {
+	rates := DefaultRates()
+
+	rate, err := rates.Rate("USD", "EUR")
+	if err != nil || rate.String() != "0.92" {
+		t.Errorf("USD/EUR = %s, %v", rate, err)
+	}
+
+	//The opposite pair is answered with the inverse
+	rate, err = rates.Rate("EUR", "USD")
+	if err != nil || rate.String() != "1.08695652" {
+		t.Errorf("EUR/USD = %s, %v", rate, err)
+	}
+
+	_, err = rates.Rate("EUR", "GBP")
+	if _, ok := err.(RateUnavailableError); !ok {
+		t.Errorf("EUR/GBP: err = %v, want RateUnavailableError", err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`{"eur/gbp": "0.86"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rates, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate, err := rates.Rate("EUR", "GBP")
+	if err != nil || rate.String() != "0.86" {
+		t.Errorf("EUR/GBP = %s, %v", rate, err)
+	}
+
+	for name, content := range map[string]string{
+		"no slash":         `{"EURGBP": "0.86"}`,
+		"unknown currency": `{"EUR/XYZ": "0.86"}`,
+		"number":           `{"EUR/GBP": 0.86}`,
+	} {
+		err = os.WriteFile(path, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = LoadFile(path)
+		if err == nil {
+			t.Errorf("%s: rates file was accepted", name)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	exchange := Exchange{Rates: DefaultRates(), QuoteTTL: time.Minute}
+	now := time.Now()
+
+	quote, err := exchange.Quote(model.Quote{From: "USD", To: "GBP", Amount: 10000}, 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.ID == "" || quote.Converted != 7900 || !quote.ExpiresAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("quote = %+v", quote)
+	}
+
+	//A 1% fee halved by the customer's discount
+	exchange.Fee = 100
+	quote, err = exchange.Quote(model.Quote{From: "USD", To: "GBP", Amount: 10000}, 5000, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Fee != 50 || quote.Rate.String() != "0.78605" || quote.Converted != 7861 {
+		t.Fatalf("quote = %+v", quote)
+	}
+}
@@ -1,5 +1,10 @@
-module gobank
+module gobank-server
 
 go 1.22.2
 
 require github.com/lib/pq v1.10.9
+
+require (
+	golang.org/x/crypto v0.31.0
+	golang.org/x/sys v0.28.0 // indirect
+)
@@ -0,0 +1,281 @@
+package ledger
+
+import (
+	//Import standard library
+	"fmt"
+	"strings"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+// CashAccount is the bank's own account, used as the other side of money entering (topup)
+// or leaving (withdraw) the bank. It has no row in users TABLE
+const CashAccount = "cash"
+
+// FXAccount is the bank's currency exchange position. A converted transfer is paid into it in
+// one currency and paid out of it in another, so every currency stays balanced on its own.
+// It has no row in accounts TABLE either
+const FXAccount = "fx"
+
+// RewardsAccount is what the bank spends on rewards such as cashback. It has no row in accounts
+// TABLE either
+const RewardsAccount = "rewards"
+
+// IsBankAccount reports whether id is one of the bank's own accounts. They have no balance to
+// keep, only journal entries
+func IsBankAccount(id string) bool {
+	return id == CashAccount || id == FXAccount || id == RewardsAccount
+}
+
+const (
+	Debit  = "debit"
+	Credit = "credit"
+)
+
+type Entry struct {
+	Account   string
+	Direction string
+	Amount    model.Money
+	Currency  model.Currency
+}
+
+type UnbalancedPostingError struct {
+	Currency model.Currency
+	Debit    model.Money
+	Credit   model.Money
+}
+
+func (e UnbalancedPostingError) Error() string {
+	return fmt.Sprintf("Posting is not balanced: debit %s, credit %s", e.Debit.Format(e.Currency), e.Credit.Format(e.Currency))
+}
+
+// CurrencyMismatchError is returned when an amount is not in the currency of its account
+type CurrencyMismatchError struct {
+	Account  string
+	Currency model.Currency
+	Amount   model.Currency
+}
+
+func (e CurrencyMismatchError) Error() string {
+	return fmt.Sprintf("Account %s is held in %s, not %s", e.Account, e.Currency, e.Amount)
+}
+
+type AccountNotFoundError struct {
+	Account string
+}
+
+func (e AccountNotFoundError) Error() string {
+	return fmt.Sprintf("Account %s does not exist", e.Account)
+}
+
+// AccountClosedError is returned when money would move in or out of a closed account
+type AccountClosedError struct {
+	Account string
+}
+
+func (e AccountClosedError) Error() string {
+	return fmt.Sprintf("Account %s is closed", e.Account)
+}
+
+type InvalidAmountError struct {
+	Amount model.Money
+}
+
+func (e InvalidAmountError) Error() string {
+	return "Amount of money must be greater than 0"
+}
+
+type SelfTransferError struct{}
+
+func (e SelfTransferError) Error() string {
+	return "Cannot transfer money to the same account"
+}
+
+type InsufficientFundsError struct {
+	Balance model.Money
+	Amount  model.Money
+}
+
+func (e InsufficientFundsError) Error() string {
+	return fmt.Sprintf("Insufficient funds: balance is %s but %s was requested", e.Balance, e.Amount)
+}
+
+// CheckEntries makes sure a posting is balanced: at least two entries, all positive, and in
+// every currency as much debited as credited. Stores call it before writing anything
+func CheckEntries(entries []Entry) error {
+	debit := map[model.Currency]model.Money{}
+	credit := map[model.Currency]model.Money{}
+	for _, entry := range entries {
+		if entry.Amount <= 0 {
+			return fmt.Errorf("journal entry for account %s has non-positive amount %s", entry.Account, entry.Amount)
+		}
+		if entry.Currency == "" {
+			return fmt.Errorf("journal entry for account %s has no currency", entry.Account)
+		}
+
+		if entry.Direction == Debit {
+			debit[entry.Currency] += entry.Amount
+		} else if entry.Direction == Credit {
+			credit[entry.Currency] += entry.Amount
+		} else {
+			return fmt.Errorf("journal entry for account %s has unknown direction %q", entry.Account, entry.Direction)
+		}
+	}
+	if len(entries) < 2 {
+		return UnbalancedPostingError{}
+	}
+	for _, entry := range entries {
+		if debit[entry.Currency] != credit[entry.Currency] {
+			return UnbalancedPostingError{Currency: entry.Currency, Debit: debit[entry.Currency], Credit: credit[entry.Currency]}
+		}
+	}
+
+	return nil
+}
+
+// CheckAmount rejects zero and negative amounts
+func CheckAmount(amount model.Money) error {
+	if amount <= 0 {
+		return InvalidAmountError{Amount: amount}
+	}
+
+	return nil
+}
+
+// CheckTransfer validates what can be checked without looking at the accounts. A transfer
+// between two currencies must carry the rate and the converted amount
+func CheckTransfer(transaction model.Transaction) error {
+	err := CheckAmount(transaction.Amount)
+	if err != nil {
+		return err
+	}
+
+	if transaction.DebitAccount == transaction.CreditAccount {
+		return SelfTransferError{}
+	}
+
+	if transaction.Currency != transaction.CreditCurrency {
+		if transaction.Rate <= 0 || transaction.Rate.Convert(transaction.Amount) != transaction.CreditAmount {
+			return fmt.Errorf("converted amount %s does not match rate %s", transaction.CreditAmount, transaction.Rate)
+		}
+		return CheckAmount(transaction.CreditAmount)
+	}
+
+	if transaction.CreditAmount != transaction.Amount {
+		return fmt.Errorf("credit amount %s differs from amount %s without conversion", transaction.CreditAmount, transaction.Amount)
+	}
+
+	return nil
+}
+
+// TransferEntries are the journal entries of a transfer between two accounts. A converted
+// transfer goes through FXAccount, one leg in each currency
+func TransferEntries(transaction model.Transaction) []Entry {
+	if transaction.Currency == transaction.CreditCurrency {
+		return []Entry{
+			{Account: transaction.DebitAccount, Direction: Debit, Amount: transaction.Amount, Currency: transaction.Currency},
+			{Account: transaction.CreditAccount, Direction: Credit, Amount: transaction.Amount, Currency: transaction.Currency},
+		}
+	}
+
+	return []Entry{
+		{Account: transaction.DebitAccount, Direction: Debit, Amount: transaction.Amount, Currency: transaction.Currency},
+		{Account: FXAccount, Direction: Credit, Amount: transaction.Amount, Currency: transaction.Currency},
+		{Account: FXAccount, Direction: Debit, Amount: transaction.CreditAmount, Currency: transaction.CreditCurrency},
+		{Account: transaction.CreditAccount, Direction: Credit, Amount: transaction.CreditAmount, Currency: transaction.CreditCurrency},
+	}
+}
+
+// TopupEntries move money from the bank's cash account to the customer
+func TopupEntries(account string, amount model.Money, currency model.Currency) []Entry {
+	return []Entry{
+		{Account: CashAccount, Direction: Debit, Amount: amount, Currency: currency},
+		{Account: account, Direction: Credit, Amount: amount, Currency: currency},
+	}
+}
+
+// WithdrawalEntries move money from the customer to the bank's cash account
+func WithdrawalEntries(account string, amount model.Money, currency model.Currency) []Entry {
+	return []Entry{
+		{Account: account, Direction: Debit, Amount: amount, Currency: currency},
+		{Account: CashAccount, Direction: Credit, Amount: amount, Currency: currency},
+	}
+}
+
+// RewardEntries move money the bank gives away from its rewards account to the customer
+func RewardEntries(account string, amount model.Money, currency model.Currency) []Entry {
+	return []Entry{
+		{Account: RewardsAccount, Direction: Debit, Amount: amount, Currency: currency},
+		{Account: account, Direction: Credit, Amount: amount, Currency: currency},
+	}
+}
+
+type Mismatch struct {
+	Account        string
+	Balance        model.Money
+	JournalBalance model.Money
+}
+
+type TransactionFilter struct {
+	Cursor       int64 //Only return transactions older than this ID, 0 for the first page
+	Limit        int
+	From         time.Time
+	To           time.Time
+	Counterparty string
+	Direction    string //"in", "out" or empty for both
+	MinAmount    model.Money
+	MaxAmount    model.Money
+	Search       string
+}
+
+// Matches reports whether one of the account's transactions passes the filter. The Postgres
+// store turns the filter into SQL instead, both must agree
+func (filter TransactionFilter) Matches(account string, transaction model.Transaction) bool {
+	switch filter.Direction {
+	case "in":
+		if transaction.CreditAccount != account {
+			return false
+		}
+	case "out":
+		if transaction.DebitAccount != account {
+			return false
+		}
+	default:
+		if transaction.DebitAccount != account && transaction.CreditAccount != account {
+			return false
+		}
+	}
+
+	if filter.Cursor > 0 && transaction.ID >= filter.Cursor {
+		return false
+	}
+	if !filter.From.IsZero() && transaction.Date.Before(filter.From) {
+		return false
+	}
+	//Dates are stored without time, the whole end day is included
+	if !filter.To.IsZero() && !transaction.Date.Before(filter.To.AddDate(0, 0, 1)) {
+		return false
+	}
+	if filter.Counterparty != "" {
+		counterparty := transaction.CreditAccount
+		if transaction.CreditAccount == account {
+			counterparty = transaction.DebitAccount
+		}
+		if counterparty != filter.Counterparty {
+			return false
+		}
+	}
+	if filter.MinAmount > 0 && transaction.Amount < filter.MinAmount {
+		return false
+	}
+	if filter.MaxAmount > 0 && transaction.Amount > filter.MaxAmount {
+		return false
+	}
+	if filter.Search != "" && !strings.Contains(strings.ToLower(transaction.Description), strings.ToLower(filter.Search)) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,118 @@
+package ledger
+
+import (
+	//Import standard library
+	"testing"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+func TestCheckEntries(t *testing.T) {
+	converted := model.Transaction{
+		DebitAccount: "a", CreditAccount: "b", Amount: 10000, Currency: "USD",
+		CreditAmount: 9200, CreditCurrency: "EUR", Rate: 92000000,
+	}
+
+	tests := map[string]struct {
+		entries []Entry
+		ok      bool
+	}{
+		"transfer": {TransferEntries(model.Transaction{
+			DebitAccount: "a", CreditAccount: "b", Amount: 100, Currency: "USD", CreditAmount: 100, CreditCurrency: "USD",
+		}), true},
+		"converted transfer": {TransferEntries(converted), true},
+		"topup":              {TopupEntries("a", 100, "USD"), true},
+		"single":             {[]Entry{{Account: "a", Direction: Debit, Amount: 100, Currency: "USD"}}, false},
+		"unbalanced": {[]Entry{
+			{Account: "a", Direction: Debit, Amount: 100, Currency: "USD"},
+			{Account: "b", Direction: Credit, Amount: 99, Currency: "USD"},
+		}, false},
+		"balanced across currencies only": {[]Entry{
+			{Account: "a", Direction: Debit, Amount: 100, Currency: "USD"},
+			{Account: "b", Direction: Credit, Amount: 100, Currency: "EUR"},
+		}, false},
+		"no currency": {[]Entry{
+			{Account: "a", Direction: Debit, Amount: 100},
+			{Account: "b", Direction: Credit, Amount: 100},
+		}, false},
+		"zero amount": {[]Entry{
+			{Account: "a", Direction: Debit, Amount: 0, Currency: "USD"},
+			{Account: "b", Direction: Credit, Amount: 0, Currency: "USD"},
+		}, false},
+		"unknown direction": {[]Entry{
+			{Account: "a", Direction: "sideways", Amount: 100, Currency: "USD"},
+			{Account: "b", Direction: Credit, Amount: 100, Currency: "USD"},
+		}, false},
+	}
+
+	for name, test := range tests {
+		err := CheckEntries(test.entries)
+		if (err == nil) != test.ok {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestCheckTransfer(t *testing.T) {
+	err := CheckTransfer(model.Transaction{DebitAccount: "a", CreditAccount: "a", Amount: 100, CreditAmount: 100})
+	if _, ok := err.(SelfTransferError); !ok {
+		t.Errorf("self transfer: err = %v", err)
+	}
+
+	err = CheckTransfer(model.Transaction{DebitAccount: "a", CreditAccount: "b", Amount: -1})
+	if _, ok := err.(InvalidAmountError); !ok {
+		t.Errorf("negative amount: err = %v", err)
+	}
+
+	//The converted amount must be the one the rate gives
+	converted := model.Transaction{
+		DebitAccount: "a", CreditAccount: "b", Amount: 10000, Currency: "USD",
+		CreditAmount: 9200, CreditCurrency: "EUR", Rate: 92000000,
+	}
+	err = CheckTransfer(converted)
+	if err != nil {
+		t.Errorf("converted transfer: err = %v", err)
+	}
+
+	converted.CreditAmount = 9300
+	err = CheckTransfer(converted)
+	if err == nil {
+		t.Error("converted transfer with a wrong amount was accepted")
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	transaction := model.Transaction{
+		ID: 5, Date: day, DebitAccount: "a", CreditAccount: "b", Amount: 1000, Description: "Rent for May",
+	}
+
+	tests := map[string]struct {
+		account string
+		filter  TransactionFilter
+		want    bool
+	}{
+		"no filter":              {"a", TransactionFilter{}, true},
+		"other account":          {"c", TransactionFilter{}, false},
+		"incoming for receiver":  {"b", TransactionFilter{Direction: "in"}, true},
+		"incoming for payer":     {"a", TransactionFilter{Direction: "in"}, false},
+		"before cursor":          {"a", TransactionFilter{Cursor: 6}, true},
+		"at cursor":              {"a", TransactionFilter{Cursor: 5}, false},
+		"to includes whole day":  {"a", TransactionFilter{To: day}, true},
+		"from after":             {"a", TransactionFilter{From: day.AddDate(0, 0, 1)}, false},
+		"counterparty":           {"a", TransactionFilter{Counterparty: "b"}, true},
+		"wrong counterparty":     {"a", TransactionFilter{Counterparty: "c"}, false},
+		"amount in range":        {"a", TransactionFilter{MinAmount: 1000, MaxAmount: 1000}, true},
+		"amount below min":       {"a", TransactionFilter{MinAmount: 1001}, false},
+		"search ignores case":    {"a", TransactionFilter{Search: "rent"}, true},
+		"search without a match": {"a", TransactionFilter{Search: "salary"}, false},
+	}
+
+	for name, test := range tests {
+		if got := test.filter.Matches(test.account, transaction); got != test.want {
+			t.Errorf("%s: Matches = %v, want %v", name, got, test.want)
+		}
+	}
+}
@@ -5,44 +5,152 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	//Import user's defined package
-	"gobank/auth"
-	"gobank/user"
-	"gobank/utility"
+	"gobank-server/config"
+	"gobank-server/exp"
+	"gobank-server/fx"
+	"gobank-server/migration"
+	"gobank-server/perks"
+	"gobank-server/scheduler"
+	"gobank-server/server"
+	"gobank-server/store/postgres"
+	"gobank-server/user"
+	"gobank-server/utility"
 )
 
 func main() {
+	//Load config from defaults, config file, environment variables and flags
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Println("Error at: main -> Invalid configuration")
+		fmt.Println(err)
+		return
+	}
+
+	//Load token signing keys
+	err = utility.LoadSigningKeys(cfg.Token)
+	if err != nil {
+		fmt.Println("Error at: main -> Error loading token signing keys")
+		fmt.Println(err)
+		return
+	}
+
 	//Connect to database
-	_, err := utility.ConnectDB("gobank")
+	db, err := utility.ConnectDB(cfg.Database)
 	if err != nil {
 		fmt.Println("Error at: main -> Error connecting to database")
 		fmt.Println(err)
 		return
 	}
 
-	//Set up the initial table in database
-	utility.InitializeTable()
+	//Subcommands run against the database and exit
+	if len(args) > 0 {
+		if args[0] == "migrate" {
+			runMigrate(db, args[1:])
+			return
+		}
+
+		fmt.Printf("Unknown command %q\n", args[0])
+		return
+	}
+
+	//Bring the schema up to date, only one instance migrates at a time
+	applied, err := migration.Up(db)
+	if err != nil {
+		fmt.Println("Error at: main -> Error migrating database")
+		fmt.Println(err)
+		return
+	}
+	for _, m := range applied {
+		fmt.Printf("Applied migration %d_%s\n", m.Version, m.Name)
+	}
+
+	stores := postgres.New(db, cfg.Account.Prefix())
+
+	//Check that balances agree with the ledger
+	mismatches, err := stores.Ledger.Reconcile()
+	if err != nil {
+		fmt.Println("Error at: main -> Error reconciling ledger")
+		fmt.Println(err)
+		return
+	}
+	for _, mismatch := range mismatches {
+		fmt.Printf("Ledger mismatch: account %s has balance %s but journal says %s\n", mismatch.Account, mismatch.Balance, mismatch.JournalBalance)
+	}
+
+	//Load exchange rates, the built-in ones stand in when no file is configured
+	rates := fx.DefaultRates()
+	if cfg.FX.RatesFile != "" {
+		rates, err = fx.LoadFile(cfg.FX.RatesFile)
+		if err != nil {
+			fmt.Println("Error at: main -> Error loading FX rates")
+			fmt.Println(err)
+			return
+		}
+	}
+	exchange := fx.Exchange{Rates: rates, QuoteTTL: time.Duration(cfg.FX.QuoteTTL), Fee: cfg.FX.Fee}
 
-	//Setup mux and handle function
-	mux := http.NewServeMux()
+	//Load EXP rules, the built-in ones stand in when no file is configured
+	expRules := exp.DefaultRules()
+	if cfg.Exp.RulesFile != "" {
+		expRules, err = exp.LoadRules(cfg.Exp.RulesFile)
+		if err != nil {
+			fmt.Println("Error at: main -> Error loading EXP rules")
+			fmt.Println(err)
+			return
+		}
+	}
 
-	//mux for auth (both user and admin)
-	mux.HandleFunc("/register", auth.Register)
-	mux.HandleFunc("/login", auth.Login)
-	mux.HandleFunc("/refresh", auth.SendCredential)
-	mux.HandleFunc("/update-password", auth.ChangePassword)
+	//Load the perks of each level, the built-in tiers stand in when no file is configured
+	tiers := perks.DefaultTable()
+	if cfg.Perks.TiersFile != "" {
+		tiers, err = perks.LoadTable(cfg.Perks.TiersFile)
+		if err != nil {
+			fmt.Println("Error at: main -> Error loading perks tiers")
+			fmt.Println(err)
+			return
+		}
+	}
+
+	levels, err := utility.NewLevelCurve(cfg.Level)
+	if err != nil {
+		fmt.Println("Error at: main -> Error building level curve")
+		fmt.Println(err)
+		return
+	}
+
+	serverConfig := server.Config{
+		Exchange:   exchange,
+		CoolingOff: user.CoolingOff{Period: time.Duration(cfg.Payees.CoolingOff), Limit: cfg.Payees.CoolingOffLimit},
+		Rewards: user.Rewards{
+			Expiry:      time.Duration(cfg.Rewards.Expiry),
+			MinTransfer: cfg.Rewards.MinTransfer,
+			PointValue:  cfg.Rewards.PointValue,
+		},
+		ExpRules: expRules,
+		Levels:   levels,
+		Tiers:    tiers,
+
+		IdempotencyTTL: time.Duration(cfg.Server.IdempotencyTTL),
+	}
 
-	//mux for user
-	mux.HandleFunc("/topup", user.Topup)
-	mux.HandleFunc("/withdraw", user.Withdraw)
-	mux.HandleFunc("/fullname", user.GetFullname) //Find account's fullname based on account number
-	mux.HandleFunc("/transaction", user.MakeTransaction)
-	mux.HandleFunc("/transactions", user.GetTransactions)
+	//Scheduled transfers are made through the same checks as the ones sent by clients
+	transfers := server.UserHandler(stores, serverConfig)
+	go scheduler.Scheduler{
+		Schedules:   stores.Schedules,
+		Transfer:    transfers.Transfer,
+		Notifier:    scheduler.LogNotifier{},
+		Interval:    time.Duration(cfg.Scheduler.Interval),
+		RetryDelay:  time.Duration(cfg.Scheduler.RetryDelay),
+		MaxAttempts: cfg.Scheduler.MaxAttempts,
+	}.Run()
 
 	//Start server
-	fmt.Println("Server start at http://localhost:8800")
-	err = http.ListenAndServe("localhost:8800", mux)
+	fmt.Printf("Server start at http://%s\n", cfg.Server.Addr)
+	err = http.ListenAndServe(cfg.Server.Addr, server.New(stores, serverConfig))
 	if err != nil {
 		fmt.Println("Error at main -> Error starting server")
 		log.Fatal(err)
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	//Import standard library
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+// IdempotencyHeader carries the key a client sends with a request it may retry
+const IdempotencyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency answers a request sent again with the same Idempotency-Key from the stored
+// response, so a client that timed out after the server committed can retry without moving
+// the money twice. Keys belong to the caller and are forgotten after TTL
+type Idempotency struct {
+	Keys store.IdempotencyStore
+	TTL  time.Duration
+}
+
+// Wrap makes handler idempotent for requests that carry a key, requests without one go straight
+// through. It must run inside Protect since keys are kept per caller
+func (i Idempotency) Wrap(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyHeader)
+		if key == "" {
+			handler(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Idempotency key must have at most 255 characters")
+			return
+		}
+
+		//Read the body so it can be hashed, then hand it to the handler again
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			utility.InternalError(w, "Error at: Idempotency -> Error reading request body", err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		hash := requestHash(r, body)
+		record, reserved, err := i.Keys.ReserveKey(store.IdempotencyRecord{
+			Customer:    Claims(r).ID,
+			Key:         key,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(i.TTL),
+		})
+		if err != nil {
+			utility.InternalError(w, "Error at: Idempotency -> Error reserving idempotency key", err)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != hash:
+				utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeIdempotencyKeyReused, "This idempotency key was already used for another request")
+			case record.Status == 0:
+				utility.WriteProblem(w, http.StatusConflict, utility.CodeIdempotencyInProgress, "A request with this idempotency key is still in progress")
+			default:
+				//Replay the stored response
+				if record.ContentType != "" {
+					w.Header().Set("Content-Type", record.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(record.Status)
+				w.Write(record.Body)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+
+		//Server errors leave nothing behind, the client may retry them with the same key
+		if recorder.status >= http.StatusInternalServerError {
+			err = i.Keys.ReleaseKey(record.Customer, record.Key)
+			if err != nil {
+				fmt.Println("Error at: Idempotency -> Error releasing idempotency key")
+				fmt.Println(err)
+			}
+			return
+		}
+
+		record.Status = recorder.status
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.Body = recorder.body.Bytes()
+		err = i.Keys.CompleteKey(record)
+		if err != nil {
+			fmt.Println("Error at: Idempotency -> Error storing response")
+			fmt.Println(err)
+		}
+	}
+}
+
+// requestHash ties a key to one request: same route, same query and same body
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes the response on to the client and keeps a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
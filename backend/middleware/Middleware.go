@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	//Import standard library
+	"context"
+	"net/http"
+	"slices"
+
+	//Import user's defined package
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+// Permission lists what a caller needs to reach a route. The caller must have one of Roles
+// (any role when empty) and every one of Scopes
+type Permission struct {
+	Roles  []string
+	Scopes []string
+}
+
+// Auth checks tokens against the session store, so revoked tokens are refused
+type Auth struct {
+	Sessions store.SessionStore
+}
+
+type claimsKey struct{}
+
+// Protect authenticates the request, enforces the route's permission and hands the verified
+// claims to the handler through the request context
+func (a Auth) Protect(handler http.HandlerFunc, permission Permission) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//Verify token
+		token := r.Header.Get("token")
+		err := utility.VerifyToken(a.Sessions, token)
+		if err != nil {
+			if _, ok := err.(utility.ExpiredTokenError); ok {
+				utility.WriteProblem(w, http.StatusUnauthorized, utility.CodeTokenExpired, "Your token has expired")
+				return
+			}
+
+			if _, ok := err.(utility.TokenTamperedError); ok {
+				utility.WriteProblem(w, http.StatusUnauthorized, utility.CodeTokenInvalid, "Cannot verify who you are! Your token may have been tampered")
+				return
+			}
+
+			/*Other errors*/
+			utility.InternalError(w, "Error at: Protect -> Error verifying token", err)
+			return
+		}
+
+		//Extracting claims
+		claims, err := utility.ExtractingClaims(token)
+		if err != nil {
+			utility.InternalError(w, "Error at: Protect -> Error extracting claims", err)
+			return
+		}
+
+		//Check if the requester has authority to perform this action
+		if !permission.allows(claims) {
+			utility.WriteProblem(w, http.StatusForbidden, utility.CodeForbidden, "You have no authority to perform this action")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), claimsKey{}, claims)
+		handler(w, r.WithContext(ctx))
+	})
+}
+
+// Claims returns the claims of the caller. It is only meaningful in handlers wrapped by Protect
+func Claims(r *http.Request) utility.Claim {
+	claims, _ := r.Context().Value(claimsKey{}).(utility.Claim)
+	return claims
+}
+
+func (p Permission) allows(claims utility.Claim) bool {
+	if len(p.Roles) > 0 && !slices.Contains(p.Roles, claims.Role) {
+		return false
+	}
+
+	scopes := claims.Scopes()
+	for _, scope := range p.Scopes {
+		if !slices.Contains(scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,296 @@
+package migration
+
+import (
+	//Import standard library
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations are embedded in the binary, each version has an up and a down script:
+//
+//	sql/0002_constraints_and_indexes.up.sql
+//	sql/0002_constraints_and_indexes.down.sql
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+// Any constant works as long as every instance of the server uses the same one
+const lockID = 7_460_254_321
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type State struct {
+	Migration
+	AppliedAt sql.NullTime
+}
+
+// Load reads every embedded migration, sorted by version
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		//File name is <version>_<name>.<up|down>.sql
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		base, direction, found := strings.Cut(base, ".")
+		if !found || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s must end with .up.sql or .down.sql", entry.Name())
+		}
+
+		prefix, name, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s must start with a version number", entry.Name())
+		}
+
+		script, err := files.ReadFile("sql/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, name)
+		}
+
+		if direction == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns the ones it applied
+func Up(db *sql.DB) ([]Migration, error) {
+	var applied []Migration
+	err := withLock(db, func(conn *sql.Conn) error {
+		migrations, versions, err := pending(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if versions[migration.Version] {
+				continue
+			}
+
+			err = run(conn, migration.Up, func(tx *sql.Tx) error {
+				_, err := tx.Exec(
+					"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)",
+					migration.Version, migration.Name, time.Now(),
+				)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("applying migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the latest applied migration. It returns false when there is nothing to roll back
+func Down(db *sql.DB) (Migration, bool, error) {
+	var (
+		rolledBack Migration
+		found      bool
+	)
+	err := withLock(db, func(conn *sql.Conn) error {
+		migrations, versions, err := pending(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if versions[migrations[i].Version] {
+				rolledBack, found = migrations[i], true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+
+		err = run(conn, rolledBack.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1", rolledBack.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("rolling back migration %d_%s: %w", rolledBack.Version, rolledBack.Name, err)
+		}
+
+		return nil
+	})
+
+	return rolledBack, found, err
+}
+
+// Status lists every known migration and when it was applied
+func Status(db *sql.DB) ([]State, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	err = createTable(db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := map[int]time.Time{}
+	for rows.Next() {
+		var (
+			version int
+			at      time.Time
+		)
+		err = rows.Scan(&version, &at)
+		if err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	states := make([]State, len(migrations))
+	for i, migration := range migrations {
+		states[i].Migration = migration
+		if at, ok := appliedAt[migration.Version]; ok {
+			states[i].AppliedAt = sql.NullTime{Time: at, Valid: true}
+		}
+	}
+
+	return states, nil
+}
+
+// withLock holds a PostgreSQL advisory lock while fn runs, so when several instances start
+// together only one migrates and the others wait for it. Advisory locks belong to a
+// connection, so everything runs on the same one
+func withLock(db *sql.DB, fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID)
+	if err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+
+	err = createTable(conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func createTable(db execer) error {
+	_, err := db.ExecContext(context.Background(), `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(100),
+			applied_at TIMESTAMP
+		)
+	`)
+	return err
+}
+
+// pending returns every known migration and the versions already applied
+func pending(conn *sql.Conn) ([]Migration, map[int]bool, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := conn.QueryContext(context.Background(), "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	versions := map[int]bool{}
+	for rows.Next() {
+		var version int
+		err = rows.Scan(&version)
+		if err != nil {
+			return nil, nil, err
+		}
+		versions[version] = true
+	}
+
+	return migrations, versions, rows.Err()
+}
+
+// run executes a script and records it in one database transaction, so a failed migration
+// leaves nothing half applied
+func run(conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(script)
+	if err != nil {
+		return err
+	}
+
+	err = record(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
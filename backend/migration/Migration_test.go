@@ -0,0 +1,25 @@
+package migration
+
+import (
+	//Import standard library
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("no migration is embedded")
+	}
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %d_%s, want version %d: versions must have no gap", migration.Version, migration.Name, i+1)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %d_%s is missing a script", migration.Version, migration.Name)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS postings;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS admins;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,69 @@
+-- Tables that used to be created by utility.InitializeTable. IF NOT EXISTS lets databases
+-- created before migrations adopt this version without losing data
+CREATE TABLE IF NOT EXISTS users (
+	id VARCHAR(10) PRIMARY KEY,
+	email VARCHAR(30),
+	password VARCHAR(255),
+	fullname VARCHAR(30),
+	balance DECIMAL,
+	exp INT,
+	state VARCHAR(10)
+);
+
+CREATE TABLE IF NOT EXISTS admins (
+	id SERIAL PRIMARY KEY,
+	email VARCHAR(30),
+	password VARCHAR(255),
+	fullname VARCHAR(30)
+);
+
+-- Widen password columns created before argon2id hashes
+ALTER TABLE users ALTER COLUMN password TYPE VARCHAR(255);
+ALTER TABLE admins ALTER COLUMN password TYPE VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS transactions (
+	id SERIAL PRIMARY KEY,
+	date DATE,
+	debit VARCHAR(10),
+	credit VARCHAR(10),
+	beneficiary VARCHAR(50),
+	amount DECIMAL,
+	description VARCHAR(255)
+);
+
+-- Transactions can be transfer, topup or withdrawal
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS type VARCHAR(20) DEFAULT 'transfer';
+
+-- Group of balanced journal entries
+CREATE TABLE IF NOT EXISTS postings (
+	id SERIAL PRIMARY KEY,
+	kind VARCHAR(20),
+	transaction_id INT,
+	created_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+	id SERIAL PRIMARY KEY,
+	posting_id INT,
+	account VARCHAR(10),
+	direction VARCHAR(6),
+	amount DECIMAL
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id VARCHAR(32) PRIMARY KEY,
+	account VARCHAR(10),
+	role VARCHAR(10),
+	refresh_hash VARCHAR(64),
+	device VARCHAR(100),
+	created_at TIMESTAMP,
+	last_used_at TIMESTAMP,
+	expires_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
+
+-- Access tokens killed before they expire
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti VARCHAR(32) PRIMARY KEY,
+	expires_at TIMESTAMP
+);
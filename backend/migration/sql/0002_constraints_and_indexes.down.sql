@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS revoked_tokens_expires_at_idx;
+DROP INDEX IF EXISTS sessions_account_idx;
+DROP INDEX IF EXISTS journal_entries_account_idx;
+DROP INDEX IF EXISTS journal_entries_posting_id_idx;
+DROP INDEX IF EXISTS postings_transaction_id_idx;
+DROP INDEX IF EXISTS transactions_credit_idx;
+DROP INDEX IF EXISTS transactions_debit_idx;
+
+ALTER TABLE journal_entries DROP CONSTRAINT IF EXISTS journal_entries_posting_id_fkey;
+ALTER TABLE postings DROP CONSTRAINT IF EXISTS postings_transaction_id_fkey;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_credit_fkey;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_debit_fkey;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_balance_non_negative;
+ALTER TABLE admins DROP CONSTRAINT IF EXISTS admins_email_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
@@ -0,0 +1,27 @@
+-- One account per email
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE admins ADD CONSTRAINT admins_email_key UNIQUE (email);
+
+-- Money can never go below zero, the ledger already refuses it
+ALTER TABLE users ADD CONSTRAINT users_balance_non_negative CHECK (balance >= 0);
+
+-- Both sides of a transaction are user accounts. Topups have no debit account and
+-- withdrawals have no credit account, so the columns stay nullable
+ALTER TABLE transactions
+	ADD CONSTRAINT transactions_debit_fkey FOREIGN KEY (debit) REFERENCES users (id);
+ALTER TABLE transactions
+	ADD CONSTRAINT transactions_credit_fkey FOREIGN KEY (credit) REFERENCES users (id);
+
+ALTER TABLE postings
+	ADD CONSTRAINT postings_transaction_id_fkey FOREIGN KEY (transaction_id) REFERENCES transactions (id);
+ALTER TABLE journal_entries
+	ADD CONSTRAINT journal_entries_posting_id_fkey FOREIGN KEY (posting_id) REFERENCES postings (id);
+
+-- Transaction history is read per account, newest first
+CREATE INDEX transactions_debit_idx ON transactions (debit, id DESC);
+CREATE INDEX transactions_credit_idx ON transactions (credit, id DESC);
+CREATE INDEX postings_transaction_id_idx ON postings (transaction_id);
+CREATE INDEX journal_entries_posting_id_idx ON journal_entries (posting_id);
+CREATE INDEX journal_entries_account_idx ON journal_entries (account);
+CREATE INDEX sessions_account_idx ON sessions (account, role);
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
@@ -0,0 +1,8 @@
+-- Fails once an account number longer than 10 characters has been given out
+ALTER TABLE sessions ALTER COLUMN account TYPE VARCHAR(10);
+ALTER TABLE journal_entries ALTER COLUMN account TYPE VARCHAR(10);
+ALTER TABLE transactions ALTER COLUMN credit TYPE VARCHAR(10);
+ALTER TABLE transactions ALTER COLUMN debit TYPE VARCHAR(10);
+ALTER TABLE users ALTER COLUMN id TYPE VARCHAR(10);
+
+DROP SEQUENCE IF EXISTS account_serial_seq;
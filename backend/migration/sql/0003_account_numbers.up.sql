@@ -0,0 +1,12 @@
+-- Account serials come from a sequence, so two registrations at the same time never get the
+-- same number and numbers of deleted accounts are never given out again. Legacy numbers are
+-- 9 digits and new ones at least 11 (serial + check digits), so the two can never collide
+CREATE SEQUENCE IF NOT EXISTS account_serial_seq
+	MINVALUE 100000000 MAXVALUE 999999999 START WITH 100000000 NO CYCLE;
+
+-- Room for the branch and product prefix and the check digits
+ALTER TABLE users ALTER COLUMN id TYPE VARCHAR(20);
+ALTER TABLE transactions ALTER COLUMN debit TYPE VARCHAR(20);
+ALTER TABLE transactions ALTER COLUMN credit TYPE VARCHAR(20);
+ALTER TABLE journal_entries ALTER COLUMN account TYPE VARCHAR(20);
+ALTER TABLE sessions ALTER COLUMN account TYPE VARCHAR(20);
@@ -0,0 +1,17 @@
+-- Every account's money is folded back into its customer's single balance. Fails once money
+-- has moved through an account other than a customer's checking account, since
+-- transactions can no longer point at it
+ALTER TABLE users ADD COLUMN balance DECIMAL DEFAULT 0;
+UPDATE users SET balance = (
+	SELECT COALESCE(SUM(balance), 0) FROM accounts WHERE accounts.customer = users.id
+);
+ALTER TABLE users ADD CONSTRAINT users_balance_non_negative CHECK (balance >= 0);
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_debit_fkey;
+ALTER TABLE transactions DROP CONSTRAINT transactions_credit_fkey;
+ALTER TABLE transactions
+	ADD CONSTRAINT transactions_debit_fkey FOREIGN KEY (debit) REFERENCES users (id);
+ALTER TABLE transactions
+	ADD CONSTRAINT transactions_credit_fkey FOREIGN KEY (credit) REFERENCES users (id);
+
+DROP TABLE accounts;
@@ -0,0 +1,29 @@
+-- A customer can hold several accounts. Balances move from users to accounts, and every
+-- existing customer's number becomes the number of their checking account
+CREATE TABLE accounts (
+	id VARCHAR(20) PRIMARY KEY,
+	customer VARCHAR(20) NOT NULL REFERENCES users (id),
+	type VARCHAR(10) NOT NULL,
+	name VARCHAR(30) NOT NULL DEFAULT '',
+	balance DECIMAL NOT NULL DEFAULT 0,
+	state VARCHAR(10) NOT NULL DEFAULT 'open',
+	opened_at TIMESTAMP NOT NULL,
+	closed_at TIMESTAMP,
+	CONSTRAINT accounts_balance_non_negative CHECK (balance >= 0)
+);
+
+INSERT INTO accounts (id, customer, type, balance, opened_at)
+SELECT id, id, 'checking', COALESCE(balance, 0), NOW() FROM users;
+
+CREATE INDEX accounts_customer_idx ON accounts (customer);
+
+-- Both sides of a transaction are now accounts
+ALTER TABLE transactions DROP CONSTRAINT transactions_debit_fkey;
+ALTER TABLE transactions DROP CONSTRAINT transactions_credit_fkey;
+ALTER TABLE transactions
+	ADD CONSTRAINT transactions_debit_fkey FOREIGN KEY (debit) REFERENCES accounts (id);
+ALTER TABLE transactions
+	ADD CONSTRAINT transactions_credit_fkey FOREIGN KEY (credit) REFERENCES accounts (id);
+
+ALTER TABLE users DROP CONSTRAINT users_balance_non_negative;
+ALTER TABLE users DROP COLUMN balance;
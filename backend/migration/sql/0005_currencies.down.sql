@@ -0,0 +1,12 @@
+-- Balances held in other currencies are kept as they are and read as US dollars afterwards,
+-- only roll back before any account is opened in another currency
+ALTER TABLE transactions DROP COLUMN quote_id;
+ALTER TABLE transactions DROP COLUMN rate;
+ALTER TABLE transactions DROP COLUMN credit_currency;
+ALTER TABLE transactions DROP COLUMN credit_amount;
+
+DROP TABLE fx_quotes;
+
+ALTER TABLE transactions DROP COLUMN currency;
+ALTER TABLE journal_entries DROP COLUMN currency;
+ALTER TABLE accounts DROP COLUMN currency;
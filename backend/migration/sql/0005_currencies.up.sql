@@ -0,0 +1,28 @@
+-- Every account holds one ISO 4217 currency. Money already in the bank is US dollars
+ALTER TABLE accounts ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE journal_entries ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE transactions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- A quote locks a rate for one transfer until it expires, used_at is set by that transfer
+CREATE TABLE fx_quotes (
+	id VARCHAR(32) PRIMARY KEY,
+	customer VARCHAR(20) NOT NULL REFERENCES users (id),
+	debit VARCHAR(20) NOT NULL REFERENCES accounts (id),
+	credit VARCHAR(20) NOT NULL REFERENCES accounts (id),
+	from_currency CHAR(3) NOT NULL,
+	to_currency CHAR(3) NOT NULL,
+	rate DECIMAL NOT NULL,
+	amount DECIMAL NOT NULL,
+	converted DECIMAL NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP
+);
+
+-- A converted transfer credits another amount in another currency, at the quoted rate
+ALTER TABLE transactions ADD COLUMN credit_amount DECIMAL;
+ALTER TABLE transactions ADD COLUMN credit_currency CHAR(3);
+ALTER TABLE transactions ADD COLUMN rate DECIMAL;
+ALTER TABLE transactions ADD COLUMN quote_id VARCHAR(32) REFERENCES fx_quotes (id);
+UPDATE transactions SET credit_amount = amount, credit_currency = currency;
+ALTER TABLE transactions ALTER COLUMN credit_amount SET NOT NULL;
+ALTER TABLE transactions ALTER COLUMN credit_currency SET NOT NULL;
@@ -0,0 +1,16 @@
+-- The response to every money-moving request sent with an Idempotency-Key header, so a retried
+-- request is answered from here instead of moving the money twice. status is 0 while the first
+-- request is still running
+CREATE TABLE idempotency_keys (
+	customer VARCHAR(20) NOT NULL REFERENCES users (id),
+	key VARCHAR(255) NOT NULL,
+	request_hash CHAR(64) NOT NULL,
+	status INT NOT NULL DEFAULT 0,
+	content_type VARCHAR(100) NOT NULL DEFAULT '',
+	body BYTEA,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (customer, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
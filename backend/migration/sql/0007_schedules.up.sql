@@ -0,0 +1,23 @@
+-- Transfers the bank makes for a customer later, once or as a standing order. next_run is the
+-- day of the next transfer, retry_at is set while a failed run is tried again
+CREATE TABLE schedules (
+	id BIGSERIAL PRIMARY KEY,
+	customer VARCHAR(20) NOT NULL REFERENCES users (id),
+	debit VARCHAR(20) NOT NULL REFERENCES accounts (id),
+	credit VARCHAR(20) NOT NULL REFERENCES accounts (id),
+	amount DECIMAL NOT NULL,
+	currency CHAR(3) NOT NULL,
+	description VARCHAR(255) NOT NULL DEFAULT '',
+	frequency VARCHAR(20) NOT NULL,
+	day INT NOT NULL DEFAULT 0,
+	next_run TIMESTAMP NOT NULL,
+	retry_at TIMESTAMP,
+	attempts INT NOT NULL DEFAULT 0,
+	state VARCHAR(10) NOT NULL DEFAULT 'active',
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT schedules_amount_positive CHECK (amount > 0)
+);
+
+CREATE INDEX schedules_customer_idx ON schedules (customer);
+CREATE INDEX schedules_due_idx ON schedules (next_run) WHERE state = 'active';
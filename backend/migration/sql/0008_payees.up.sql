@@ -0,0 +1,15 @@
+-- Accounts a customer saved to send money to. name is the owner's name when the payee was
+-- saved. Until cooling_off_until at most a limited amount can be sent to a new payee,
+-- cooling_off_sent is what has been sent so far
+CREATE TABLE payees (
+	id BIGSERIAL PRIMARY KEY,
+	customer VARCHAR(20) NOT NULL REFERENCES users (id),
+	nickname VARCHAR(30) NOT NULL,
+	account VARCHAR(20) NOT NULL REFERENCES accounts (id),
+	name VARCHAR(30) NOT NULL,
+	added_at TIMESTAMP NOT NULL,
+	last_used_at TIMESTAMP,
+	cooling_off_until TIMESTAMP NOT NULL,
+	cooling_off_sent DECIMAL NOT NULL DEFAULT 0,
+	CONSTRAINT payees_customer_account_key UNIQUE (customer, account)
+);
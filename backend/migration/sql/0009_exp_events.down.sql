@@ -0,0 +1 @@
+DROP TABLE exp_events;
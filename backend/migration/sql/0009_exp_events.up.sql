@@ -0,0 +1,12 @@
+-- Every award of experience points, users.exp is the sum of a user's points so it can be
+-- audited against this table
+CREATE TABLE exp_events (
+	id BIGSERIAL PRIMARY KEY,
+	customer VARCHAR(20) NOT NULL REFERENCES users (id),
+	kind VARCHAR(20) NOT NULL,
+	points INTEGER NOT NULL CHECK (points > 0),
+	reference VARCHAR(40) NOT NULL,
+	at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX exp_events_customer_at_idx ON exp_events (customer, at);
@@ -0,0 +1 @@
+ALTER TABLE fx_quotes DROP COLUMN fee;
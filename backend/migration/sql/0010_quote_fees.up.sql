@@ -0,0 +1,2 @@
+-- Conversion fee taken off a quote's rate, after the customer's tier discount
+ALTER TABLE fx_quotes ADD COLUMN fee DECIMAL NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+-- Achievements customers unlocked. Their definitions live in the achievement package, which
+-- refers to each one by the ID stored in achievement
+CREATE TABLE achievements (
+	customer VARCHAR(20) NOT NULL REFERENCES users (id),
+	achievement VARCHAR(40) NOT NULL,
+	unlocked_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (customer, achievement)
+);
@@ -0,0 +1 @@
+DROP TABLE reward_entries;
@@ -0,0 +1,14 @@
+-- Reward points customers earned and redeemed, kept apart from their accounts' balance. Earned
+-- points are positive and expire at expires_at, redeemed ones are negative. Expired points are
+-- not written, they are worked out by replaying a customer's entries
+CREATE TABLE reward_entries (
+	id BIGSERIAL PRIMARY KEY,
+	customer VARCHAR(20) NOT NULL REFERENCES users (id),
+	kind VARCHAR(10) NOT NULL CHECK (kind IN ('earn', 'redeem')),
+	points INTEGER NOT NULL CHECK (points <> 0),
+	reference VARCHAR(40) NOT NULL,
+	at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP
+);
+
+CREATE INDEX reward_entries_customer_at_idx ON reward_entries (customer, at);
@@ -0,0 +1,4 @@
+-- A scheduled transfer is stamped with its schedule's run key, so a run made twice (two servers,
+-- or a crash before the schedule was saved) breaks the constraint and is rolled back
+ALTER TABLE transactions ADD COLUMN schedule_run VARCHAR(40);
+ALTER TABLE transactions ADD CONSTRAINT transactions_schedule_run_key UNIQUE (schedule_run);
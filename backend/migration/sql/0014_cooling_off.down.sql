@@ -0,0 +1,11 @@
+ALTER TABLE payees ADD COLUMN cooling_off_until TIMESTAMP;
+ALTER TABLE payees ADD COLUMN cooling_off_sent DECIMAL NOT NULL DEFAULT 0;
+
+UPDATE payees SET cooling_off_until = cooling_off.until, cooling_off_sent = cooling_off.sent
+FROM cooling_off
+WHERE cooling_off.customer = payees.customer AND cooling_off.account = payees.account;
+
+UPDATE payees SET cooling_off_until = added_at WHERE cooling_off_until IS NULL;
+ALTER TABLE payees ALTER COLUMN cooling_off_until SET NOT NULL;
+
+DROP TABLE cooling_off;
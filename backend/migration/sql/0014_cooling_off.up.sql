@@ -0,0 +1,15 @@
+-- Cooling-off is kept by the account paid, not by payee, so deleting a payee or never saving
+-- the account does not lift the limit. It starts when the customer first saves or pays the account
+CREATE TABLE cooling_off (
+	customer VARCHAR(20) NOT NULL REFERENCES users (id),
+	account VARCHAR(20) NOT NULL REFERENCES accounts (id),
+	until TIMESTAMP NOT NULL,
+	sent DECIMAL NOT NULL DEFAULT 0,
+	PRIMARY KEY (customer, account)
+);
+
+INSERT INTO cooling_off (customer, account, until, sent)
+SELECT customer, account, cooling_off_until, cooling_off_sent FROM payees;
+
+ALTER TABLE payees DROP COLUMN cooling_off_until;
+ALTER TABLE payees DROP COLUMN cooling_off_sent;
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// An account number is an optional prefix (branch then product code), a serial number that is
+// never given out twice, and two ISO 7064 MOD 97-10 check digits, the scheme IBANs use. With
+// branch 01 and product 01, serial 100000042 becomes:
+//
+//	01 01 100000042 97
+//
+// Numbers given out before check digits existed are 9 digits long and are still accepted
+const (
+	SerialDigits = 9
+	MinSerial    = 100000000
+	MaxSerial    = 999999999
+
+	legacyDigits    = 9
+	maxPrefixDigits = 6
+)
+
+type InvalidAccountNumberError struct {
+	Number string
+	Reason string
+}
+
+func (e InvalidAccountNumberError) Error() string {
+	return fmt.Sprintf("Invalid account number %q: %s", e.Number, e.Reason)
+}
+
+// NewAccountNumber appends the check digits to prefix + serial
+func NewAccountNumber(prefix string, serial int64) (string, error) {
+	if !isDigits(prefix) || len(prefix) > maxPrefixDigits {
+		return "", fmt.Errorf("account number prefix %q must be at most %d digits", prefix, maxPrefixDigits)
+	}
+	if serial < MinSerial || serial > MaxSerial {
+		return "", fmt.Errorf("account serial %d is out of range, no account number left", serial)
+	}
+
+	body := prefix + strconv.FormatInt(serial, 10)
+	check := 98 - mod97(body+"00")
+	return fmt.Sprintf("%s%02d", body, check), nil
+}
+
+// ValidateAccountNumber catches typos before the number is looked up: a single wrong digit or
+// two swapped digits always break the check
+func ValidateAccountNumber(number string) error {
+	if number == "" {
+		return InvalidAccountNumberError{Number: number, Reason: "account number is empty"}
+	}
+	if !isDigits(number) {
+		return InvalidAccountNumberError{Number: number, Reason: "account number must only contain digits"}
+	}
+
+	//Legacy numbers have no check digits
+	if len(number) == legacyDigits && number[0] != '0' {
+		return nil
+	}
+
+	if len(number) < SerialDigits+2 || len(number) > maxPrefixDigits+SerialDigits+2 {
+		return InvalidAccountNumberError{Number: number, Reason: "account number has the wrong length"}
+	}
+	if mod97(number) != 1 {
+		return InvalidAccountNumberError{Number: number, Reason: "check digits do not match"}
+	}
+
+	return nil
+}
+
+// mod97 computes the remainder digit by digit, the number does not fit in an int64
+func mod97(digits string) int {
+	remainder := 0
+	for _, digit := range digits {
+		remainder = (remainder*10 + int(digit-'0')) % 97
+	}
+	return remainder
+}
+
+func isDigits(text string) bool {
+	return strings.Trim(text, "0123456789") == ""
+}
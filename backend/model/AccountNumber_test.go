@@ -0,0 +1,64 @@
+package model
+
+import (
+	//Import standard library
+	"testing"
+)
+
+func TestNewAccountNumber(t *testing.T) {
+	tests := []struct {
+		prefix string
+		serial int64
+		want   string
+	}{
+		{"", 100000000, "10000000049"},
+		{"", 100000042, "10000004220"},
+		{"0101", 100000042, "010110000004297"},
+	}
+
+	for _, test := range tests {
+		number, err := NewAccountNumber(test.prefix, test.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if number != test.want {
+			t.Errorf("NewAccountNumber(%q, %d) = %s, want %s", test.prefix, test.serial, number, test.want)
+		}
+		if err := ValidateAccountNumber(number); err != nil {
+			t.Errorf("%s does not pass its own check: %v", number, err)
+		}
+	}
+
+	for _, serial := range []int64{MinSerial - 1, MaxSerial + 1} {
+		if _, err := NewAccountNumber("", serial); err == nil {
+			t.Errorf("serial %d was accepted", serial)
+		}
+	}
+	if _, err := NewAccountNumber("1234567", MinSerial); err == nil {
+		t.Error("a 7 digit prefix was accepted")
+	}
+}
+
+func TestValidateAccountNumber(t *testing.T) {
+	for _, number := range []string{"10000000049", "010110000004297", "100000007"} {
+		if err := ValidateAccountNumber(number); err != nil {
+			t.Errorf("%s is rejected: %v", number, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"1000000004a",
+		"10000000048",      //Wrong check digit
+		"10000000409",      //Swapped digits
+		"100000000499",     //Typed an extra digit
+		"1000000049",       //Missed a digit
+		"012345678",        //Legacy numbers never start with 0
+		"1234567890123456", //Too long
+	}
+	for _, number := range invalid {
+		if _, ok := ValidateAccountNumber(number).(InvalidAccountNumberError); !ok {
+			t.Errorf("%q is accepted", number)
+		}
+	}
+}
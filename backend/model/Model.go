@@ -5,12 +5,41 @@ import "time"
 type User struct {
 	ID string `json:"id"`
 	//DateCreated time.Time `json:"date created"`
-	Email    string  `json:"email"`
-	Password string  `json:"password"`
-	Fullname string  `json:"fullname"`
-	Balance  float64 `json:"balance"`
-	Exp      int     `json:"exp"`
-	State    string  `json:"state"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Fullname string `json:"fullname"`
+	Exp      int    `json:"exp"`
+	State    string `json:"state"`
+}
+
+// Product types a customer can open an account of
+const (
+	Checking = "checking"
+	Savings  = "savings"
+	Pot      = "pot"
+)
+
+// Account holds money for one customer. The checking account opened at registration has the
+// customer's ID as its number, further accounts get their own numbers
+type Account struct {
+	ID       string     `json:"id"`
+	Customer string     `json:"customer"`
+	Type     string     `json:"type"`
+	Name     string     `json:"name"`
+	Balance  Money      `json:"balance"`
+	Currency Currency   `json:"currency"`
+	State    string     `json:"state"`
+	OpenedAt time.Time  `json:"opened at"`
+	ClosedAt *time.Time `json:"closed at,omitempty"`
+}
+
+const (
+	AccountOpen   = "open"
+	AccountClosed = "closed"
+)
+
+func ValidAccountType(accountType string) bool {
+	return accountType == Checking || accountType == Savings || accountType == Pot
 }
 
 type Admin struct {
@@ -21,24 +50,155 @@ type Admin struct {
 }
 
 type Info struct {
-	ID       string  `json:"id"`
-	Fullname string  `json:"fullname"`
-	Role     string  `json:"role"`
-	Balance  float64 `json:"balance"`
-	Level    int     `json:"level"`
-	Exp      int     `json:"exp"`
+	ID       string    `json:"id"`
+	Fullname string    `json:"fullname"`
+	Role     string    `json:"role"`
+	Balance  Money     `json:"balance"` //Sum of the open accounts held in Currency
+	Currency Currency  `json:"currency"`
+	Level    int       `json:"level"`
+	Exp      int       `json:"exp"`
+	LevelMin int       `json:"level min"` //EXP the level starts at
+	LevelMax int       `json:"level max"` //EXP the next level starts at, 0 at the max level
+	Accounts []Account `json:"accounts"`
 }
 
 type Credential struct {
-	Token string `json:"token"`
-	Info  Info   `json:"info"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh token"`
+	Info         Info   `json:"info"`
+}
+
+type Session struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	CreatedAt  time.Time `json:"created at"`
+	LastUsedAt time.Time `json:"last used at"`
+	ExpiresAt  time.Time `json:"expires at"`
+	Current    bool      `json:"current"`
 }
 
 type Transaction struct {
+	ID            int64     `json:"id"`
+	Type          string    `json:"type"`
 	Date          time.Time `json:"date transfer"`
 	DebitAccount  string    `json:"debit account"`
 	CreditAccount string    `json:"credit account"`
 	Beneficiary   string    `json:"beneficiary"`
-	Amount        float64   `json:"amount"`
+	Amount        Money     `json:"amount"`
+	Currency      Currency  `json:"currency"`
 	Description   string    `json:"description"`
+	//What the credit account received, in its own currency. Same as Amount and Currency
+	//unless the transfer was converted at Rate
+	CreditAmount   Money    `json:"credit amount"`
+	CreditCurrency Currency `json:"credit currency"`
+	Rate           Rate     `json:"rate,omitempty"`
+	QuoteID        string   `json:"quote id,omitempty"`
+	ScheduleRun    string   `json:"-"` //Run key of the schedule that made the transfer, see Schedule.RunKey
+	DailyLimit     Money    `json:"-"` //What the customer's tier lets them transfer a day, 0 for no limit
+}
+
+// Payee is an account a customer saved to send money to. Name is the account owner's name,
+// checked when the payee was saved. Until CoolingOffUntil only a limited amount can be sent to
+// the account, CoolingOffSent is what has been sent so far in the account's currency. Both
+// belong to the account, not the payee, they started when the account was first saved or paid
+type Payee struct {
+	ID              int64      `json:"id"`
+	Customer        string     `json:"-"`
+	Nickname        string     `json:"nickname"`
+	Account         string     `json:"account"`
+	Name            string     `json:"name"`
+	AddedAt         time.Time  `json:"added at"`
+	LastUsedAt      *time.Time `json:"last used at,omitempty"`
+	CoolingOffUntil time.Time  `json:"cooling off until"`
+	CoolingOffSent  Money      `json:"cooling off sent"`
+}
+
+// Tier is the perks of the levels from MinLevel to MaxLevel, no upper bound when MaxLevel is 0.
+// Limits are in each account's currency and 0 means no limit. FeeDiscount is taken off the
+// conversion fee, Cashback is paid back on transfers to other customers and RewardPoints are
+// earned for each whole unit they send
+type Tier struct {
+	Name          string  `json:"name"`
+	MinLevel      int     `json:"min level"`
+	MaxLevel      int     `json:"max level,omitempty"`
+	TransferLimit Money   `json:"daily transfer limit"`
+	WithdrawLimit Money   `json:"daily withdraw limit"`
+	FeeDiscount   Percent `json:"fee discount"`
+	Cashback      Percent `json:"cashback"`
+	RewardPoints  int     `json:"reward points"`
+}
+
+// Perks is a user's level, how far it is through it, and what its tier gives. Next is nil at
+// the last tier
+type Perks struct {
+	Level    int   `json:"level"`
+	Exp      int   `json:"exp"`
+	LevelMin int   `json:"level min"` //EXP the level starts at
+	LevelMax int   `json:"level max"` //EXP the next level starts at, 0 at the max level
+	Tier     Tier  `json:"tier"`
+	Next     *Tier `json:"next tier,omitempty"`
+}
+
+// Kinds of ExpEvent
+const (
+	ExpTransfer   = "transfer"
+	ExpTopup      = "topup"
+	ExpStreak     = "streak"
+	ExpAccountAge = "account_age"
+)
+
+// ExpEvent is one award of experience points. A user's Exp is the sum of its events, Reference
+// tells what earned them: a transaction ID, an account number or the streak's last day
+type ExpEvent struct {
+	ID        int64     `json:"id"`
+	Customer  string    `json:"-"`
+	Kind      string    `json:"kind"`
+	Points    int       `json:"points"`
+	Reference string    `json:"reference"`
+	At        time.Time `json:"at"`
+}
+
+// Achievement is one the customer unlocked, ID is the achievement's in the registry
+type Achievement struct {
+	ID         string    `json:"id"`
+	Customer   string    `json:"-"`
+	UnlockedAt time.Time `json:"unlocked at"`
+}
+
+// Badge is an achievement as shown to its customer, UnlockedAt is nil while it is locked
+type Badge struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	UnlockedAt  *time.Time `json:"unlocked at,omitempty"`
+}
+
+// Quote locks an exchange rate for one transfer until ExpiresAt
+type Quote struct {
+	ID            string    `json:"id"`
+	Customer      string    `json:"-"`
+	DebitAccount  string    `json:"debit account"`
+	CreditAccount string    `json:"credit account"`
+	From          Currency  `json:"from"`
+	To            Currency  `json:"to"`
+	Rate          Rate      `json:"rate"`
+	Fee           Percent   `json:"fee"` //Conversion fee already taken off Rate
+	Amount        Money     `json:"amount"`
+	Converted     Money     `json:"converted"`
+	ExpiresAt     time.Time `json:"expires at"`
+}
+
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next cursor"`
+}
+
+// Problem is an RFC 7807 problem details body. Code is stable and meant for programs, Detail is
+// meant for people
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
 }
@@ -0,0 +1,179 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount of money counted in minor units (cents). Every amount carries
+// exactly MinorDigits decimal places, so arithmetic on Money never drifts like float64 does
+type Money int64
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	MinorDigits = 2
+	minorFactor = 100
+
+	DefaultCurrency Currency = "USD"
+)
+
+// Currencies accounts can be opened in. Every one of them has MinorDigits decimal places
+var SupportedCurrencies = []Currency{"USD", "EUR", "GBP", "AUD", "CAD", "CHF", "SGD"}
+
+type InvalidCurrencyError struct {
+	Value string
+}
+
+func (e InvalidCurrencyError) Error() string {
+	return fmt.Sprintf("Unsupported currency %q", e.Value)
+}
+
+// ParseCurrency accepts a supported ISO 4217 code in any case
+func ParseCurrency(value string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(value)))
+	for _, supported := range SupportedCurrencies {
+		if currency == supported {
+			return currency, nil
+		}
+	}
+
+	return "", InvalidCurrencyError{Value: value}
+}
+
+type InvalidMoneyError struct {
+	Value string
+}
+
+func (e InvalidMoneyError) Error() string {
+	return fmt.Sprintf("Invalid amount of money %q", e.Value)
+}
+
+// ParseMoney reads a decimal string such as "10", "10.5" or "-0.25". Digits beyond
+// MinorDigits are only accepted when they are zeros, so no amount is silently rounded
+func ParseMoney(value string) (Money, error) {
+	text := strings.TrimSpace(value)
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" && fraction == "" {
+		return 0, InvalidMoneyError{Value: value}
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	//Drop trailing zeros beyond the minor digits (DECIMAL columns may have a larger scale)
+	for len(fraction) > MinorDigits && strings.HasSuffix(fraction, "0") {
+		fraction = fraction[:len(fraction)-1]
+	}
+	if len(fraction) > MinorDigits {
+		return 0, InvalidMoneyError{Value: value}
+	}
+	fraction += strings.Repeat("0", MinorDigits-len(fraction))
+
+	//Both parts must be plain digits
+	for _, part := range []string{whole, fraction} {
+		for _, char := range part {
+			if char < '0' || char > '9' {
+				return 0, InvalidMoneyError{Value: value}
+			}
+		}
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/minorFactor-1 {
+		return 0, InvalidMoneyError{Value: value}
+	}
+	minor, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return 0, InvalidMoneyError{Value: value}
+	}
+
+	money := Money(units*minorFactor + minor)
+	if negative {
+		money = -money
+	}
+
+	return money, nil
+}
+
+func (m Money) String() string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	return fmt.Sprintf("%s%d.%0*d", sign, value/minorFactor, MinorDigits, value%minorFactor)
+}
+
+// Format returns the amount followed by its currency code, e.g. "10.50 USD"
+func (m Money) Format(currency Currency) string {
+	return fmt.Sprintf("%s %s", m.String(), currency)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts a JSON string, and a JSON number for clients that still send floats
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var text string
+	if len(data) > 0 && data[0] == '"' {
+		err := json.Unmarshal(data, &text)
+		if err != nil {
+			return err
+		}
+	} else {
+		text = string(data)
+	}
+
+	money, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = money
+
+	return nil
+}
+
+// Scan reads a DECIMAL column
+func (m *Money) Scan(src any) error {
+	var text string
+	switch value := src.(type) {
+	case []byte:
+		text = string(value)
+	case string:
+		text = value
+	case int64:
+		text = strconv.FormatInt(value, 10)
+	case float64:
+		text = strconv.FormatFloat(value, 'f', -1, 64)
+	case nil:
+		return errors.New("cannot scan NULL into Money")
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+
+	money, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = money
+
+	return nil
+}
+
+// Value writes the amount as a decimal string so DECIMAL columns receive it exactly
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
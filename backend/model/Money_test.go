@@ -0,0 +1,56 @@
+package model
+
+import (
+	//Import standard library
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Money
+		ok    bool
+	}{
+		{"10", 1000, true},
+		{"10.5", 1050, true},
+		{"10.50", 1050, true},
+		{".25", 25, true},
+		{"-0.25", -25, true},
+		{"+3", 300, true},
+		{"1.2300", 123, true},
+		{"1.234", 0, false},
+		{"", 0, false},
+		{".", 0, false},
+		{"1e3", 0, false},
+		{"1,000", 0, false},
+		{"99999999999999999999", 0, false},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMoney(test.value)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("ParseMoney(%q) = %d, %v; want %d, ok %v", test.value, got, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(Money(-1005))
+	if err != nil || string(data) != `"-10.05"` {
+		t.Fatalf("Marshal = %s, %v", data, err)
+	}
+
+	//Numbers are accepted too, for old clients
+	for _, input := range []string{`"10.05"`, `10.05`} {
+		var money Money
+		err = json.Unmarshal([]byte(input), &money)
+		if err != nil || money != 1005 {
+			t.Errorf("Unmarshal(%s) = %d, %v", input, money, err)
+		}
+	}
+
+	if Money(1050).Format("USD") != "10.50 USD" {
+		t.Errorf("Format = %q", Money(1050).Format("USD"))
+	}
+}
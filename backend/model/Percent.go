@@ -0,0 +1,80 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Percent is a rate counted in hundredths of a percent, 125 is 1.25%. It is written like Money,
+// "1.25", so rates are exact too
+type Percent int64
+
+// Hundred is 100%
+const Hundred Percent = 100 * minorFactor
+
+type InvalidPercentError struct {
+	Value string
+}
+
+func (e InvalidPercentError) Error() string {
+	return fmt.Sprintf("Invalid percentage %q, it must be between 0 and 100", e.Value)
+}
+
+// ParsePercent reads a decimal string between "0" and "100" with at most two decimal places
+func ParsePercent(value string) (Percent, error) {
+	money, err := ParseMoney(value)
+	if err != nil || money < 0 || Percent(money) > Hundred {
+		return 0, InvalidPercentError{Value: value}
+	}
+
+	return Percent(money), nil
+}
+
+func (p Percent) String() string {
+	return Money(p).String() + "%"
+}
+
+// Of returns the percentage of amount, rounded down to the minor unit
+func (p Percent) Of(amount Money) Money {
+	product := new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(p)))
+	return Money(product.Quo(product, big.NewInt(int64(Hundred))).Int64())
+}
+
+// Less takes the percentage off p, a 50% discount on a 1.00% fee leaves 0.50%
+func (p Percent) Less(discount Percent) Percent {
+	return p - Percent(discount.Of(Money(p)))
+}
+
+func (p Percent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Money(p).String())
+}
+
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	var text string
+	err := json.Unmarshal(data, &text)
+	if err != nil {
+		return err
+	}
+
+	percent, err := ParsePercent(text)
+	if err != nil {
+		return err
+	}
+	*p = percent
+
+	return nil
+}
+
+// Scan reads a DECIMAL column
+func (p *Percent) Scan(src any) error {
+	var money Money
+	err := money.Scan(src)
+	*p = Percent(money)
+	return err
+}
+
+func (p Percent) Value() (driver.Value, error) {
+	return Money(p).String(), nil
+}
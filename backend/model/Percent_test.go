@@ -0,0 +1,40 @@
+package model
+
+import (
+	//Import standard library
+	"testing"
+)
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Percent
+		ok    bool
+	}{
+		{"1", 100, true},
+		{"0.25", 25, true},
+		{"100", 10000, true},
+		{"0", 0, true},
+		{"100.01", 0, false},
+		{"-1", 0, false},
+		{"0.125", 0, false},
+		{"", 0, false},
+	}
+
+	for _, test := range tests {
+		got, err := ParsePercent(test.value)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("ParsePercent(%q) = %d, %v; want %d, ok %v", test.value, got, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestPercentOf(t *testing.T) {
+	//Cashback is rounded down, 0.25% of 10.99 is 0.027475
+	if got := Percent(25).Of(1099); got != 2 {
+		t.Errorf("0.25%% of 10.99 = %s, want 0.02", got)
+	}
+	if got := Percent(100).Less(5000); got != 50 {
+		t.Errorf("1%% less 50%% = %s, want 0.50%%", got)
+	}
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Rate is an exchange rate with RateDigits decimal places: how many units of the quote currency
+// one unit of the base currency buys. Like Money it is exact, never a float
+type Rate int64
+
+const (
+	RateDigits = 8
+	rateFactor = 100000000
+)
+
+type InvalidRateError struct {
+	Value string
+}
+
+func (e InvalidRateError) Error() string {
+	return fmt.Sprintf("Invalid exchange rate %q", e.Value)
+}
+
+// ParseRate reads a positive decimal string such as "0.92" or "25400"
+func ParseRate(value string) (Rate, error) {
+	text := strings.TrimSpace(value)
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	if len(fraction) > RateDigits || strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, InvalidRateError{Value: value}
+	}
+	fraction += strings.Repeat("0", RateDigits-len(fraction))
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > 1e9 {
+		return 0, InvalidRateError{Value: value}
+	}
+	minor, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return 0, InvalidRateError{Value: value}
+	}
+
+	rate := Rate(units*rateFactor + minor)
+	if rate <= 0 {
+		return 0, InvalidRateError{Value: value}
+	}
+
+	return rate, nil
+}
+
+// String drops trailing zeros, 0.92000000 is written 0.92
+func (r Rate) String() string {
+	text := fmt.Sprintf("%d.%0*d", int64(r)/rateFactor, RateDigits, int64(r)%rateFactor)
+	return strings.TrimSuffix(strings.TrimRight(text, "0"), ".")
+}
+
+// Convert returns amount in the quote currency, rounded half away from zero to the minor unit
+func (r Rate) Convert(amount Money) Money {
+	product := new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(r)))
+	half := big.NewInt(rateFactor / 2)
+	if product.Sign() < 0 {
+		half.Neg(half)
+	}
+	product.Add(product, half)
+	return Money(product.Quo(product, big.NewInt(rateFactor)).Int64())
+}
+
+// LessFee is the rate a customer gets once the bank takes its conversion fee, rounded down
+func (r Rate) LessFee(fee Percent) Rate {
+	product := new(big.Int).Mul(big.NewInt(int64(r)), big.NewInt(int64(Hundred-fee)))
+	return Rate(product.Quo(product, big.NewInt(int64(Hundred))).Int64())
+}
+
+// Inverse is the rate of the opposite pair, rounded to RateDigits
+func (r Rate) Inverse() Rate {
+	return Rate((rateFactor*rateFactor + int64(r)/2) / int64(r))
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	var text string
+	err := json.Unmarshal(data, &text)
+	if err != nil {
+		return err
+	}
+
+	rate, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = rate
+
+	return nil
+}
+
+// Scan reads a DECIMAL column, NULL (no conversion) is read as 0
+func (r *Rate) Scan(src any) error {
+	var text string
+	switch value := src.(type) {
+	case nil:
+		*r = 0
+		return nil
+	case []byte:
+		text = string(value)
+	case string:
+		text = value
+	default:
+		return fmt.Errorf("cannot scan %T into Rate", src)
+	}
+
+	//DECIMAL columns may carry more zeros than RateDigits
+	if whole, fraction, found := strings.Cut(text, "."); found && len(fraction) > RateDigits {
+		if strings.Trim(fraction[RateDigits:], "0") != "" {
+			return errors.New("exchange rate has more than 8 decimal places")
+		}
+		text = whole + "." + fraction[:RateDigits]
+	}
+
+	rate, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = rate
+
+	return nil
+}
+
+// Value writes the rate as a decimal string, 0 (no conversion) as NULL
+func (r Rate) Value() (driver.Value, error) {
+	if r == 0 {
+		return nil, nil
+	}
+
+	return r.String(), nil
+}
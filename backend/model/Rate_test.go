@@ -0,0 +1,74 @@
+package model
+
+import (
+	//Import standard library
+	"encoding/json"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Rate
+		ok    bool
+	}{
+		{"0.92", 92000000, true},
+		{"1", 100000000, true},
+		{"25400", 2540000000000, true},
+		{".5", 50000000, true},
+		{"0.123456789", 0, false},
+		{"0", 0, false},
+		{"-1", 0, false},
+		{"", 0, false},
+		{"1e3", 0, false},
+	}
+
+	for _, test := range tests {
+		got, err := ParseRate(test.value)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("ParseRate(%q) = %d, %v; want %d, ok %v", test.value, got, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestRateConvert(t *testing.T) {
+	tests := []struct {
+		rate   string
+		amount Money
+		want   Money
+	}{
+		{"0.92", 10000, 9200},
+		{"0.92", 1, 1},     //0.0092 rounds up to 0.01
+		{"1.5", 1, 2},      //0.015 rounds half away from zero
+		{"0.79", 333, 263}, //2.6307
+		{"1.36", 99999999, 135999999},
+	}
+
+	for _, test := range tests {
+		rate, err := ParseRate(test.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rate.Convert(test.amount); got != test.want {
+			t.Errorf("%s.Convert(%s) = %s, want %s", test.rate, test.amount, got, test.want)
+		}
+	}
+}
+
+func TestRateInverseAndJSON(t *testing.T) {
+	rate, _ := ParseRate("0.8")
+	if got := rate.Inverse().String(); got != "1.25" {
+		t.Errorf("inverse of 0.8 = %s, want 1.25", got)
+	}
+
+	data, err := json.Marshal(rate)
+	if err != nil || string(data) != `"0.8"` {
+		t.Fatalf("Marshal = %s, %v", data, err)
+	}
+
+	var parsed Rate
+	err = json.Unmarshal(data, &parsed)
+	if err != nil || parsed != rate {
+		t.Fatalf("Unmarshal = %d, %v", parsed, err)
+	}
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// Kinds of RewardEntry
+const (
+	RewardEarn   = "earn"
+	RewardRedeem = "redeem"
+	RewardExpire = "expire"
+)
+
+// RewardEntry is a change of a customer's reward points. Earned points last until ExpiresAt,
+// redeemed and expired points are negative. Only earn and redeem entries are stored, expire
+// entries are worked out by ReplayRewards. Reference is the transaction that earned the points
+// or the account they were redeemed into
+type RewardEntry struct {
+	ID        int64      `json:"id"`
+	Customer  string     `json:"-"`
+	Kind      string     `json:"kind"`
+	Points    int        `json:"points"`
+	Reference string     `json:"reference"`
+	At        time.Time  `json:"at"`
+	ExpiresAt *time.Time `json:"expires at,omitempty"`
+}
+
+// RewardWallet is what a customer holds in reward points, kept apart from its accounts' balance.
+// Value is what the points are worth once redeemed, in the currency of the account they go to.
+// NextExpiry is when the points expiring first, Expiring of them, are lost
+type RewardWallet struct {
+	Points     int        `json:"points"`
+	Value      Money      `json:"value"`
+	Expiring   int        `json:"expiring,omitempty"`
+	NextExpiry *time.Time `json:"next expiry,omitempty"`
+}
+
+// Redemption is points turned into money on an account, Balance is the account's new balance
+type Redemption struct {
+	Points  int   `json:"points"`
+	Amount  Money `json:"amount"`
+	Balance Money `json:"balance"`
+}
+
+// RewardPoints returns the points earned by amount at perUnit points for each whole unit
+func RewardPoints(amount Money, perUnit int) int {
+	if amount <= 0 || perUnit <= 0 {
+		return 0
+	}
+	return int(int64(amount) / minorFactor * int64(perUnit))
+}
+
+// rewardLot is what is left of the points of an earn entry
+type rewardLot struct {
+	entry RewardEntry
+	left  int
+}
+
+// ReplayRewards goes through a customer's stored entries in time order, up to now. Redeemed
+// points are taken from the earned ones expiring first, what is left of earned points when they
+// expire is lost. It returns the wallet, without its Value, and the history with the
+// expirations, newest first
+func ReplayRewards(entries []RewardEntry, now time.Time) (RewardWallet, []RewardEntry) {
+	entries = append([]RewardEntry{}, entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].At.Before(entries[j].At)
+	})
+
+	var lots []*rewardLot
+	var history []RewardEntry
+
+	//expire drops the lots expired at t, recording what was left of them
+	expire := func(t time.Time) {
+		kept := lots[:0]
+		for _, lot := range lots {
+			if lot.entry.ExpiresAt.After(t) {
+				kept = append(kept, lot)
+				continue
+			}
+			if lot.left > 0 {
+				history = append(history, RewardEntry{
+					Customer:  lot.entry.Customer,
+					Kind:      RewardExpire,
+					Points:    -lot.left,
+					Reference: lot.entry.Reference,
+					At:        *lot.entry.ExpiresAt,
+				})
+			}
+		}
+		lots = kept
+	}
+
+	for _, entry := range entries {
+		if entry.At.After(now) {
+			break
+		}
+		expire(entry.At)
+		history = append(history, entry)
+
+		switch entry.Kind {
+		case RewardEarn:
+			if entry.ExpiresAt != nil {
+				lots = append(lots, &rewardLot{entry: entry, left: entry.Points})
+			}
+		case RewardRedeem:
+			sort.SliceStable(lots, func(i, j int) bool {
+				return lots[i].entry.ExpiresAt.Before(*lots[j].entry.ExpiresAt)
+			})
+			redeemed := -entry.Points
+			for _, lot := range lots {
+				taken := min(lot.left, redeemed)
+				lot.left -= taken
+				redeemed -= taken
+			}
+		}
+	}
+	expire(now)
+
+	var wallet RewardWallet
+	for _, lot := range lots {
+		if lot.left == 0 {
+			continue
+		}
+		wallet.Points += lot.left
+		expiresAt := *lot.entry.ExpiresAt
+		switch {
+		case wallet.NextExpiry == nil || expiresAt.Before(*wallet.NextExpiry):
+			wallet.NextExpiry, wallet.Expiring = &expiresAt, lot.left
+		case expiresAt.Equal(*wallet.NextExpiry):
+			wallet.Expiring += lot.left
+		}
+	}
+
+	//Expirations were recorded late, put them back in time order then turn it around
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].At.Before(history[j].At)
+	})
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return wallet, history
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	//Import standard library
+	"fmt"
+	"testing"
+)
+
+func TestReplayRewards(t *testing.T) {
+	earn := func(points int, at, expires string) RewardEntry {
+		expiresAt := date(expires)
+		return RewardEntry{Kind: RewardEarn, Points: points, Reference: at, At: date(at), ExpiresAt: &expiresAt}
+	}
+	entries := []RewardEntry{
+		earn(100, "2026-01-01", "2026-07-01"),
+		earn(50, "2026-02-01", "2026-08-01"),
+		//Takes the points expiring first: 80 of January's
+		{Kind: RewardRedeem, Points: -80, At: date("2026-03-01")},
+		earn(30, "2026-06-15", "2026-12-15"),
+	}
+
+	wallet, history := ReplayRewards(entries, date("2026-07-15"))
+	if wallet.Points != 80 || wallet.Expiring != 50 || !wallet.NextExpiry.Equal(date("2026-08-01")) {
+		t.Fatalf("wallet = %d points, %d expiring at %v", wallet.Points, wallet.Expiring, wallet.NextExpiry)
+	}
+
+	var got []string
+	for _, entry := range history {
+		got = append(got, fmt.Sprintf("%s %s %d", entry.At.Format("01-02"), entry.Kind, entry.Points))
+	}
+	want := "[07-01 expire -20 06-15 earn 30 03-01 redeem -80 02-01 earn 50 01-01 earn 100]"
+	if fmt.Sprint(got) != want {
+		t.Fatalf("history = %v, want %s", got, want)
+	}
+
+	//Entries after now are not counted yet
+	wallet, _ = ReplayRewards(entries, date("2026-02-15"))
+	if wallet.Points != 150 || wallet.Expiring != 100 {
+		t.Fatalf("wallet = %+v", wallet)
+	}
+}
+
+func TestRewardPoints(t *testing.T) {
+	tests := []struct {
+		amount  Money
+		perUnit int
+		want    int
+	}{
+		{3000, 1, 30},
+		{3099, 2, 60},
+		{99, 5, 0},
+		{1000, 0, 0},
+	}
+
+	for _, test := range tests {
+		if got := RewardPoints(test.amount, test.perUnit); got != test.want {
+			t.Errorf("RewardPoints(%s, %d) = %d, want %d", test.amount, test.perUnit, got, test.want)
+		}
+	}
+}
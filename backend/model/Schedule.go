@@ -0,0 +1,124 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// How often a scheduled transfer runs
+const (
+	Once            = "once"
+	Daily           = "daily"
+	Weekly          = "weekly"
+	Monthly         = "monthly"           //On Day of every month, or its last day when the month is shorter
+	LastBusinessDay = "last-business-day" //On the last weekday of every month
+)
+
+const (
+	ScheduleActive    = "active"
+	ScheduleCompleted = "completed"
+	ScheduleCancelled = "cancelled"
+	ScheduleFailed    = "failed"
+)
+
+// Schedule is a transfer the bank makes for the customer later, once or as a standing order.
+// Runs are whole days in UTC, a schedule is due from the start of its NextRun day
+type Schedule struct {
+	ID            int64      `json:"id"`
+	Customer      string     `json:"-"`
+	DebitAccount  string     `json:"debit account"`
+	CreditAccount string     `json:"credit account"`
+	Amount        Money      `json:"amount"`
+	Currency      Currency   `json:"currency"`
+	Description   string     `json:"description"`
+	Frequency     string     `json:"frequency"`
+	Day           int        `json:"day,omitempty"` //Day of the month of a monthly schedule
+	NextRun       time.Time  `json:"next run"`      //The client sends the first day it may run
+	RetryAt       *time.Time `json:"retry at,omitempty"`
+	Attempts      int        `json:"attempts"` //Failed attempts at NextRun
+	State         string     `json:"state"`
+	LastError     string     `json:"last error,omitempty"`
+	CreatedAt     time.Time  `json:"created at"`
+}
+
+func ValidFrequency(frequency string) bool {
+	switch frequency {
+	case Once, Daily, Weekly, Monthly, LastBusinessDay:
+		return true
+	}
+	return false
+}
+
+// Due reports whether the schedule should run at now, its retry when one is pending
+func (s Schedule) Due(now time.Time) bool {
+	if s.State != ScheduleActive {
+		return false
+	}
+	if s.RetryAt != nil {
+		return !s.RetryAt.After(now)
+	}
+	return !s.NextRun.After(now)
+}
+
+// RunKey names the schedule's next run. The ledger makes one transfer per run key, so a run
+// made again after a crash or by another server is refused
+func (s Schedule) RunKey() string {
+	return fmt.Sprintf("%d/%s", s.ID, s.NextRun.Format("2006-01-02"))
+}
+
+// FirstRun returns the first day on or after start the schedule runs on
+func (s Schedule) FirstRun(start time.Time) time.Time {
+	start = Day(start)
+
+	switch s.Frequency {
+	case Monthly, LastBusinessDay:
+		run := s.runInMonth(start.Year(), start.Month())
+		if run.Before(start) {
+			run = s.runInMonth(start.Year(), start.Month()+1)
+		}
+		return run
+	}
+
+	return start
+}
+
+// Following returns the day the schedule runs on after run, the zero time when it only runs once
+func (s Schedule) Following(run time.Time) time.Time {
+	run = Day(run)
+
+	switch s.Frequency {
+	case Daily:
+		return run.AddDate(0, 0, 1)
+	case Weekly:
+		return run.AddDate(0, 0, 7)
+	case Monthly, LastBusinessDay:
+		return s.runInMonth(run.Year(), run.Month()+1)
+	}
+
+	return time.Time{}
+}
+
+// runInMonth returns the day a monthly schedule runs on in the month, which may overflow into
+// the next year
+func (s Schedule) runInMonth(year int, month time.Month) time.Time {
+	//Day 0 of the following month is the last day of this one
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+
+	if s.Frequency == LastBusinessDay {
+		for last.Weekday() == time.Saturday || last.Weekday() == time.Sunday {
+			last = last.AddDate(0, 0, -1)
+		}
+		return last
+	}
+
+	if s.Day < last.Day() {
+		return time.Date(last.Year(), last.Month(), s.Day, 0, 0, 0, 0, time.UTC)
+	}
+	return last
+}
+
+// Day truncates t to the start of its day in UTC
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
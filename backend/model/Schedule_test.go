@@ -0,0 +1,73 @@
+package model
+
+import (
+	//Import standard library
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestScheduleFirstRun(t *testing.T) {
+	tests := []struct {
+		frequency string
+		day       int
+		start     string
+		want      string
+	}{
+		{Once, 0, "2026-03-10", "2026-03-10"},
+		{Weekly, 0, "2026-03-10", "2026-03-10"},
+		{Monthly, 15, "2026-03-10", "2026-03-15"},
+		{Monthly, 5, "2026-03-10", "2026-04-05"},
+		{Monthly, 31, "2026-02-10", "2026-02-28"},
+		{Monthly, 10, "2026-12-20", "2027-01-10"},
+		{LastBusinessDay, 0, "2026-01-05", "2026-01-30"}, //Jan 31st is a Saturday
+		{LastBusinessDay, 0, "2026-05-30", "2026-06-30"}, //May 29th has passed
+	}
+
+	for _, test := range tests {
+		schedule := Schedule{Frequency: test.frequency, Day: test.day}
+		got := schedule.FirstRun(date(test.start).Add(13 * time.Hour))
+		if !got.Equal(date(test.want)) {
+			t.Errorf("%s day %d from %s = %s; want %s", test.frequency, test.day, test.start, got.Format("2006-01-02"), test.want)
+		}
+	}
+}
+
+func TestScheduleFollowing(t *testing.T) {
+	tests := []struct {
+		frequency string
+		day       int
+		run       string
+		want      string
+	}{
+		{Once, 0, "2026-03-10", ""},
+		{Daily, 0, "2026-02-28", "2026-03-01"},
+		{Weekly, 0, "2026-12-29", "2027-01-05"},
+		{Monthly, 31, "2026-01-31", "2026-02-28"},
+		{Monthly, 31, "2026-02-28", "2026-03-31"}, //Does not drift to the 28th
+		{LastBusinessDay, 0, "2026-07-31", "2026-08-31"},
+		{LastBusinessDay, 0, "2026-11-30", "2026-12-31"},
+	}
+
+	for _, test := range tests {
+		schedule := Schedule{Frequency: test.frequency, Day: test.day}
+		got := schedule.Following(date(test.run))
+
+		if test.want == "" {
+			if !got.IsZero() {
+				t.Errorf("%s after %s = %s; want none", test.frequency, test.run, got.Format("2006-01-02"))
+			}
+			continue
+		}
+		if !got.Equal(date(test.want)) {
+			t.Errorf("%s day %d after %s = %s; want %s", test.frequency, test.day, test.run, got.Format("2006-01-02"), test.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package perks
+
+import (
+	//Import standard library
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+// Table lists the tiers from the lowest level up. Every level from 1 belongs to exactly one tier
+type Table []model.Tier
+
+// DefaultTable is used when no tiers file is configured
+func DefaultTable() Table {
+	return Table{
+		{Name: "Bronze", MinLevel: 1, MaxLevel: 2, TransferLimit: 100000, WithdrawLimit: 50000, RewardPoints: 1},
+		{Name: "Silver", MinLevel: 3, MaxLevel: 4, TransferLimit: 500000, WithdrawLimit: 200000, FeeDiscount: 2500, Cashback: 25, RewardPoints: 2},
+		{Name: "Gold", MinLevel: 5, MaxLevel: 9, TransferLimit: 2000000, WithdrawLimit: 500000, FeeDiscount: 5000, Cashback: 50, RewardPoints: 3},
+		{Name: "Platinum", MinLevel: 10, TransferLimit: 10000000, WithdrawLimit: 2000000, FeeDiscount: 10000, Cashback: 100, RewardPoints: 5},
+	}
+}
+
+// LoadTable reads the tiers from a JSON array shaped like model.Tier
+func LoadTable(path string) (Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading tiers file: %w", err)
+	}
+
+	var table Table
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&table)
+	if err != nil {
+		return nil, fmt.Errorf("parsing tiers file %s: %w", path, err)
+	}
+
+	return table, table.Validate()
+}
+
+// Validate checks that the tiers follow each other without gap nor overlap, from level 1 to
+// the last tier which has no upper bound
+func (t Table) Validate() error {
+	if len(t) == 0 {
+		return errors.New("tiers table is empty")
+	}
+
+	var errs []error
+	next := 1
+	for i, tier := range t {
+		if tier.Name == "" {
+			errs = append(errs, fmt.Errorf("tier %d has no name", i+1))
+		}
+		if tier.MinLevel != next {
+			errs = append(errs, fmt.Errorf("tier %s must start at level %d", tier.Name, next))
+		}
+		last := i == len(t)-1
+		if last && tier.MaxLevel != 0 {
+			errs = append(errs, fmt.Errorf("last tier %s must have no max level", tier.Name))
+		}
+		if !last && tier.MaxLevel < tier.MinLevel {
+			errs = append(errs, fmt.Errorf("tier %s must end at or after level %d", tier.Name, tier.MinLevel))
+		}
+		if tier.TransferLimit < 0 || tier.WithdrawLimit < 0 {
+			errs = append(errs, fmt.Errorf("tier %s limits must not be negative", tier.Name))
+		}
+		if tier.RewardPoints < 0 {
+			errs = append(errs, fmt.Errorf("tier %s reward points must not be negative", tier.Name))
+		}
+		next = tier.MaxLevel + 1
+	}
+
+	return errors.Join(errs...)
+}
+
+// ForLevel returns the level's tier and the one after it, nil at the last tier. Levels below
+// the first tier get the first tier
+func (t Table) ForLevel(level int) (model.Tier, *model.Tier) {
+	for i, tier := range t {
+		if tier.MaxLevel == 0 || level <= tier.MaxLevel {
+			if i+1 < len(t) {
+				return tier, &t[i+1]
+			}
+			return tier, nil
+		}
+	}
+
+	return t[len(t)-1], nil
+}
@@ -0,0 +1,68 @@
+package perks
+
+import (
+	//Import standard library
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForLevel(t *testing.T) {
+	table := DefaultTable()
+	err := table.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		level int
+		tier  string
+		next  string
+	}{
+		{1, "Bronze", "Silver"},
+		{2, "Bronze", "Silver"},
+		{3, "Silver", "Gold"},
+		{9, "Gold", "Platinum"},
+		{40, "Platinum", ""},
+	}
+	for _, test := range tests {
+		tier, next := table.ForLevel(test.level)
+		nextName := ""
+		if next != nil {
+			nextName = next.Name
+		}
+		if tier.Name != test.tier || nextName != test.next {
+			t.Errorf("ForLevel(%d) = %s, %s; want %s, %s", test.level, tier.Name, nextName, test.tier, test.next)
+		}
+	}
+}
+
+func TestLoadTableRejectsGaps(t *testing.T) {
+	files := map[string]string{
+		"gap":      `[{"name": "Bronze", "min level": 1, "max level": 2}, {"name": "Gold", "min level": 4}]`,
+		"bounded":  `[{"name": "Bronze", "min level": 1, "max level": 2}]`,
+		"negative": `[{"name": "Bronze", "min level": 1, "daily transfer limit": "-1"}]`,
+		"empty":    `[]`,
+	}
+	for name, content := range files {
+		path := filepath.Join(t.TempDir(), "tiers.json")
+		err := os.WriteFile(path, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = LoadTable(path)
+		if err == nil {
+			t.Errorf("%s: tiers file was accepted", name)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "tiers.json")
+	err := os.WriteFile(path, []byte(`[{"name": "Member", "min level": 1, "cashback": "0.10"}]`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := LoadTable(path)
+	if err != nil || len(table) != 1 || table[0].Cashback != 10 {
+		t.Fatalf("table = %+v, err = %v", table, err)
+	}
+}
@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	//Import standard library
+	"fmt"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// Transfer makes a transfer for the customer, user.Handler.Transfer in the server
+type Transfer func(customer string, transaction model.Transaction) (model.Transaction, error)
+
+// Notifier tells the customer that a scheduled transfer could not be made
+type Notifier interface {
+	ScheduleFailed(schedule model.Schedule) error
+}
+
+// LogNotifier writes failed schedules to the server log
+type LogNotifier struct{}
+
+func (LogNotifier) ScheduleFailed(schedule model.Schedule) error {
+	fmt.Printf("Scheduled transfer %d of customer %s failed: %s\n", schedule.ID, schedule.Customer, schedule.LastError)
+	return nil
+}
+
+// Scheduler makes the scheduled transfers that are due. Each transfer carries its run's key, so
+// a run made again, by another server or after a crash left the schedule unsaved, is refused by
+// the ledger and only moves the schedule on
+type Scheduler struct {
+	Schedules   store.ScheduleStore
+	Transfer    Transfer
+	Notifier    Notifier
+	Interval    time.Duration //How often due schedules are looked for
+	RetryDelay  time.Duration
+	MaxAttempts int
+}
+
+// Run looks for due schedules every Interval and never returns, start it in its own goroutine
+func (s Scheduler) Run() {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		err := s.RunDue(time.Now())
+		if err != nil {
+			fmt.Println("Error at: Scheduler -> Error running due schedules")
+			fmt.Println(err)
+		}
+		<-ticker.C
+	}
+}
+
+// RunDue makes every transfer due at now and saves what became of each schedule
+func (s Scheduler) RunDue(now time.Time) error {
+	schedules, err := s.Schedules.DueSchedules(now)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		schedule = s.run(schedule, now)
+
+		err = s.Schedules.UpdateSchedule(schedule)
+		if err != nil {
+			fmt.Printf("Error at: Scheduler -> Error saving schedule %d\n", schedule.ID)
+			fmt.Println(err)
+		}
+	}
+
+	return nil
+}
+
+// run makes the schedule's transfer and returns the schedule as it should be saved
+func (s Scheduler) run(schedule model.Schedule, now time.Time) model.Schedule {
+	_, err := s.Transfer(schedule.Customer, model.Transaction{
+		DebitAccount:  schedule.DebitAccount,
+		CreditAccount: schedule.CreditAccount,
+		Amount:        schedule.Amount,
+		Description:   schedule.Description,
+		ScheduleRun:   schedule.RunKey(),
+	})
+	if _, ok := err.(store.ScheduleRunDoneError); ok {
+		err = nil
+	}
+	if err == nil {
+		schedule.LastError = ""
+		return s.advance(schedule, now)
+	}
+
+	schedule.Attempts++
+	schedule.LastError = err.Error()
+
+	//Money may come in before the next attempt, a closed account never reopens
+	if retryable(err) && schedule.Attempts < s.MaxAttempts {
+		retryAt := now.Add(s.RetryDelay)
+		schedule.RetryAt = &retryAt
+		return schedule
+	}
+
+	if retryable(err) {
+		schedule = s.advance(schedule, now)
+		if schedule.State == model.ScheduleCompleted {
+			schedule.State = model.ScheduleFailed
+		}
+	} else {
+		schedule.RetryAt = nil
+		schedule.State = model.ScheduleFailed
+	}
+
+	err = s.Notifier.ScheduleFailed(schedule)
+	if err != nil {
+		fmt.Printf("Error at: Scheduler -> Error notifying failure of schedule %d\n", schedule.ID)
+		fmt.Println(err)
+	}
+
+	return schedule
+}
+
+// advance moves the schedule to its following run, or completes it when there is none. Runs
+// missed while the server was down are skipped, only the latest one has been made
+func (s Scheduler) advance(schedule model.Schedule, now time.Time) model.Schedule {
+	schedule.Attempts = 0
+	schedule.RetryAt = nil
+
+	next := schedule.Following(schedule.NextRun)
+	for !next.IsZero() && next.Before(model.Day(now)) {
+		next = schedule.Following(next)
+	}
+	if next.IsZero() {
+		schedule.State = model.ScheduleCompleted
+		return schedule
+	}
+
+	schedule.NextRun = next
+	return schedule
+}
+
+// retryable reports whether the transfer may succeed when tried again later
+func retryable(err error) bool {
+	switch err.(type) {
+	case model.InvalidAccountNumberError, ledger.AccountNotFoundError, ledger.AccountClosedError,
+		ledger.InvalidAmountError, ledger.SelfTransferError, ledger.CurrencyMismatchError:
+		return false
+	}
+	return true
+}
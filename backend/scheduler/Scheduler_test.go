@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	//Import standard library
+	"testing"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/model"
+	"gobank-server/store/memory"
+)
+
+// notifications remembers the failed schedules it was told about
+type notifications []model.Schedule
+
+func (n *notifications) ScheduleFailed(schedule model.Schedule) error {
+	*n = append(*n, schedule)
+	return nil
+}
+
+func TestRetryThenSkip(t *testing.T) {
+	stores := memory.New()
+	first := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	schedule, err := stores.Schedules.CreateSchedule(model.Schedule{
+		Customer: "10000000049", Amount: 100, Frequency: model.LastBusinessDay, NextRun: first, State: model.ScheduleActive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	notified := &notifications{}
+	runner := Scheduler{
+		Schedules: stores.Schedules,
+		Transfer: func(customer string, transaction model.Transaction) (model.Transaction, error) {
+			calls++
+			return transaction, ledger.InsufficientFundsError{Amount: transaction.Amount}
+		},
+		Notifier:    notified,
+		RetryDelay:  time.Hour,
+		MaxAttempts: 3,
+	}
+
+	//Each attempt waits for the retry delay
+	now := first.Add(9 * time.Hour)
+	for i := 0; i < 3; i++ {
+		err = runner.RunDue(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(30 * time.Minute)
+		err = runner.RunDue(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(30 * time.Minute)
+	}
+	if calls != 3 || len(*notified) != 1 {
+		t.Fatalf("calls = %d, notifications = %d; want 3 and 1", calls, len(*notified))
+	}
+
+	//The missed run is given up, the standing order carries on next month
+	schedules, _ := stores.Schedules.Schedules(schedule.Customer)
+	got := schedules[0]
+	if got.State != model.ScheduleActive || got.Attempts != 0 || got.RetryAt != nil || got.LastError == "" ||
+		!got.NextRun.Equal(time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("schedule = %+v", got)
+	}
+}
+
+func TestPermanentFailure(t *testing.T) {
+	stores := memory.New()
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	_, err := stores.Schedules.CreateSchedule(model.Schedule{
+		Customer: "10000000049", Amount: 100, Frequency: model.Daily, NextRun: day, State: model.ScheduleActive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notified := &notifications{}
+	runner := Scheduler{
+		Schedules: stores.Schedules,
+		Transfer: func(customer string, transaction model.Transaction) (model.Transaction, error) {
+			return transaction, ledger.AccountClosedError{Account: "10000000146"}
+		},
+		Notifier:    notified,
+		RetryDelay:  time.Hour,
+		MaxAttempts: 3,
+	}
+
+	err = runner.RunDue(day)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//A closed account never reopens, the schedule stops at once
+	schedules, _ := stores.Schedules.Schedules("10000000049")
+	if schedules[0].State != model.ScheduleFailed || len(*notified) != 1 {
+		t.Fatalf("schedule = %+v, notifications = %d", schedules[0], len(*notified))
+	}
+}
+
+func TestRunMadeOnce(t *testing.T) {
+	stores := memory.New()
+	var ids []string
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		user, err := stores.Users.CreateUser(model.User{Email: email, Fullname: email})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, user.ID)
+	}
+	_, err := stores.Ledger.Topup(ids[0], 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	schedule, err := stores.Schedules.CreateSchedule(model.Schedule{
+		Customer: ids[0], DebitAccount: ids[0], CreditAccount: ids[1], Amount: 1000,
+		Frequency: model.Daily, NextRun: day, State: model.ScheduleActive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runner := Scheduler{
+		Schedules: stores.Schedules,
+		Transfer: func(customer string, transaction model.Transaction) (model.Transaction, error) {
+			transaction.Date = day
+			transaction.Currency, transaction.CreditCurrency = model.DefaultCurrency, model.DefaultCurrency
+			transaction.CreditAmount = transaction.Amount
+			return stores.Ledger.Transfer(transaction)
+		},
+		Notifier:    &notifications{},
+		RetryDelay:  time.Hour,
+		MaxAttempts: 3,
+	}
+
+	//The schedule is saved as it was before the run, like after a crash, and runs again
+	now := day.Add(9 * time.Hour)
+	for i := 0; i < 2; i++ {
+		err = runner.RunDue(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			err = stores.Schedules.UpdateSchedule(schedule)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	account, _ := stores.Accounts.AccountByID(ids[0])
+	schedules, _ := stores.Schedules.Schedules(ids[0])
+	if account.Balance != 9000 || !schedules[0].NextRun.Equal(day.AddDate(0, 0, 1)) || schedules[0].LastError != "" {
+		t.Fatalf("balance = %s, schedule = %+v; want 90.00 and the next day", account.Balance, schedules[0])
+	}
+}
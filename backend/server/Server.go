@@ -0,0 +1,172 @@
+package server
+
+import (
+	//Import standard library
+	"net/http"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/achievement"
+	"gobank-server/auth"
+	"gobank-server/exp"
+	"gobank-server/fx"
+	"gobank-server/middleware"
+	"gobank-server/perks"
+	"gobank-server/store"
+	"gobank-server/user"
+	"gobank-server/utility"
+)
+
+// Config is what the routes need besides the stores. Transfers between currencies are quoted
+// by Exchange, transfers to accounts paid for the first time are limited by CoolingOff, reward
+// points are earned and redeemed by Rewards, EXP is awarded by ExpRules, turned into levels by
+// Levels, and each level's perks are looked up in Tiers. Responses to requests sent with an
+// Idempotency-Key are replayed for IdempotencyTTL
+type Config struct {
+	Exchange   fx.Exchange
+	CoolingOff user.CoolingOff
+	Rewards    user.Rewards
+	ExpRules   exp.Rules
+	Levels     utility.LevelCurve
+	Tiers      perks.Table
+
+	IdempotencyTTL time.Duration
+}
+
+// New builds the server's routes on top of the given stores. main passes the Postgres stores,
+// tests pass the in-memory ones
+func New(stores store.Stores, cfg Config) http.Handler {
+	authHandler := auth.Handler{
+		Users:    stores.Users,
+		Accounts: stores.Accounts,
+		Admins:   stores.Admins,
+		Sessions: stores.Sessions,
+		Levels:   cfg.Levels,
+	}
+	userHandler := UserHandler(stores, cfg)
+	guard := middleware.Auth{Sessions: stores.Sessions}
+	idempotent := middleware.Idempotency{Keys: stores.Idempotency, TTL: cfg.IdempotencyTTL}
+
+	//Setup mux and handle function
+	mux := http.NewServeMux()
+
+	//mux for auth (both user and admin)
+	mux.HandleFunc("/register", authHandler.Register)
+	mux.HandleFunc("/login", authHandler.Login)
+	mux.HandleFunc("/refresh", authHandler.SendCredential)
+	mux.Handle("/update-password", guard.Protect(authHandler.ChangePassword, middleware.Permission{
+		Roles: []string{"user", "admin"}, Scopes: []string{"profile:write"},
+	}))
+	mux.Handle("/info", guard.Protect(authHandler.SendInfo, middleware.Permission{
+		Roles: []string{"user", "admin"}, Scopes: []string{"profile:read"},
+	}))
+	mux.Handle("/logout", guard.Protect(authHandler.Logout, middleware.Permission{}))
+	mux.Handle("/sessions", guard.Protect(authHandler.ListSessions, middleware.Permission{
+		Scopes: []string{"sessions:manage"},
+	}))
+	mux.Handle("/sessions/revoke", guard.Protect(authHandler.RevokeSession, middleware.Permission{
+		Scopes: []string{"sessions:manage"},
+	}))
+
+	//mux for user
+	//Money-moving routes accept an Idempotency-Key header so clients can retry them safely
+	mux.Handle("/topup", guard.Protect(idempotent.Wrap(userHandler.Topup), middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"balance:write"},
+	}))
+	mux.Handle("/withdraw", guard.Protect(idempotent.Wrap(userHandler.Withdraw), middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"balance:write"},
+	}))
+	mux.Handle("/accounts", guard.Protect(userHandler.ListAccounts, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"accounts:read"},
+	}))
+	mux.Handle("/accounts/open", guard.Protect(userHandler.OpenAccount, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"accounts:write"},
+	}))
+	mux.Handle("/accounts/close", guard.Protect(userHandler.CloseAccount, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"accounts:write"},
+	}))
+	//Find account's fullname based on account number
+	mux.Handle("/fullname", guard.Protect(userHandler.GetFullname, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"accounts:read"},
+	}))
+	mux.Handle("/payees", guard.Protect(userHandler.ListPayees, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"accounts:read"},
+	}))
+	mux.Handle("/payees/add", guard.Protect(userHandler.AddPayee, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transfers:write"},
+	}))
+	mux.Handle("/payees/edit", guard.Protect(userHandler.EditPayee, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transfers:write"},
+	}))
+	mux.Handle("/payees/delete", guard.Protect(userHandler.DeletePayee, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transfers:write"},
+	}))
+	mux.Handle("/achievements", guard.Protect(userHandler.GetAchievements, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"profile:read"},
+	}))
+	mux.Handle("/perks", guard.Protect(userHandler.GetPerks, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"profile:read"},
+	}))
+	mux.Handle("/rewards", guard.Protect(userHandler.GetRewards, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transactions:read"},
+	}))
+	mux.Handle("/rewards/history", guard.Protect(userHandler.GetRewardHistory, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transactions:read"},
+	}))
+	mux.Handle("/rewards/redeem", guard.Protect(idempotent.Wrap(userHandler.RedeemPoints), middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"balance:write"},
+	}))
+	mux.Handle("/quotes", guard.Protect(userHandler.CreateQuote, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transfers:write"},
+	}))
+	mux.Handle("/transaction", guard.Protect(idempotent.Wrap(userHandler.MakeTransaction), middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transfers:write"},
+	}))
+	mux.Handle("/schedules", guard.Protect(userHandler.ListSchedules, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transactions:read"},
+	}))
+	mux.Handle("/schedules/create", guard.Protect(userHandler.CreateSchedule, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transfers:write"},
+	}))
+	mux.Handle("/schedules/cancel", guard.Protect(userHandler.CancelSchedule, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transfers:write"},
+	}))
+	mux.Handle("/transactions", guard.Protect(userHandler.GetTransactions, middleware.Permission{
+		Roles: []string{"user"}, Scopes: []string{"transactions:read"},
+	}))
+
+	return mux
+}
+
+// UserHandler is the handler behind the user routes. The scheduler makes its transfers through
+// it too, so they are checked and earn EXP and achievements like the ones sent by clients
+func UserHandler(stores store.Stores, cfg Config) user.Handler {
+	return user.Handler{
+		Users:         stores.Users,
+		Accounts:      stores.Accounts,
+		Ledger:        stores.Ledger,
+		Quotes:        stores.Quotes,
+		Schedules:     stores.Schedules,
+		Payees:        stores.Payees,
+		RewardEntries: stores.Rewards,
+		Exchange:      cfg.Exchange,
+		CoolingOff:    cfg.CoolingOff,
+		Rewards:       cfg.Rewards,
+		Exp: exp.Engine{
+			Rules:    cfg.ExpRules,
+			Events:   stores.Exp,
+			Accounts: stores.Accounts,
+			Ledger:   stores.Ledger,
+		},
+		Badges: achievement.Tracker{
+			Achievements: stores.Achievements,
+			Exp:          stores.Exp,
+			Accounts:     stores.Accounts,
+			Ledger:       stores.Ledger,
+			Payees:       stores.Payees,
+			Levels:       cfg.Levels,
+		},
+		Perks:  cfg.Tiers,
+		Levels: cfg.Levels,
+	}
+}
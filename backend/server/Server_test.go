@@ -0,0 +1,1189 @@
+package server
+
+import (
+	//Import standard library
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/exp"
+	"gobank-server/fx"
+	"gobank-server/ledger"
+	"gobank-server/model"
+	"gobank-server/perks"
+	"gobank-server/scheduler"
+	"gobank-server/store"
+	"gobank-server/store/memory"
+	"gobank-server/user"
+	"gobank-server/utility"
+)
+
+// testConfig uses the built-in rates and EXP rules, lets 200.00 go to an account in its first day
+// and makes reward points of transfers from 10.00 worth 0.01 for 30 days
+var testConfig = Config{
+	Exchange:   fx.Exchange{Rates: fx.DefaultRates(), QuoteTTL: time.Minute},
+	CoolingOff: user.CoolingOff{Period: 24 * time.Hour, Limit: 20000},
+	Rewards:    user.Rewards{Expiry: 30 * 24 * time.Hour, MinTransfer: 1000, PointValue: 1},
+	ExpRules:   exp.DefaultRules(),
+	Levels:     utility.DefaultLevelCurve,
+	Tiers:      perks.DefaultTable(),
+
+	IdempotencyTTL: 24 * time.Hour,
+}
+
+// testServer runs the real routes on top of the in-memory stores
+type testServer struct {
+	t      *testing.T
+	url    string
+	stores store.Stores
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	err := utility.SetSigningKeys(map[string][]byte{"test": []byte("test-signing-key-that-is-long-enough")}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stores := memory.New()
+	srv := httptest.NewServer(New(stores, testConfig))
+	t.Cleanup(srv.Close)
+
+	return &testServer{t: t, url: srv.URL, stores: stores}
+}
+
+// do sends body as JSON (unless it is nil) and returns the status and the raw respond body
+func (s *testServer) do(method, path, token string, body any) (int, []byte) {
+	s.t.Helper()
+	return s.doWithKey(method, path, token, "", body)
+}
+
+// doWithKey is do with an Idempotency-Key header, none when key is empty
+func (s *testServer) doWithKey(method, path, token, key string, body any) (int, []byte) {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.url+path, reader)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	return resp.StatusCode, data
+}
+
+// expectProblem checks the status and the problem's code
+func (s *testServer) expectProblem(status int, data []byte, wantStatus int, wantCode string) {
+	s.t.Helper()
+
+	if status != wantStatus {
+		s.t.Fatalf("status = %d, want %d (body %s)", status, wantStatus, data)
+	}
+
+	var problem model.Problem
+	err := json.Unmarshal(data, &problem)
+	if err != nil {
+		s.t.Fatalf("respond is not a problem: %s", data)
+	}
+	if problem.Code != wantCode || problem.Status != wantStatus {
+		s.t.Fatalf("problem = %+v, want code %s", problem, wantCode)
+	}
+}
+
+func (s *testServer) register(role, email, password, fullname string) {
+	s.t.Helper()
+
+	status, data := s.do("POST", "/register?role="+role, "", map[string]string{
+		"email": email, "password": password, "fullname": fullname,
+	})
+	if status != http.StatusCreated {
+		s.t.Fatalf("register %s: status = %d (body %s)", email, status, data)
+	}
+}
+
+func (s *testServer) login(role, email, password string) model.Credential {
+	s.t.Helper()
+
+	status, data := s.do("POST", "/login?role="+role, "", map[string]string{"email": email, "password": password})
+	if status != http.StatusOK {
+		s.t.Fatalf("login %s: status = %d (body %s)", email, status, data)
+	}
+
+	var credential model.Credential
+	err := json.Unmarshal(data, &credential)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	return credential
+}
+
+// newUser registers and logs in a user
+func (s *testServer) newUser(email, fullname string) model.Credential {
+	s.t.Helper()
+
+	s.register("user", email, "password", fullname)
+	return s.login("user", email, "password")
+}
+
+// newUserClaimingExp is newUser with a register body that also sends a large EXP, which the
+// server must ignore
+func (s *testServer) newUserClaimingExp(email, fullname string) model.Credential {
+	s.t.Helper()
+
+	status, data := s.do("POST", "/register?role=user", "", map[string]any{
+		"email": email, "password": "password", "fullname": fullname, "exp": 1000000, "state": "frozen",
+	})
+	if status != http.StatusCreated {
+		s.t.Fatalf("register %s: status = %d (body %s)", email, status, data)
+	}
+	return s.login("user", email, "password")
+}
+
+func (s *testServer) topup(token, amount string) {
+	s.t.Helper()
+
+	status, data := s.do("POST", "/topup", token, amount)
+	if status != http.StatusOK {
+		s.t.Fatalf("topup: status = %d (body %s)", status, data)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	s := newTestServer(t)
+	s.register("user", "alice@example.com", "password", "Alice")
+	s.register("admin", "root@example.com", "password", "Root")
+
+	user, err := s.stores.Users.UserByEmail("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != "10000000049" || user.Password == "password" {
+		t.Fatalf("stored user = %+v, want first account number and a hashed password", user)
+	}
+
+	status, data := s.do("POST", "/register?role=user", "", map[string]string{"email": "alice@example.com", "password": "x"})
+	s.expectProblem(status, data, http.StatusConflict, utility.CodeEmailTaken)
+
+	status, data = s.do("POST", "/register?role=admin", "", map[string]string{"email": "root@example.com", "password": "x"})
+	s.expectProblem(status, data, http.StatusConflict, utility.CodeEmailTaken)
+
+	status, data = s.do("POST", "/register?role=owner", "", map[string]string{})
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidRole)
+
+	status, data = s.do("POST", "/register?role=user", "", "not an object")
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidRequest)
+}
+
+func TestRegisterIgnoresExpAndState(t *testing.T) {
+	s := newTestServer(t)
+
+	//EXP and state are set by the server, never by the customer
+	credential := s.newUserClaimingExp("alice@example.com", "Alice")
+
+	status, data := s.do("GET", "/info", credential.Token, nil)
+	var info model.Info
+	if status != http.StatusOK || json.Unmarshal(data, &info) != nil {
+		t.Fatalf("status = %d (body %s)", status, data)
+	}
+	if info.Level != 1 || info.Exp != 0 {
+		t.Fatalf("info = %+v, want level 1 and 0 EXP", info)
+	}
+
+	user, err := s.stores.Users.UserByEmail("alice@example.com")
+	if err != nil || user.State != "active" {
+		t.Fatalf("stored user = %+v, %v, want active", user, err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	s := newTestServer(t)
+	s.register("user", "alice@example.com", "password", "Alice")
+
+	credential := s.login("user", "alice@example.com", "password")
+	if credential.Token == "" || credential.RefreshToken == "" {
+		t.Fatalf("credential = %+v, want both tokens", credential)
+	}
+	if credential.Info.Fullname != "Alice" || credential.Info.Role != "user" || credential.Info.Currency != model.DefaultCurrency {
+		t.Fatalf("info = %+v", credential.Info)
+	}
+
+	status, data := s.do("POST", "/login?role=user", "", map[string]string{"email": "alice@example.com", "password": "wrong"})
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeInvalidCredential)
+
+	status, data = s.do("POST", "/login?role=user", "", map[string]string{"email": "bob@example.com", "password": "password"})
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeInvalidCredential)
+
+	status, data = s.do("POST", "/login?role=admin", "", map[string]string{"email": "alice@example.com", "password": "password"})
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeInvalidCredential)
+}
+
+func TestLoginUpgradesLegacyHash(t *testing.T) {
+	s := newTestServer(t)
+
+	//sha256("password"), as stored before argon2id
+	legacy := "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"
+	_, err := s.stores.Users.CreateUser(model.User{Email: "old@example.com", Password: legacy, Fullname: "Old", State: "active"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.login("user", "old@example.com", "password")
+
+	user, err := s.stores.Users.UserByEmail("old@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Password == legacy {
+		t.Fatal("legacy hash was not upgraded on login")
+	}
+	s.login("user", "old@example.com", "password")
+}
+
+func TestRefresh(t *testing.T) {
+	s := newTestServer(t)
+	credential := s.newUser("alice@example.com", "Alice")
+
+	status, data := s.do("POST", "/refresh", "", credential.RefreshToken)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d (body %s)", status, data)
+	}
+	var refreshed model.Credential
+	err := json.Unmarshal(data, &refreshed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.RefreshToken == credential.RefreshToken || refreshed.Info.Fullname != "Alice" {
+		t.Fatalf("refreshed = %+v, want a new refresh token and the user's info", refreshed)
+	}
+
+	//Using the old refresh token again revokes the whole session
+	status, data = s.do("POST", "/refresh", "", credential.RefreshToken)
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeSessionExpired)
+
+	status, data = s.do("POST", "/refresh", "", refreshed.RefreshToken)
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeSessionExpired)
+
+	status, data = s.do("POST", "/refresh", "", "garbage")
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeSessionExpired)
+}
+
+func TestInfo(t *testing.T) {
+	s := newTestServer(t)
+	credential := s.newUser("alice@example.com", "Alice")
+	s.topup(credential.Token, "100")
+
+	//Info is read with the access token, the refresh token stays usable
+	status, data := s.do("GET", "/info", credential.Token, nil)
+	var info model.Info
+	if status != http.StatusOK || json.Unmarshal(data, &info) != nil {
+		t.Fatalf("status = %d (body %s)", status, data)
+	}
+	if info.Fullname != "Alice" || info.Balance != 10000 || len(info.Accounts) != 1 {
+		t.Fatalf("info = %+v", info)
+	}
+
+	status, data = s.do("POST", "/refresh", "", credential.RefreshToken)
+	if status != http.StatusOK {
+		t.Fatalf("refresh after info: status = %d (body %s)", status, data)
+	}
+}
+
+func TestUpdatePassword(t *testing.T) {
+	s := newTestServer(t)
+	credential := s.newUser("alice@example.com", "Alice")
+
+	status, data := s.do("UPDATE", "/update-password", credential.Token, "password")
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeSamePassword)
+
+	status, data = s.do("UPDATE", "/update-password", credential.Token, "new password")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d (body %s)", status, data)
+	}
+	s.login("user", "alice@example.com", "new password")
+
+	s.register("admin", "root@example.com", "password", "Root")
+	admin := s.login("admin", "root@example.com", "password")
+	status, data = s.do("UPDATE", "/update-password", admin.Token, "admin password")
+	if status != http.StatusOK {
+		t.Fatalf("admin status = %d (body %s)", status, data)
+	}
+	s.login("admin", "root@example.com", "admin password")
+}
+
+func TestLogout(t *testing.T) {
+	s := newTestServer(t)
+	credential := s.newUser("alice@example.com", "Alice")
+
+	status, data := s.do("POST", "/logout", credential.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d (body %s)", status, data)
+	}
+
+	status, data = s.do("GET", "/sessions", credential.Token, nil)
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeTokenInvalid)
+
+	status, data = s.do("POST", "/refresh", "", credential.RefreshToken)
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeSessionExpired)
+}
+
+func TestSessions(t *testing.T) {
+	s := newTestServer(t)
+	first := s.newUser("alice@example.com", "Alice")
+	second := s.login("user", "alice@example.com", "password")
+
+	status, data := s.do("GET", "/sessions", first.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d (body %s)", status, data)
+	}
+	var sessions []model.Session
+	err := json.Unmarshal(data, &sessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+
+	//Revoke the other session from the first one
+	var other string
+	for _, session := range sessions {
+		if !session.Current {
+			other = session.ID
+		}
+	}
+	status, data = s.do("POST", "/sessions/revoke", first.Token, other)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d (body %s)", status, data)
+	}
+
+	status, data = s.do("GET", "/sessions", second.Token, nil)
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeTokenInvalid)
+
+	status, data = s.do("POST", "/sessions/revoke", first.Token, other)
+	s.expectProblem(status, data, http.StatusNotFound, utility.CodeSessionNotFound)
+}
+
+func TestProtect(t *testing.T) {
+	s := newTestServer(t)
+	s.register("admin", "root@example.com", "password", "Root")
+	admin := s.login("admin", "root@example.com", "password")
+
+	status, data := s.do("POST", "/topup", "", "10")
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeTokenInvalid)
+
+	status, data = s.do("POST", "/topup", admin.Token+"x", "10")
+	s.expectProblem(status, data, http.StatusUnauthorized, utility.CodeTokenInvalid)
+
+	//Admins have no balance
+	status, data = s.do("POST", "/topup", admin.Token, "10")
+	s.expectProblem(status, data, http.StatusForbidden, utility.CodeForbidden)
+}
+
+func TestTopupAndWithdraw(t *testing.T) {
+	s := newTestServer(t)
+	credential := s.newUser("alice@example.com", "Alice")
+
+	status, data := s.do("POST", "/topup", credential.Token, "10.50")
+	if status != http.StatusOK || string(data) != `"10.50"` {
+		t.Fatalf("topup: status = %d, body %s", status, data)
+	}
+
+	status, data = s.do("POST", "/withdraw", credential.Token, "0.50")
+	if status != http.StatusOK || string(data) != `"10.00"` {
+		t.Fatalf("withdraw: status = %d, body %s", status, data)
+	}
+
+	status, data = s.do("POST", "/withdraw", credential.Token, "10.01")
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeInsufficientFunds)
+
+	status, data = s.do("POST", "/topup", credential.Token, "-1")
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidAmount)
+
+	status, data = s.do("POST", "/topup", credential.Token, "1.001")
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidRequest)
+}
+
+func TestFullname(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	s.newUser("bob@example.com", "Bob")
+
+	status, data := s.do("POST", "/fullname", alice.Token, "10000000146")
+	if status != http.StatusOK || string(data) != `"Bob"` {
+		t.Fatalf("status = %d, body %s", status, data)
+	}
+
+	status, data = s.do("POST", "/fullname", alice.Token, "999999999")
+	s.expectProblem(status, data, http.StatusNotFound, utility.CodeAccountNotFound)
+
+	//Two digits swapped
+	status, data = s.do("POST", "/fullname", alice.Token, "10000000416")
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidAccount)
+}
+
+func TestTransfer(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "100")
+
+	transfer := func(debit, credit, amount string) (int, []byte) {
+		money, err := model.ParseMoney(amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.do("POST", "/transaction", alice.Token, model.Transaction{
+			DebitAccount: debit, CreditAccount: credit, Amount: money, Description: "Lunch",
+		})
+	}
+
+	//Without a debit account the checking account pays
+	status, data := transfer("", bob.Info.ID, "30")
+	if status != http.StatusCreated {
+		t.Fatalf("status = %d (body %s)", status, data)
+	}
+
+	status, data = transfer("", alice.Info.ID, "1")
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeSelfTransfer)
+
+	status, data = transfer("", bob.Info.ID, "0")
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidAmount)
+
+	status, data = transfer("", "999999999", "1")
+	s.expectProblem(status, data, http.StatusNotFound, utility.CodeAccountNotFound)
+
+	status, data = transfer("", "12345", "1")
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidAccount)
+
+	status, data = transfer("", bob.Info.ID, "70.01")
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeInsufficientFunds)
+
+	aliceAccount, _ := s.stores.Accounts.AccountByID(alice.Info.ID)
+	bobAccount, _ := s.stores.Accounts.AccountByID(bob.Info.ID)
+	if aliceAccount.Balance != 7000 || bobAccount.Balance != 3000 {
+		t.Fatalf("balances = %s and %s, want 70.00 and 30.00", aliceAccount.Balance, bobAccount.Balance)
+	}
+
+	mismatches, err := s.stores.Ledger.Reconcile()
+	if err != nil || len(mismatches) != 0 {
+		t.Fatalf("reconcile = %v, %v", mismatches, err)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "100")
+	for _, amount := range []model.Money{1000, 2000, 3000} {
+		status, data := s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: bob.Info.ID, Amount: amount})
+		if status != http.StatusCreated {
+			t.Fatalf("status = %d (body %s)", status, data)
+		}
+	}
+
+	page := func(query string) model.TransactionPage {
+		status, data := s.do("GET", "/transactions"+query, alice.Token, nil)
+		if status != http.StatusOK {
+			t.Fatalf("%s: status = %d (body %s)", query, status, data)
+		}
+		var page model.TransactionPage
+		err := json.Unmarshal(data, &page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+
+	//Newest first, two per page
+	first := page("?limit=2")
+	if len(first.Transactions) != 2 || first.Transactions[0].Amount != 3000 || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	second := page("?limit=2&cursor=" + first.NextCursor)
+	if len(second.Transactions) != 2 || second.Transactions[1].Type != "topup" || second.NextCursor != "" {
+		t.Fatalf("second page = %+v", second)
+	}
+
+	if out := page("?direction=out&min=15"); len(out.Transactions) != 2 {
+		t.Fatalf("outgoing of at least 15 = %+v", out)
+	}
+	if in := page("?direction=in"); len(in.Transactions) != 1 {
+		t.Fatalf("incoming = %+v", in)
+	}
+
+	status, data := s.do("GET", "/transactions?limit=1000", alice.Token, nil)
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidFilter)
+}
+
+func TestAccounts(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+
+	//Registration opens a checking account with the customer's number
+	if len(alice.Info.Accounts) != 1 || alice.Info.Accounts[0].ID != alice.Info.ID || alice.Info.Accounts[0].Type != model.Checking {
+		t.Fatalf("accounts after registration = %+v", alice.Info.Accounts)
+	}
+
+	status, data := s.do("POST", "/accounts/open", alice.Token, map[string]string{"type": "savings", "name": "Rainy day"})
+	if status != http.StatusCreated {
+		t.Fatalf("open: status = %d (body %s)", status, data)
+	}
+	var savings model.Account
+	err := json.Unmarshal(data, &savings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if savings.Customer != alice.Info.ID || savings.State != model.AccountOpen || model.ValidateAccountNumber(savings.ID) != nil {
+		t.Fatalf("opened account = %+v", savings)
+	}
+
+	status, data = s.do("POST", "/accounts/open", alice.Token, map[string]string{"type": "loan"})
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidType)
+	status, data = s.do("POST", "/accounts/open", alice.Token, map[string]string{"type": "pot"})
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidRequest)
+
+	status, data = s.do("GET", "/accounts", alice.Token, nil)
+	var accounts []model.Account
+	if status != http.StatusOK || json.Unmarshal(data, &accounts) != nil || len(accounts) != 2 || accounts[1].ID != savings.ID {
+		t.Fatalf("list: status = %d, body %s", status, data)
+	}
+
+	//Money goes to the account picked, and between the customer's own accounts
+	status, data = s.do("POST", "/topup?account="+savings.ID, alice.Token, "50")
+	if status != http.StatusOK || string(data) != `"50.00"` {
+		t.Fatalf("topup savings: status = %d, body %s", status, data)
+	}
+	status, data = s.do("POST", "/transaction", alice.Token, model.Transaction{DebitAccount: savings.ID, CreditAccount: alice.Info.ID, Amount: 2000})
+	if status != http.StatusCreated {
+		t.Fatalf("transfer to own account: status = %d (body %s)", status, data)
+	}
+
+	//Other customers' accounts cannot be used
+	status, data = s.do("POST", "/withdraw?account="+savings.ID, bob.Token, "1")
+	s.expectProblem(status, data, http.StatusNotFound, utility.CodeAccountNotFound)
+	status, data = s.do("POST", "/transaction", bob.Token, model.Transaction{DebitAccount: alice.Info.ID, CreditAccount: bob.Info.ID, Amount: 100})
+	s.expectProblem(status, data, http.StatusNotFound, utility.CodeAccountNotFound)
+
+	credential := s.login("user", "alice@example.com", "password")
+	if len(credential.Info.Accounts) != 2 || credential.Info.Balance != 5000 {
+		t.Fatalf("info = %+v, want two accounts holding 50.00", credential.Info)
+	}
+
+	//Only empty accounts can be closed, and never the last open one
+	status, data = s.do("POST", "/accounts/close", alice.Token, savings.ID)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeAccountNotEmpty)
+	status, data = s.do("POST", "/withdraw?account="+savings.ID, alice.Token, "30")
+	if status != http.StatusOK {
+		t.Fatalf("withdraw savings: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/accounts/close", alice.Token, savings.ID)
+	if status != http.StatusOK {
+		t.Fatalf("close: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/accounts/close", alice.Token, alice.Info.ID)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeLastAccount)
+
+	status, data = s.do("POST", "/topup?account="+savings.ID, alice.Token, "1")
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeAccountClosed)
+	status, data = s.do("POST", "/fullname", bob.Token, savings.ID)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeAccountClosed)
+
+	//History of a closed account is still readable
+	status, data = s.do("GET", "/transactions?account="+savings.ID, alice.Token, nil)
+	var page model.TransactionPage
+	if status != http.StatusOK || json.Unmarshal(data, &page) != nil || len(page.Transactions) != 3 {
+		t.Fatalf("closed account history: status = %d, body %s", status, data)
+	}
+}
+
+func TestQuotedTransfer(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "100")
+
+	status, data := s.do("POST", "/accounts/open", bob.Token, map[string]string{"type": "savings", "currency": "eur"})
+	var euros model.Account
+	if status != http.StatusCreated || json.Unmarshal(data, &euros) != nil || euros.Currency != "EUR" {
+		t.Fatalf("open: status = %d, body %s", status, data)
+	}
+	status, data = s.do("POST", "/accounts/open", bob.Token, map[string]string{"type": "savings", "currency": "XYZ"})
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidCurrency)
+
+	//Money is only converted at a quoted rate
+	transfer := model.Transaction{CreditAccount: euros.ID, Amount: 10000}
+	status, data = s.do("POST", "/transaction", alice.Token, transfer)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeQuoteRequired)
+
+	status, data = s.do("POST", "/quotes", alice.Token, map[string]string{"credit account": bob.Info.ID, "amount": "100"})
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidRequest)
+
+	status, data = s.do("POST", "/quotes", alice.Token, map[string]string{"credit account": euros.ID, "amount": "100"})
+	var quote model.Quote
+	if status != http.StatusCreated || json.Unmarshal(data, &quote) != nil {
+		t.Fatalf("quote: status = %d, body %s", status, data)
+	}
+	if quote.Rate.String() != "0.92" || quote.Converted != 9200 || quote.From != "USD" || quote.To != "EUR" {
+		t.Fatalf("quote = %+v", quote)
+	}
+
+	//The quote only covers what it was asked for, and only for its customer
+	transfer.QuoteID = quote.ID
+	status, data = s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: euros.ID, Amount: 5000, QuoteID: quote.ID})
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeInvalidQuote)
+
+	status, data = s.do("POST", "/transaction", alice.Token, transfer)
+	if status != http.StatusCreated {
+		t.Fatalf("transfer: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/transaction", alice.Token, transfer)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeInvalidQuote)
+
+	//Both legs and the rate are recorded
+	status, data = s.do("GET", "/transactions?account="+euros.ID, bob.Token, nil)
+	var page model.TransactionPage
+	if status != http.StatusOK || json.Unmarshal(data, &page) != nil || len(page.Transactions) != 1 {
+		t.Fatalf("history: status = %d, body %s", status, data)
+	}
+	received := page.Transactions[0]
+	if received.Amount != 10000 || received.Currency != "USD" || received.CreditAmount != 9200 ||
+		received.CreditCurrency != "EUR" || received.Rate.String() != "0.92" || received.QuoteID != quote.ID {
+		t.Fatalf("transaction = %+v", received)
+	}
+
+	//Expired quotes are refused
+	s.topup(alice.Token, "100")
+	status, data = s.do("POST", "/quotes", alice.Token, map[string]string{"credit account": euros.ID, "amount": "100"})
+	if status != http.StatusCreated || json.Unmarshal(data, &quote) != nil {
+		t.Fatalf("quote: status = %d, body %s", status, data)
+	}
+	quote.Customer = alice.Info.ID
+	quote.ExpiresAt = time.Now().Add(-time.Second)
+	err := s.stores.Quotes.CreateQuote(quote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, data = s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: euros.ID, Amount: 10000, QuoteID: quote.ID})
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeQuoteExpired)
+
+	mismatches, err := s.stores.Ledger.Reconcile()
+	if err != nil || len(mismatches) != 0 {
+		t.Fatalf("Reconcile = %v, %v", mismatches, err)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+
+	//A retried topup is answered from the first response and moves no money
+	for i := 0; i < 2; i++ {
+		status, data := s.doWithKey("POST", "/topup", alice.Token, "topup-1", "10")
+		if status != http.StatusOK || string(data) != `"10.00"` {
+			t.Fatalf("topup %d: status = %d, body %s", i, status, data)
+		}
+	}
+
+	//Problems are replayed too
+	for i := 0; i < 2; i++ {
+		status, data := s.doWithKey("POST", "/withdraw", alice.Token, "withdraw-1", "20")
+		s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeInsufficientFunds)
+	}
+
+	transfer := model.Transaction{CreditAccount: bob.Info.ID, Amount: 400}
+	for i := 0; i < 2; i++ {
+		status, data := s.doWithKey("POST", "/transaction", alice.Token, "transfer-1", transfer)
+		if status != http.StatusCreated {
+			t.Fatalf("transfer %d: status = %d (body %s)", i, status, data)
+		}
+	}
+
+	//A key belongs to one request
+	transfer.Amount = 500
+	status, data := s.doWithKey("POST", "/transaction", alice.Token, "transfer-1", transfer)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeIdempotencyKeyReused)
+
+	//Keys are kept per customer
+	status, data = s.doWithKey("POST", "/topup", bob.Token, "topup-1", "10")
+	if status != http.StatusOK || string(data) != `"14.00"` {
+		t.Fatalf("Bob's topup: status = %d, body %s", status, data)
+	}
+
+	account, err := s.stores.Accounts.AccountByID(alice.Info.ID)
+	if err != nil || account.Balance != 600 {
+		t.Fatalf("Alice's balance = %s, %v; want 6.00", account.Balance, err)
+	}
+}
+
+func TestSchedules(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "100")
+
+	tomorrow := model.Day(time.Now()).AddDate(0, 0, 1)
+	status, data := s.do("POST", "/schedules/create", alice.Token, model.Schedule{
+		CreditAccount: bob.Info.ID, Amount: 2500, Description: "Rent", Frequency: "weekly", NextRun: tomorrow,
+	})
+	var schedule model.Schedule
+	if status != http.StatusCreated || json.Unmarshal(data, &schedule) != nil {
+		t.Fatalf("create: status = %d (body %s)", status, data)
+	}
+	if schedule.DebitAccount != alice.Info.ID || !schedule.NextRun.Equal(tomorrow) || schedule.State != model.ScheduleActive {
+		t.Fatalf("schedule = %+v", schedule)
+	}
+
+	status, data = s.do("POST", "/schedules/create", alice.Token, model.Schedule{CreditAccount: bob.Info.ID, Amount: 100, Frequency: "yearly"})
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidSchedule)
+	status, data = s.do("POST", "/schedules/create", alice.Token, model.Schedule{
+		CreditAccount: bob.Info.ID, Amount: 100, Frequency: "once", NextRun: tomorrow.AddDate(0, 0, -2),
+	})
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidSchedule)
+	status, data = s.do("POST", "/schedules/create", alice.Token, model.Schedule{CreditAccount: alice.Info.ID, Amount: 100, Frequency: "once"})
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeSelfTransfer)
+
+	status, data = s.do("POST", "/accounts/open", bob.Token, map[string]string{"type": "savings", "currency": "EUR"})
+	var euros model.Account
+	if status != http.StatusCreated || json.Unmarshal(data, &euros) != nil {
+		t.Fatalf("open: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/schedules/create", alice.Token, model.Schedule{CreditAccount: euros.ID, Amount: 100, Frequency: "once"})
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeInvalidCurrency)
+
+	//The scheduler makes the transfer on the day, through the same path as the clients
+	transfers := UserHandler(s.stores, testConfig)
+	runner := scheduler.Scheduler{
+		Schedules:   s.stores.Schedules,
+		Transfer:    transfers.Transfer,
+		Notifier:    scheduler.LogNotifier{},
+		RetryDelay:  time.Hour,
+		MaxAttempts: 3,
+	}
+	err := runner.RunDue(tomorrow.Add(8 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bobAccount, _ := s.stores.Accounts.AccountByID(bob.Info.ID)
+	if bobAccount.Balance != 2500 {
+		t.Fatalf("bob's balance = %s, want 25.00", bobAccount.Balance)
+	}
+
+	status, data = s.do("GET", "/schedules", alice.Token, nil)
+	var schedules []model.Schedule
+	if status != http.StatusOK || json.Unmarshal(data, &schedules) != nil || len(schedules) != 1 {
+		t.Fatalf("list: status = %d (body %s)", status, data)
+	}
+	if !schedules[0].NextRun.Equal(tomorrow.AddDate(0, 0, 7)) {
+		t.Fatalf("next run = %s, want a week after the first", schedules[0].NextRun)
+	}
+
+	//Only the owner can cancel, and only once
+	status, data = s.do("POST", "/schedules/cancel", bob.Token, schedule.ID)
+	s.expectProblem(status, data, http.StatusNotFound, utility.CodeScheduleNotFound)
+	status, data = s.do("POST", "/schedules/cancel", alice.Token, schedule.ID)
+	if status != http.StatusOK {
+		t.Fatalf("cancel: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/schedules/cancel", alice.Token, schedule.ID)
+	s.expectProblem(status, data, http.StatusNotFound, utility.CodeScheduleNotFound)
+
+	err = runner.RunDue(tomorrow.AddDate(0, 0, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobAccount, _ = s.stores.Accounts.AccountByID(bob.Info.ID)
+	if bobAccount.Balance != 2500 {
+		t.Fatalf("cancelled schedule ran, bob's balance = %s", bobAccount.Balance)
+	}
+}
+
+func TestPayees(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	carol := s.newUser("carol@example.com", "Carol")
+	dave := s.newUser("dave@example.com", "Dave")
+	s.topup(alice.Token, "1000")
+
+	status, data := s.do("POST", "/payees/add", alice.Token, map[string]string{"account": bob.Info.ID})
+	var payee model.Payee
+	if status != http.StatusCreated || json.Unmarshal(data, &payee) != nil {
+		t.Fatalf("add: status = %d (body %s)", status, data)
+	}
+	if payee.Name != "Bob" || payee.Nickname != "Bob" || payee.Account != bob.Info.ID {
+		t.Fatalf("payee = %+v", payee)
+	}
+
+	status, data = s.do("POST", "/payees/add", alice.Token, map[string]string{"account": bob.Info.ID})
+	s.expectProblem(status, data, http.StatusConflict, utility.CodePayeeExists)
+	status, data = s.do("POST", "/payees/add", alice.Token, map[string]string{"account": alice.Info.ID})
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidRequest)
+
+	//A new payee can only be sent the cooling-off limit in total, failed transfers do not count
+	transfer := func(account string, amount model.Money) (int, []byte) {
+		return s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: account, Amount: amount})
+	}
+	status, data = transfer(bob.Info.ID, 12000)
+	if status != http.StatusCreated {
+		t.Fatalf("transfer: status = %d (body %s)", status, data)
+	}
+	status, data = transfer(bob.Info.ID, 8001)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeCoolingOffLimit)
+	status, data = transfer(bob.Info.ID, 8000)
+	if status != http.StatusCreated {
+		t.Fatalf("transfer: status = %d (body %s)", status, data)
+	}
+
+	stored, _ := s.stores.Payees.PayeeByAccount(alice.Info.ID, bob.Info.ID)
+	if stored.LastUsedAt == nil || stored.CoolingOffSent != 20000 {
+		t.Fatalf("payee after transfers = %+v", stored)
+	}
+
+	//Deleting the payee, or saving it again, does not lift the limit
+	status, data = s.do("POST", "/payees/delete", alice.Token, payee.ID)
+	if status != http.StatusOK {
+		t.Fatalf("delete: status = %d (body %s)", status, data)
+	}
+	status, data = transfer(bob.Info.ID, 1)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeCoolingOffLimit)
+	status, data = s.do("POST", "/payees/add", alice.Token, map[string]string{"account": bob.Info.ID})
+	if status != http.StatusCreated || json.Unmarshal(data, &payee) != nil {
+		t.Fatalf("add again: status = %d (body %s)", status, data)
+	}
+	if !payee.CoolingOffUntil.Equal(stored.CoolingOffUntil) || payee.CoolingOffSent != 20000 {
+		t.Fatalf("payee saved again = %+v, want cooling-off %+v", payee, stored)
+	}
+
+	//An account never saved cools off from its first payment
+	status, data = transfer(carol.Info.ID, 20001)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeCoolingOffLimit)
+	status, data = transfer(carol.Info.ID, 20000)
+	if status != http.StatusCreated {
+		t.Fatalf("transfer to an account never saved: status = %d (body %s)", status, data)
+	}
+
+	//Once the period is over the limit no longer applies
+	_, err := s.stores.Payees.CreatePayee(model.Payee{
+		Customer: alice.Info.ID, Nickname: "Dave", Account: dave.Info.ID, Name: "Dave",
+		AddedAt: time.Now(), CoolingOffUntil: time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, data = transfer(dave.Info.ID, 30000)
+	if status != http.StatusCreated {
+		t.Fatalf("transfer after cooling-off: status = %d (body %s)", status, data)
+	}
+
+	status, data = s.do("POST", "/payees/edit", alice.Token, map[string]any{"id": payee.ID, "nickname": "Landlord"})
+	if status != http.StatusOK || json.Unmarshal(data, &payee) != nil || payee.Nickname != "Landlord" {
+		t.Fatalf("edit: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/payees/edit", bob.Token, map[string]any{"id": payee.ID, "nickname": "Tenant"})
+	s.expectProblem(status, data, http.StatusNotFound, utility.CodePayeeNotFound)
+
+	status, data = s.do("POST", "/payees/delete", alice.Token, payee.ID)
+	if status != http.StatusOK {
+		t.Fatalf("delete: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("GET", "/payees", alice.Token, nil)
+	var payees []model.Payee
+	if status != http.StatusOK || json.Unmarshal(data, &payees) != nil || len(payees) != 1 || payees[0].Account != dave.Info.ID {
+		t.Fatalf("list: status = %d (body %s)", status, data)
+	}
+}
+
+func TestCoolingOffCountsAmountCredited(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "300")
+
+	var euros model.Account
+	status, data := s.do("POST", "/accounts/open", bob.Token, map[string]string{"type": "savings", "currency": "eur"})
+	if status != http.StatusCreated || json.Unmarshal(data, &euros) != nil {
+		t.Fatalf("open: status = %d, body %s", status, data)
+	}
+
+	//send converts amount to euros, then sends it to Bob's euro account
+	send := func(amount string) (int, []byte) {
+		t.Helper()
+
+		var quote model.Quote
+		status, data := s.do("POST", "/quotes", alice.Token, map[string]string{"credit account": euros.ID, "amount": amount})
+		if status != http.StatusCreated || json.Unmarshal(data, &quote) != nil {
+			t.Fatalf("quote: status = %d, body %s", status, data)
+		}
+		return s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: euros.ID, Amount: quote.Amount, QuoteID: quote.ID})
+	}
+
+	//210.00 USD arrive as 193.20 EUR, under the 200.00 limit of the euro account
+	for _, amount := range []string{"150", "60"} {
+		status, data = send(amount)
+		if status != http.StatusCreated {
+			t.Fatalf("transfer of %s: status = %d (body %s)", amount, status, data)
+		}
+	}
+	status, data = send("10")
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeCoolingOffLimit)
+}
+
+func TestNewCustomerGetsLowestTier(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUserClaimingExp("alice@example.com", "Alice")
+
+	var perks model.Perks
+	status, data := s.do("GET", "/perks", alice.Token, nil)
+	if status != http.StatusOK || json.Unmarshal(data, &perks) != nil {
+		t.Fatalf("perks: status = %d (body %s)", status, data)
+	}
+	if perks.Exp != 0 || perks.Level != 1 || perks.Tier.Name != "Bronze" {
+		t.Fatalf("perks = %+v, want Bronze at level 1", perks)
+	}
+
+	//The limits are Bronze's too
+	s.topup(alice.Token, "2000")
+	status, data = s.do("POST", "/withdraw", alice.Token, "500.01")
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeDailyLimit)
+}
+
+func TestPerks(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "2000")
+
+	var perks model.Perks
+	status, data := s.do("GET", "/perks", alice.Token, nil)
+	if status != http.StatusOK || json.Unmarshal(data, &perks) != nil {
+		t.Fatalf("perks: status = %d (body %s)", status, data)
+	}
+	if perks.Level != 1 || perks.LevelMin != 0 || perks.LevelMax != 200 || perks.Tier.Name != "Bronze" || perks.Next == nil || perks.Next.Name != "Silver" {
+		t.Fatalf("perks = %+v", perks)
+	}
+
+	//Bronze withdraws up to 500.00 a day, from all of the customer's accounts together
+	status, data = s.do("POST", "/withdraw", alice.Token, "400")
+	if status != http.StatusOK {
+		t.Fatalf("withdraw: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/withdraw", alice.Token, "100.01")
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeDailyLimit)
+
+	//Opening another account does not give a new limit, moving money into it is not limited
+	var savings model.Account
+	status, data = s.do("POST", "/accounts/open", alice.Token, map[string]string{"type": "savings"})
+	if status != http.StatusCreated || json.Unmarshal(data, &savings) != nil {
+		t.Fatalf("open: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: savings.ID, Amount: 20000})
+	if status != http.StatusCreated {
+		t.Fatalf("transfer to savings: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/withdraw?account="+savings.ID, alice.Token, "100.01")
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeDailyLimit)
+
+	//Reaching level 3 moves Alice to Silver, paying Bob earns 0.25% cashback
+	_, err := s.stores.Exp.AddExpEvent(model.ExpEvent{Customer: alice.Info.ID, Kind: model.ExpStreak, Points: 600, At: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, data = s.do("GET", "/perks", alice.Token, nil)
+	if status != http.StatusOK || json.Unmarshal(data, &perks) != nil || perks.Level != 3 || perks.Tier.Name != "Silver" {
+		t.Fatalf("perks: status = %d (body %s)", status, data)
+	}
+	status, data = s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: bob.Info.ID, Amount: 10000})
+	if status != http.StatusCreated {
+		t.Fatalf("transfer: status = %d (body %s)", status, data)
+	}
+	account, _ := s.stores.Accounts.AccountByID(alice.Info.ID)
+	if account.Balance != 130025 {
+		t.Fatalf("balance = %s, want 1300.25", account.Balance)
+	}
+	mismatches, err := s.stores.Ledger.Reconcile()
+	if err != nil || len(mismatches) > 0 {
+		t.Fatalf("reconcile = %v, %v", mismatches, err)
+	}
+}
+
+func TestAchievements(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "100")
+
+	status, data := s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: bob.Info.ID, Amount: 1000})
+	if status != http.StatusCreated {
+		t.Fatalf("transfer: status = %d (body %s)", status, data)
+	}
+
+	var badges []model.Badge
+	status, data = s.do("GET", "/achievements", alice.Token, nil)
+	if status != http.StatusOK || json.Unmarshal(data, &badges) != nil {
+		t.Fatalf("achievements: status = %d (body %s)", status, data)
+	}
+	if len(badges) != 4 || badges[0].ID != "first_transfer" || badges[0].UnlockedAt == nil || badges[1].UnlockedAt != nil {
+		t.Fatalf("badges = %s", data)
+	}
+
+	//Bob has not unlocked anything by receiving money
+	var bobBadges []model.Badge
+	status, data = s.do("GET", "/achievements", bob.Token, nil)
+	if status != http.StatusOK || json.Unmarshal(data, &bobBadges) != nil || bobBadges[0].UnlockedAt != nil {
+		t.Fatalf("achievements: status = %d (body %s)", status, data)
+	}
+}
+
+func TestNewCustomerEarnsBasePoints(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUserClaimingExp("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "100")
+
+	//The EXP sent at registration is ignored, so a point is earned per whole unit like Bronze
+	status, data := s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: bob.Info.ID, Amount: 3050})
+	if status != http.StatusCreated {
+		t.Fatalf("transfer: status = %d (body %s)", status, data)
+	}
+
+	var wallet model.RewardWallet
+	status, data = s.do("GET", "/rewards", alice.Token, nil)
+	if status != http.StatusOK || json.Unmarshal(data, &wallet) != nil {
+		t.Fatalf("rewards: status = %d (body %s)", status, data)
+	}
+	if wallet.Points != 30 {
+		t.Fatalf("wallet = %s, want 30 points", data)
+	}
+}
+
+func TestRewards(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "100")
+
+	//Bronze earns a point for each whole unit sent to another customer, small transfers earn nothing
+	for _, amount := range []model.Money{3050, 500} {
+		status, data := s.do("POST", "/transaction", alice.Token, model.Transaction{CreditAccount: bob.Info.ID, Amount: amount})
+		if status != http.StatusCreated {
+			t.Fatalf("transfer: status = %d (body %s)", status, data)
+		}
+	}
+
+	var wallet model.RewardWallet
+	status, data := s.do("GET", "/rewards", alice.Token, nil)
+	if status != http.StatusOK || json.Unmarshal(data, &wallet) != nil {
+		t.Fatalf("rewards: status = %d (body %s)", status, data)
+	}
+	if wallet.Points != 30 || wallet.Value != 30 || wallet.Expiring != 30 || wallet.NextExpiry == nil {
+		t.Fatalf("wallet = %s", data)
+	}
+
+	status, data = s.do("POST", "/rewards/redeem", alice.Token, 31)
+	s.expectProblem(status, data, http.StatusUnprocessableEntity, utility.CodeInsufficientPoints)
+	status, data = s.do("POST", "/rewards/redeem", alice.Token, 0)
+	s.expectProblem(status, data, http.StatusBadRequest, utility.CodeInvalidAmount)
+
+	//Redeemed points are paid from the bank's rewards account
+	var redemption model.Redemption
+	status, data = s.do("POST", "/rewards/redeem", alice.Token, 20)
+	if status != http.StatusOK || json.Unmarshal(data, &redemption) != nil {
+		t.Fatalf("redeem: status = %d (body %s)", status, data)
+	}
+	if redemption.Amount != 20 || redemption.Balance != 10000-3050-500+20 {
+		t.Fatalf("redemption = %s", data)
+	}
+	account, err := s.stores.Accounts.AccountByID(alice.Info.ID)
+	if err != nil || account.Balance != 10000-3050-500+20 {
+		t.Fatalf("balance = %s, %v, want 64.70", account.Balance, err)
+	}
+	_, err = s.stores.Accounts.AccountByID(ledger.RewardsAccount)
+	if _, ok := err.(store.NotFoundError); !ok {
+		t.Fatalf("rewards account: err = %v, want NotFoundError", err)
+	}
+
+	var history []model.RewardEntry
+	status, data = s.do("GET", "/rewards/history", alice.Token, nil)
+	if status != http.StatusOK || json.Unmarshal(data, &history) != nil {
+		t.Fatalf("history: status = %d (body %s)", status, data)
+	}
+	if len(history) != 2 || history[0].Kind != model.RewardRedeem || history[0].Points != -20 || history[1].Points != 30 {
+		t.Fatalf("history = %s", data)
+	}
+
+	mismatches, err := s.stores.Ledger.Reconcile()
+	if err != nil || len(mismatches) > 0 {
+		t.Fatalf("reconcile = %v, %v", mismatches, err)
+	}
+}
+
+func TestPingPongEarnsNothing(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.newUser("alice@example.com", "Alice")
+	bob := s.newUser("bob@example.com", "Bob")
+	s.topup(alice.Token, "200")
+
+	//Silver pays 0.25% cashback and 2 points a unit
+	_, err := s.stores.Exp.AddExpEvent(model.ExpEvent{Customer: alice.Info.ID, Kind: model.ExpStreak, Points: 600, At: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Only the first transfer earns, the others send the same money back and forth
+	for _, transfer := range []struct {
+		token string
+		to    string
+	}{{alice.Token, bob.Info.ID}, {bob.Token, alice.Info.ID}, {alice.Token, bob.Info.ID}} {
+		status, data := s.do("POST", "/transaction", transfer.token, model.Transaction{CreditAccount: transfer.to, Amount: 10000})
+		if status != http.StatusCreated {
+			t.Fatalf("transfer: status = %d (body %s)", status, data)
+		}
+	}
+
+	account, _ := s.stores.Accounts.AccountByID(alice.Info.ID)
+	if account.Balance != 10025 {
+		t.Fatalf("balance = %s, want 100.25", account.Balance)
+	}
+	for token, want := range map[string]int{alice.Token: 200, bob.Token: 0} {
+		var wallet model.RewardWallet
+		status, data := s.do("GET", "/rewards", token, nil)
+		if status != http.StatusOK || json.Unmarshal(data, &wallet) != nil || wallet.Points != want {
+			t.Fatalf("rewards: status = %d (body %s), want %d points", status, data, want)
+		}
+	}
+}
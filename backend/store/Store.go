@@ -0,0 +1,291 @@
+package store
+
+import (
+	//Import standard library
+	"fmt"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/model"
+)
+
+// Handlers only talk to the database through these interfaces. The postgres package is used by
+// the server, the memory package by tests
+
+type UserStore interface {
+	// CreateUser gives the user the next account number, with its check digits, and stores
+	// it with an empty checking account of the same number. It returns EmailTakenError when
+	// the email is already registered
+	CreateUser(user model.User) (model.User, error)
+	UserByEmail(email string) (model.User, error)
+	UserByID(id string) (model.User, error)
+	UpdateUserPassword(id, hash string) error
+}
+
+// AccountStore opens and closes customers' accounts, money only moves through LedgerStore
+type AccountStore interface {
+	// OpenAccount gives the account the next account number and stores it open and empty, in
+	// the currency it was given
+	OpenAccount(account model.Account) (model.Account, error)
+	AccountByID(id string) (model.Account, error)
+	// Accounts returns the customer's accounts, closed ones included, oldest first
+	Accounts(customer string) ([]model.Account, error)
+	// CloseAccount returns AccountNotEmptyError when money is left in the account and
+	// LastAccountError when it is the customer's only open account
+	CloseAccount(id string, at time.Time) (model.Account, error)
+}
+
+type AdminStore interface {
+	// CreateAdmin returns EmailTakenError when the email is already registered
+	CreateAdmin(admin model.Admin) (model.Admin, error)
+	AdminByEmail(email string) (model.Admin, error)
+	AdminByID(id string) (model.Admin, error)
+	UpdateAdminPassword(id, hash string) error
+}
+
+// LedgerStore moves money between accounts. Every method is atomic: it either posts balanced
+// journal entries, updates the balances and records the transaction, or changes nothing.
+// Money never moves in or out of a closed account, nor in another currency than the account's
+type LedgerStore interface {
+	// Transfer fills in the beneficiary's name, the credit account owner's, and returns the
+	// stored transaction. Currency and CreditCurrency must be the accounts' currencies, a
+	// converted transfer uses up its quote and returns QuoteUsedError when it already was. A
+	// scheduled transfer returns ScheduleRunDoneError when its run was already made. A
+	// transfer with a DailyLimit returns DailyLimitError when it would take what the customer
+	// sent to others today over it
+	Transfer(transaction model.Transaction) (model.Transaction, error)
+	// Topup and Withdraw are in the account's currency and return its new balance. Withdraw
+	// returns DailyLimitError when amount would take what the customer withdrew today over
+	// limit, 0 for no limit
+	Topup(account string, amount model.Money) (model.Money, error)
+	Withdraw(account string, amount, limit model.Money) (model.Money, error)
+	// Reward pays the bank's money into an account, as a "reward" transaction described by
+	// description, and returns its new balance
+	Reward(account string, amount model.Money, description string) (model.Money, error)
+	// Transactions returns the account's transactions matching the filter, newest first
+	Transactions(account string, filter ledger.TransactionFilter) ([]model.Transaction, error)
+	// Reconcile returns the accounts whose balance disagrees with the journal
+	Reconcile() ([]ledger.Mismatch, error)
+}
+
+type QuoteStore interface {
+	CreateQuote(quote model.Quote) error
+	QuoteByID(id string) (model.Quote, error)
+}
+
+// IdempotencyStore remembers the response to each of a customer's idempotency keys until the
+// key expires
+type IdempotencyStore interface {
+	// ReserveKey stores the record as in progress and returns true. When the customer's key is
+	// already stored and not expired at record.CreatedAt, the stored record is returned with false
+	ReserveKey(record IdempotencyRecord) (IdempotencyRecord, bool, error)
+	// CompleteKey saves the response of a reserved key
+	CompleteKey(record IdempotencyRecord) error
+	// ReleaseKey forgets a reserved key, so the request can be sent again
+	ReleaseKey(customer, key string) error
+}
+
+// ScheduleStore keeps customers' scheduled transfers, the scheduler runs them
+type ScheduleStore interface {
+	CreateSchedule(schedule model.Schedule) (model.Schedule, error)
+	// Schedules returns the customer's schedules, finished and cancelled ones included, oldest first
+	Schedules(customer string) ([]model.Schedule, error)
+	// CancelSchedule returns NotFoundError when the customer has no active schedule with this ID
+	CancelSchedule(id int64, customer string) (model.Schedule, error)
+	// DueSchedules returns the active schedules due at now, see model.Schedule.Due
+	DueSchedules(now time.Time) ([]model.Schedule, error)
+	// UpdateSchedule saves what a run changed: next run, retry, attempts, state and last error.
+	// A schedule cancelled in the meantime stays cancelled
+	UpdateSchedule(schedule model.Schedule) error
+}
+
+// PayeeStore keeps the accounts each customer saved to send money to
+type PayeeStore interface {
+	// CreatePayee returns PayeeExistsError when the customer already saved the account. Saving
+	// an account the customer never paid starts its cooling-off until payee.CoolingOffUntil,
+	// the payee returned carries the account's cooling-off either way
+	CreatePayee(payee model.Payee) (model.Payee, error)
+	// Payees returns the customer's payees, most recently used first and never used ones last
+	Payees(customer string) ([]model.Payee, error)
+	PayeeByAccount(customer, account string) (model.Payee, error)
+	// RenamePayee and DeletePayee return NotFoundError when the customer has no such payee
+	RenamePayee(id int64, customer, nickname string) (model.Payee, error)
+	DeletePayee(id int64, customer string) error
+	// ReserveCoolingOff adds amount, in the account's currency, to what the customer sent to the
+	// account while it cools off and returns true, or returns CoolingOffLimitError when that would go over limit. The first
+	// payment to an account that was never saved starts its cooling-off until until. Cooling-off
+	// is kept by account, deleting and saving the payee again does not start it over. It returns
+	// false when the account's cooling-off is over at now. ReleaseCoolingOff takes a reserved
+	// amount back when the transfer fails
+	ReserveCoolingOff(customer, account string, amount, limit model.Money, now, until time.Time) (bool, error)
+	ReleaseCoolingOff(customer, account string, amount model.Money) error
+	PayeeUsed(id int64, at time.Time) error
+}
+
+// ExpStore records every award of experience points
+type ExpStore interface {
+	// AddExpEvent stores the event and adds its points to the user's Exp, both or neither
+	AddExpEvent(event model.ExpEvent) (model.ExpEvent, error)
+	// ExpEvents returns the customer's events since the given time, oldest first
+	ExpEvents(customer string, since time.Time) ([]model.ExpEvent, error)
+}
+
+// AchievementStore keeps the achievements each customer unlocked
+type AchievementStore interface {
+	// UnlockAchievement stores the achievement and returns true, or false when the customer
+	// had already unlocked it
+	UnlockAchievement(achievement model.Achievement) (bool, error)
+	// Achievements returns the customer's achievements, oldest first
+	Achievements(customer string) ([]model.Achievement, error)
+}
+
+// RewardStore keeps the earn and redeem entries of customers' reward points, a wallet is worked
+// out from them by model.ReplayRewards
+type RewardStore interface {
+	AddRewardEntry(entry model.RewardEntry) (model.RewardEntry, error)
+	// RedeemPoints stores a redeem entry of entry.Points points, or returns
+	// InsufficientPointsError when the wallet holds fewer at entry.At. Both are done atomically
+	RedeemPoints(entry model.RewardEntry) (model.RewardEntry, error)
+	// CancelRedemption deletes a redeem entry whose money could not be paid, its points are
+	// back in the wallet
+	CancelRedemption(id int64) error
+	// RewardEntries returns the customer's entries, oldest first
+	RewardEntries(customer string) ([]model.RewardEntry, error)
+}
+
+type SessionStore interface {
+	CreateSession(session Session) error
+	// UpdateSession locks the session, lets update change it and saves it when update returns nil
+	UpdateSession(id string, update func(session *Session) error) error
+	// ListSessions returns the account's active sessions, most recently used first
+	ListSessions(account, role string, now time.Time) ([]Session, error)
+	// RevokeSession ends one of the account's active sessions, NotFoundError if there is none
+	RevokeSession(id, account, role string, at time.Time) error
+	RevokeToken(jti string, expiresAt time.Time) error
+	// IsTokenRevoked reports whether the token's session has ended or the token itself was revoked
+	IsTokenRevoked(sessionID, jti string) (bool, error)
+}
+
+// Stores bundles every store the server needs
+type Stores struct {
+	Users        UserStore
+	Accounts     AccountStore
+	Admins       AdminStore
+	Ledger       LedgerStore
+	Quotes       QuoteStore
+	Idempotency  IdempotencyStore
+	Schedules    ScheduleStore
+	Payees       PayeeStore
+	Exp          ExpStore
+	Achievements AchievementStore
+	Rewards      RewardStore
+	Sessions     SessionStore
+}
+
+// Session is what is stored about a login. Only the hash of the refresh token is kept
+type Session struct {
+	ID          string
+	Account     string
+	Role        string
+	RefreshHash string
+	Device      string
+	CreatedAt   time.Time
+	LastUsedAt  time.Time
+	ExpiresAt   time.Time
+	RevokedAt   time.Time //Zero while the session is active
+}
+
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && now.Before(s.ExpiresAt)
+}
+
+// IdempotencyRecord is a request made with an idempotency key and, once it has finished, its
+// response. Only a hash of the request is kept
+type IdempotencyRecord struct {
+	Customer    string
+	Key         string
+	RequestHash string
+	Status      int //0 while the request is in progress
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+type NotFoundError struct{}
+
+func (e NotFoundError) Error() string {
+	return "Record not found"
+}
+
+type EmailTakenError struct{}
+
+func (e EmailTakenError) Error() string {
+	return "This email has been registered in the system"
+}
+
+type AccountNotEmptyError struct {
+	Balance model.Money
+}
+
+func (e AccountNotEmptyError) Error() string {
+	return fmt.Sprintf("Account still holds %s, move the money out before closing it", e.Balance)
+}
+
+type LastAccountError struct{}
+
+func (e LastAccountError) Error() string {
+	return "Cannot close your only open account"
+}
+
+type QuoteUsedError struct{}
+
+func (e QuoteUsedError) Error() string {
+	return "This quote has already been used"
+}
+
+type ScheduleRunDoneError struct {
+	Run string
+}
+
+func (e ScheduleRunDoneError) Error() string {
+	return fmt.Sprintf("Scheduled run %s has already been made", e.Run)
+}
+
+type PayeeExistsError struct{}
+
+func (e PayeeExistsError) Error() string {
+	return "This account is already one of your payees"
+}
+
+type CoolingOffLimitError struct {
+	Limit model.Money
+	Sent  model.Money
+}
+
+func (e CoolingOffLimitError) Error() string {
+	return fmt.Sprintf("This account was first saved or paid recently, only %s can be sent to it until its cooling-off period ends (%s sent so far)", e.Limit, e.Sent)
+}
+
+// DailyLimitError is returned when money leaving a customer's accounts would go over their
+// daily limit. Limits are per customer and currency, whichever account the money leaves from,
+// and money moved between the customer's own accounts does not count. Used is what already
+// left today
+type DailyLimitError struct {
+	Kind  string //"transfer" or "withdrawal"
+	Limit model.Money
+	Used  model.Money
+}
+
+func (e DailyLimitError) Error() string {
+	return fmt.Sprintf("Only %s of %ss can be made a day, %s has been used today", e.Limit, e.Kind, e.Used)
+}
+
+type InsufficientPointsError struct {
+	Available int
+}
+
+func (e InsufficientPointsError) Error() string {
+	return fmt.Sprintf("Your rewards wallet only holds %d points", e.Available)
+}
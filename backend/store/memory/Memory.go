@@ -0,0 +1,1067 @@
+package memory
+
+import (
+	//Import standard library
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// Store keeps everything in maps behind one mutex. It behaves like the Postgres stores, down to
+// the account numbers it gives out, so handlers can be tested without a database
+type Store struct {
+	mu sync.Mutex
+
+	serial       int64
+	users        map[string]model.User
+	accounts     map[string]model.Account
+	admins       []model.Admin
+	transactions []model.Transaction
+	journal      []ledger.Entry
+	quotes       map[string]model.Quote
+	usedQuotes   map[string]bool
+	scheduleRuns map[string]bool
+	keys         map[string]store.IdempotencyRecord //By customer and key
+	schedules    []model.Schedule
+	payees       []model.Payee
+	coolingOff   map[string]coolingOff //By customer and account
+	expEvents    []model.ExpEvent
+	achievements []model.Achievement
+	rewards      []model.RewardEntry
+	sessions     map[string]store.Session
+	revoked      map[string]time.Time
+}
+
+// New returns every store backed by one in-memory Store
+func New() store.Stores {
+	s := &Store{
+		serial:       model.MinSerial,
+		users:        map[string]model.User{},
+		accounts:     map[string]model.Account{},
+		quotes:       map[string]model.Quote{},
+		usedQuotes:   map[string]bool{},
+		scheduleRuns: map[string]bool{},
+		coolingOff:   map[string]coolingOff{},
+		keys:         map[string]store.IdempotencyRecord{},
+		sessions:     map[string]store.Session{},
+		revoked:      map[string]time.Time{},
+	}
+
+	return store.Stores{Users: s, Accounts: s, Admins: s, Ledger: s, Quotes: s, Idempotency: s, Schedules: s, Payees: s, Exp: s, Achievements: s, Rewards: s, Sessions: s}
+}
+
+/*---- UserStore ----*/
+
+func (s *Store) CreateUser(user model.User) (model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.users {
+		if existing.Email == user.Email {
+			return user, store.EmailTakenError{}
+		}
+	}
+
+	id, err := s.nextAccountNumber()
+	if err != nil {
+		return user, err
+	}
+	user.ID = id
+	s.users[user.ID] = user
+	s.accounts[user.ID] = model.Account{
+		ID:       user.ID,
+		Customer: user.ID,
+		Type:     model.Checking,
+		Currency: model.DefaultCurrency,
+		State:    model.AccountOpen,
+		OpenedAt: time.Now(),
+	}
+	return user, nil
+}
+
+func (s *Store) UserByEmail(email string) (model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return model.User{}, store.NotFoundError{}
+}
+
+func (s *Store) UserByID(id string) (model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return user, store.NotFoundError{}
+	}
+
+	return user, nil
+}
+
+func (s *Store) UpdateUserPassword(id, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return store.NotFoundError{}
+	}
+
+	user.Password = hash
+	s.users[id] = user
+	return nil
+}
+
+// nextAccountNumber hands out serials in order, the caller holds the lock
+func (s *Store) nextAccountNumber() (string, error) {
+	id, err := model.NewAccountNumber("", s.serial)
+	if err != nil {
+		return "", err
+	}
+
+	s.serial++
+	return id, nil
+}
+
+/*---- AccountStore ----*/
+
+func (s *Store) OpenAccount(account model.Account) (model.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.nextAccountNumber()
+	if err != nil {
+		return account, err
+	}
+
+	account.ID = id
+	account.Balance = 0
+	account.State = model.AccountOpen
+	account.OpenedAt = time.Now()
+	account.ClosedAt = nil
+	s.accounts[id] = account
+	return account, nil
+}
+
+func (s *Store) AccountByID(id string) (model.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		return account, store.NotFoundError{}
+	}
+
+	return account, nil
+}
+
+func (s *Store) Accounts(customer string) ([]model.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accounts := []model.Account{}
+	for _, account := range s.accounts {
+		if account.Customer == customer {
+			accounts = append(accounts, account)
+		}
+	}
+	//Numbers are given out in order, so they sort like opening dates
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].ID < accounts[j].ID
+	})
+
+	return accounts, nil
+}
+
+func (s *Store) CloseAccount(id string, at time.Time) (model.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[id]
+	if !ok || account.State != model.AccountOpen {
+		return account, store.NotFoundError{}
+	}
+
+	open := 0
+	for _, other := range s.accounts {
+		if other.Customer == account.Customer && other.State == model.AccountOpen {
+			open++
+		}
+	}
+	if open <= 1 {
+		return account, store.LastAccountError{}
+	}
+
+	if account.Balance != 0 {
+		return account, store.AccountNotEmptyError{Balance: account.Balance}
+	}
+
+	account.State = model.AccountClosed
+	account.ClosedAt = &at
+	s.accounts[id] = account
+	return account, nil
+}
+
+/*---- AdminStore ----*/
+
+func (s *Store) CreateAdmin(admin model.Admin) (model.Admin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.admins {
+		if existing.Email == admin.Email {
+			return admin, store.EmailTakenError{}
+		}
+	}
+
+	admin.ID = strconv.Itoa(len(s.admins) + 1)
+	s.admins = append(s.admins, admin)
+	return admin, nil
+}
+
+func (s *Store) AdminByEmail(email string) (model.Admin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, admin := range s.admins {
+		if admin.Email == email {
+			return admin, nil
+		}
+	}
+
+	return model.Admin{}, store.NotFoundError{}
+}
+
+func (s *Store) AdminByID(id string) (model.Admin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, admin := range s.admins {
+		if admin.ID == id {
+			return admin, nil
+		}
+	}
+
+	return model.Admin{}, store.NotFoundError{}
+}
+
+func (s *Store) UpdateAdminPassword(id, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.admins {
+		if s.admins[i].ID == id {
+			s.admins[i].Password = hash
+			return nil
+		}
+	}
+
+	return store.NotFoundError{}
+}
+
+/*---- LedgerStore ----*/
+
+func (s *Store) Transfer(transaction model.Transaction) (model.Transaction, error) {
+	err := ledger.CheckTransfer(transaction)
+	if err != nil {
+		return transaction, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	debit, err := s.openAccount(transaction.DebitAccount)
+	if err != nil {
+		return transaction, err
+	}
+
+	credit, err := s.openAccount(transaction.CreditAccount)
+	if err != nil {
+		return transaction, err
+	}
+
+	err = checkCurrency(debit, transaction.Currency)
+	if err != nil {
+		return transaction, err
+	}
+
+	err = checkCurrency(credit, transaction.CreditCurrency)
+	if err != nil {
+		return transaction, err
+	}
+
+	if transaction.QuoteID != "" && s.usedQuotes[transaction.QuoteID] {
+		return transaction, store.QuoteUsedError{}
+	}
+
+	if transaction.ScheduleRun != "" && s.scheduleRuns[transaction.ScheduleRun] {
+		return transaction, store.ScheduleRunDoneError{Run: transaction.ScheduleRun}
+	}
+
+	err = s.checkDailyLimit(debit, "transfer", transaction.Amount, transaction.DailyLimit, transaction.Date)
+	if err != nil {
+		return transaction, err
+	}
+
+	if debit.Balance < transaction.Amount {
+		return transaction, ledger.InsufficientFundsError{Balance: debit.Balance, Amount: transaction.Amount}
+	}
+
+	if transaction.QuoteID != "" {
+		s.usedQuotes[transaction.QuoteID] = true
+	}
+	if transaction.ScheduleRun != "" {
+		s.scheduleRuns[transaction.ScheduleRun] = true
+	}
+
+	transaction.Type = "transfer"
+	transaction.Beneficiary = s.users[credit.Customer].Fullname
+	transaction, err = s.record(transaction, ledger.TransferEntries(transaction))
+	return transaction, err
+}
+
+func (s *Store) Topup(account string, amount model.Money) (model.Money, error) {
+	err := ledger.CheckAmount(amount)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	credit, err := s.openAccount(account)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.record(model.Transaction{
+		Type:           "topup",
+		Date:           time.Now(),
+		CreditAccount:  account,
+		Beneficiary:    s.users[credit.Customer].Fullname,
+		Amount:         amount,
+		Currency:       credit.Currency,
+		Description:    "Topup",
+		CreditAmount:   amount,
+		CreditCurrency: credit.Currency,
+	}, ledger.TopupEntries(account, amount, credit.Currency))
+	if err != nil {
+		return 0, err
+	}
+
+	return s.accounts[account].Balance, nil
+}
+
+func (s *Store) Reward(account string, amount model.Money, description string) (model.Money, error) {
+	err := ledger.CheckAmount(amount)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	credit, err := s.openAccount(account)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.record(model.Transaction{
+		Type:           "reward",
+		Date:           time.Now(),
+		CreditAccount:  account,
+		Beneficiary:    s.users[credit.Customer].Fullname,
+		Amount:         amount,
+		Currency:       credit.Currency,
+		Description:    description,
+		CreditAmount:   amount,
+		CreditCurrency: credit.Currency,
+	}, ledger.RewardEntries(account, amount, credit.Currency))
+	if err != nil {
+		return 0, err
+	}
+
+	return s.accounts[account].Balance, nil
+}
+
+func (s *Store) Withdraw(account string, amount, limit model.Money) (model.Money, error) {
+	err := ledger.CheckAmount(amount)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	debit, err := s.openAccount(account)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	err = s.checkDailyLimit(debit, "withdrawal", amount, limit, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if debit.Balance < amount {
+		return 0, ledger.InsufficientFundsError{Balance: debit.Balance, Amount: amount}
+	}
+
+	_, err = s.record(model.Transaction{
+		Type:           "withdrawal",
+		Date:           now,
+		DebitAccount:   account,
+		Amount:         amount,
+		Currency:       debit.Currency,
+		Description:    "Withdrawal",
+		CreditAmount:   amount,
+		CreditCurrency: debit.Currency,
+	}, ledger.WithdrawalEntries(account, amount, debit.Currency))
+	if err != nil {
+		return 0, err
+	}
+
+	return s.accounts[account].Balance, nil
+}
+
+func (s *Store) Transactions(account string, filter ledger.TransactionFilter) ([]model.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transactions := []model.Transaction{}
+	for i := len(s.transactions) - 1; i >= 0 && len(transactions) < filter.Limit; i-- {
+		if filter.Matches(account, s.transactions[i]) {
+			transactions = append(transactions, s.transactions[i])
+		}
+	}
+
+	return transactions, nil
+}
+
+func (s *Store) Reconcile() ([]ledger.Mismatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	debit := map[model.Currency]model.Money{}
+	credit := map[model.Currency]model.Money{}
+	journalBalances := map[string]model.Money{}
+	for _, entry := range s.journal {
+		if entry.Direction == ledger.Debit {
+			debit[entry.Currency] += entry.Amount
+			journalBalances[entry.Account] -= entry.Amount
+		} else {
+			credit[entry.Currency] += entry.Amount
+			journalBalances[entry.Account] += entry.Amount
+		}
+	}
+	for currency := range debit {
+		if debit[currency] != credit[currency] {
+			return nil, ledger.UnbalancedPostingError{Currency: currency, Debit: debit[currency], Credit: credit[currency]}
+		}
+	}
+
+	var mismatches []ledger.Mismatch
+	for id, account := range s.accounts {
+		if account.Balance != journalBalances[id] {
+			mismatches = append(mismatches, ledger.Mismatch{Account: id, Balance: account.Balance, JournalBalance: journalBalances[id]})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Account < mismatches[j].Account
+	})
+
+	return mismatches, nil
+}
+
+// record stores the transaction and posts its entries. The caller holds the lock and has
+// checked the accounts, so nothing can fail halfway
+func (s *Store) record(transaction model.Transaction, entries []ledger.Entry) (model.Transaction, error) {
+	err := ledger.CheckEntries(entries)
+	if err != nil {
+		return transaction, err
+	}
+
+	transaction.Date = dateOnly(transaction.Date)
+	transaction.ID = int64(len(s.transactions) + 1)
+	s.transactions = append(s.transactions, transaction)
+
+	for _, entry := range entries {
+		s.journal = append(s.journal, entry)
+		if ledger.IsBankAccount(entry.Account) {
+			continue
+		}
+
+		account := s.accounts[entry.Account]
+		if entry.Direction == ledger.Debit {
+			account.Balance -= entry.Amount
+		} else {
+			account.Balance += entry.Amount
+		}
+		s.accounts[entry.Account] = account
+	}
+
+	return transaction, nil
+}
+
+// dateOnly keeps only the day, like the DATE column in Postgres
+func dateOnly(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// checkDailyLimit returns store.DailyLimitError when amount would take what left the account
+// owner's accounts in its currency today, with kind transactions, over limit. Transfers between
+// the owner's own accounts are not counted. The caller holds the lock
+func (s *Store) checkDailyLimit(account model.Account, kind string, amount, limit model.Money, at time.Time) error {
+	if limit == 0 {
+		return nil
+	}
+
+	today := dateOnly(at)
+	var used model.Money
+	for _, transaction := range s.transactions {
+		if transaction.Type != kind || transaction.Currency != account.Currency || !transaction.Date.Equal(today) {
+			continue
+		}
+		if s.accounts[transaction.DebitAccount].Customer != account.Customer {
+			continue
+		}
+		if transaction.CreditAccount != "" && s.accounts[transaction.CreditAccount].Customer == account.Customer {
+			continue
+		}
+		used += transaction.Amount
+	}
+
+	if used+amount > limit {
+		return store.DailyLimitError{Kind: kind, Limit: limit, Used: used}
+	}
+
+	return nil
+}
+
+// openAccount returns the account if money can move in or out of it, the caller holds the lock
+func (s *Store) openAccount(id string) (model.Account, error) {
+	account, ok := s.accounts[id]
+	if !ok {
+		return account, ledger.AccountNotFoundError{Account: id}
+	}
+	if account.State != model.AccountOpen {
+		return account, ledger.AccountClosedError{Account: id}
+	}
+
+	return account, nil
+}
+
+// checkCurrency makes sure money moves in the account's own currency
+func checkCurrency(account model.Account, currency model.Currency) error {
+	if account.Currency != currency {
+		return ledger.CurrencyMismatchError{Account: account.ID, Currency: account.Currency, Amount: currency}
+	}
+
+	return nil
+}
+
+/*---- QuoteStore ----*/
+
+func (s *Store) CreateQuote(quote model.Quote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quotes[quote.ID] = quote
+	return nil
+}
+
+func (s *Store) QuoteByID(id string) (model.Quote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quote, ok := s.quotes[id]
+	if !ok {
+		return quote, store.NotFoundError{}
+	}
+
+	return quote, nil
+}
+
+/*---- IdempotencyStore ----*/
+
+func (s *Store) ReserveKey(record store.IdempotencyRecord) (store.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := record.Customer + "/" + record.Key
+	existing, ok := s.keys[id]
+	if ok && record.CreatedAt.Before(existing.ExpiresAt) {
+		return existing, false, nil
+	}
+
+	record.Status = 0
+	record.ContentType = ""
+	record.Body = nil
+	s.keys[id] = record
+	return record, true, nil
+}
+
+func (s *Store) CompleteKey(record store.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := record.Customer + "/" + record.Key
+	if _, ok := s.keys[id]; !ok {
+		return store.NotFoundError{}
+	}
+
+	s.keys[id] = record
+	return nil
+}
+
+func (s *Store) ReleaseKey(customer, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, customer+"/"+key)
+	return nil
+}
+
+/*---- ScheduleStore ----*/
+
+func (s *Store) CreateSchedule(schedule model.Schedule) (model.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule.ID = int64(len(s.schedules) + 1)
+	s.schedules = append(s.schedules, schedule)
+	return schedule, nil
+}
+
+func (s *Store) Schedules(customer string) ([]model.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules := []model.Schedule{}
+	for _, schedule := range s.schedules {
+		if schedule.Customer == customer {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	return schedules, nil
+}
+
+func (s *Store) CancelSchedule(id int64, customer string) (model.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, schedule := range s.schedules {
+		if schedule.ID == id && schedule.Customer == customer && schedule.State == model.ScheduleActive {
+			s.schedules[i].State = model.ScheduleCancelled
+			s.schedules[i].RetryAt = nil
+			return s.schedules[i], nil
+		}
+	}
+
+	return model.Schedule{}, store.NotFoundError{}
+}
+
+func (s *Store) DueSchedules(now time.Time) ([]model.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules := []model.Schedule{}
+	for _, schedule := range s.schedules {
+		if schedule.Due(now) {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	return schedules, nil
+}
+
+func (s *Store) UpdateSchedule(schedule model.Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.schedules {
+		if existing.ID != schedule.ID {
+			continue
+		}
+		if existing.State == model.ScheduleActive {
+			s.schedules[i].NextRun = schedule.NextRun
+			s.schedules[i].RetryAt = schedule.RetryAt
+			s.schedules[i].Attempts = schedule.Attempts
+			s.schedules[i].State = schedule.State
+			s.schedules[i].LastError = schedule.LastError
+		}
+		return nil
+	}
+
+	return store.NotFoundError{}
+}
+
+/*---- PayeeStore ----*/
+
+// coolingOff is when the cooling-off of an account a customer pays ends and what was sent to it
+// until then
+type coolingOff struct {
+	until time.Time
+	sent  model.Money
+}
+
+func (s *Store) CreatePayee(payee model.Payee) (model.Payee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.payees {
+		if existing.Customer == payee.Customer && existing.Account == payee.Account {
+			return payee, store.PayeeExistsError{}
+		}
+	}
+
+	//An account already paid keeps the cooling-off its first payment started
+	id := payee.Customer + "/" + payee.Account
+	if _, ok := s.coolingOff[id]; !ok {
+		s.coolingOff[id] = coolingOff{until: payee.CoolingOffUntil}
+	}
+
+	payee.ID = int64(len(s.payees) + 1)
+	s.payees = append(s.payees, payee)
+	return s.withCoolingOff(payee), nil
+}
+
+func (s *Store) Payees(customer string) ([]model.Payee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payees := []model.Payee{}
+	for _, payee := range s.payees {
+		if payee.Customer == customer && payee.ID != 0 {
+			payees = append(payees, s.withCoolingOff(payee))
+		}
+	}
+
+	sort.SliceStable(payees, func(i, j int) bool {
+		a, b := payees[i].LastUsedAt, payees[j].LastUsedAt
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.After(*b)
+	})
+	return payees, nil
+}
+
+func (s *Store) PayeeByAccount(customer, account string) (model.Payee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, payee := range s.payees {
+		if payee.Customer == customer && payee.Account == account && payee.ID != 0 {
+			return s.withCoolingOff(payee), nil
+		}
+	}
+
+	return model.Payee{}, store.NotFoundError{}
+}
+
+func (s *Store) RenamePayee(id int64, customer, nickname string) (model.Payee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payee, err := s.payee(id)
+	if err != nil || payee.Customer != customer {
+		return model.Payee{}, store.NotFoundError{}
+	}
+
+	payee.Nickname = nickname
+	return s.withCoolingOff(*payee), nil
+}
+
+func (s *Store) DeletePayee(id int64, customer string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payee, err := s.payee(id)
+	if err != nil || payee.Customer != customer {
+		return store.NotFoundError{}
+	}
+
+	//Deleted payees keep their slot so IDs are never reused
+	*payee = model.Payee{}
+	return nil
+}
+
+func (s *Store) ReserveCoolingOff(customer, account string, amount, limit model.Money, now, until time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	//The first payment to an account starts its cooling-off, unless saving it already did
+	id := customer + "/" + account
+	cooling, ok := s.coolingOff[id]
+	if !ok {
+		cooling = coolingOff{until: until}
+	}
+	if !now.Before(cooling.until) {
+		s.coolingOff[id] = cooling
+		return false, nil
+	}
+
+	if cooling.sent+amount > limit {
+		s.coolingOff[id] = cooling
+		return false, store.CoolingOffLimitError{Limit: limit, Sent: cooling.sent}
+	}
+
+	cooling.sent += amount
+	s.coolingOff[id] = cooling
+	return true, nil
+}
+
+func (s *Store) ReleaseCoolingOff(customer, account string, amount model.Money) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := customer + "/" + account
+	cooling := s.coolingOff[id]
+	cooling.sent -= amount
+	s.coolingOff[id] = cooling
+	return nil
+}
+
+func (s *Store) PayeeUsed(id int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payee, err := s.payee(id)
+	if err != nil {
+		return err
+	}
+
+	payee.LastUsedAt = &at
+	return nil
+}
+
+// withCoolingOff fills in the cooling-off of the payee's account, the caller holds the lock
+func (s *Store) withCoolingOff(payee model.Payee) model.Payee {
+	cooling := s.coolingOff[payee.Customer+"/"+payee.Account]
+	payee.CoolingOffUntil = cooling.until
+	payee.CoolingOffSent = cooling.sent
+	return payee
+}
+
+// payee finds a payee that was not deleted, the caller holds the lock
+func (s *Store) payee(id int64) (*model.Payee, error) {
+	if id < 1 || id > int64(len(s.payees)) || s.payees[id-1].ID == 0 {
+		return nil, store.NotFoundError{}
+	}
+
+	return &s.payees[id-1], nil
+}
+
+/*---- ExpStore ----*/
+
+func (s *Store) AddExpEvent(event model.ExpEvent) (model.ExpEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[event.Customer]
+	if !ok {
+		return event, store.NotFoundError{}
+	}
+	user.Exp += event.Points
+	s.users[event.Customer] = user
+
+	event.ID = int64(len(s.expEvents) + 1)
+	s.expEvents = append(s.expEvents, event)
+	return event, nil
+}
+
+func (s *Store) ExpEvents(customer string, since time.Time) ([]model.ExpEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := []model.ExpEvent{}
+	for _, event := range s.expEvents {
+		if event.Customer == customer && !event.At.Before(since) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+/*---- AchievementStore ----*/
+
+func (s *Store) UnlockAchievement(achievement model.Achievement) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, unlocked := range s.achievements {
+		if unlocked.Customer == achievement.Customer && unlocked.ID == achievement.ID {
+			return false, nil
+		}
+	}
+
+	s.achievements = append(s.achievements, achievement)
+	return true, nil
+}
+
+func (s *Store) Achievements(customer string) ([]model.Achievement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	achievements := []model.Achievement{}
+	for _, achievement := range s.achievements {
+		if achievement.Customer == customer {
+			achievements = append(achievements, achievement)
+		}
+	}
+
+	return achievements, nil
+}
+
+/*---- RewardStore ----*/
+
+func (s *Store) AddRewardEntry(entry model.RewardEntry) (model.RewardEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = int64(len(s.rewards) + 1)
+	s.rewards = append(s.rewards, entry)
+	return entry, nil
+}
+
+func (s *Store) RedeemPoints(entry model.RewardEntry) (model.RewardEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, _ := model.ReplayRewards(s.rewardEntries(entry.Customer), entry.At)
+	if wallet.Points < entry.Points {
+		return entry, store.InsufficientPointsError{Available: wallet.Points}
+	}
+
+	entry.Kind = model.RewardRedeem
+	entry.Points = -entry.Points
+	entry.ID = int64(len(s.rewards) + 1)
+	s.rewards = append(s.rewards, entry)
+	return entry, nil
+}
+
+func (s *Store) CancelRedemption(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	//Cancelled entries keep their slot so IDs are never reused
+	if id < 1 || id > int64(len(s.rewards)) || s.rewards[id-1].Kind != model.RewardRedeem {
+		return store.NotFoundError{}
+	}
+	s.rewards[id-1] = model.RewardEntry{}
+	return nil
+}
+
+func (s *Store) RewardEntries(customer string) ([]model.RewardEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rewardEntries(customer), nil
+}
+
+func (s *Store) rewardEntries(customer string) []model.RewardEntry {
+	entries := []model.RewardEntry{}
+	for _, entry := range s.rewards {
+		if entry.ID != 0 && entry.Customer == customer {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+/*---- SessionStore ----*/
+
+func (s *Store) CreateSession(session store.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *Store) UpdateSession(id string, update func(session *store.Session) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return store.NotFoundError{}
+	}
+
+	err := update(&session)
+	if err != nil {
+		return err
+	}
+
+	s.sessions[id] = session
+	return nil
+}
+
+func (s *Store) ListSessions(account, role string, now time.Time) ([]store.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := []store.Session{}
+	for _, session := range s.sessions {
+		if session.Account == account && session.Role == role && session.Active(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+func (s *Store) RevokeSession(id, account, role string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.Account != account || session.Role != role || !session.RevokedAt.IsZero() {
+		return store.NotFoundError{}
+	}
+
+	session.RevokedAt = at
+	s.sessions[id] = session
+	return nil
+}
+
+func (s *Store) RevokeToken(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (s *Store) IsTokenRevoked(sessionID, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok || !session.RevokedAt.IsZero() {
+		return true, nil
+	}
+
+	_, revoked := s.revoked[jti]
+	return revoked, nil
+}
@@ -0,0 +1,16 @@
+package memory
+
+import (
+	//Import standard library
+	"testing"
+
+	//Import user's defined package
+	"gobank-server/store"
+	"gobank-server/store/storetest"
+)
+
+func TestContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Stores {
+		return New()
+	})
+}
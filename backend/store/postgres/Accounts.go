@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+type Accounts struct {
+	DB *sql.DB
+	//Every new account number starts with it
+	Prefix string
+}
+
+func (a Accounts) OpenAccount(account model.Account) (model.Account, error) {
+	id, err := nextAccountNumber(a.DB, a.Prefix)
+	if err != nil {
+		return account, err
+	}
+
+	account.ID = id
+	account.Balance = 0
+	account.State = model.AccountOpen
+	account.OpenedAt = time.Now()
+	account.ClosedAt = nil
+
+	tx, err := a.DB.Begin()
+	if err != nil {
+		return account, err
+	}
+	defer tx.Rollback()
+
+	err = insertAccount(tx, account)
+	if err != nil {
+		return account, err
+	}
+
+	return account, tx.Commit()
+}
+
+func (a Accounts) AccountByID(id string) (model.Account, error) {
+	sqlQuery := `
+		SELECT id, customer, type, name, balance, currency, state, opened_at, closed_at FROM accounts
+		WHERE id = $1
+	`
+	account, err := scanAccount(a.DB.QueryRow(sqlQuery, id))
+	if err == sql.ErrNoRows {
+		return account, store.NotFoundError{}
+	}
+
+	return account, err
+}
+
+func (a Accounts) Accounts(customer string) ([]model.Account, error) {
+	sqlQuery := `
+		SELECT id, customer, type, name, balance, currency, state, opened_at, closed_at FROM accounts
+		WHERE customer = $1
+		ORDER BY opened_at, id
+	`
+	rows, err := a.DB.Query(sqlQuery, customer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []model.Account{}
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (a Accounts) CloseAccount(id string, at time.Time) (model.Account, error) {
+	tx, err := a.DB.Begin()
+	if err != nil {
+		return model.Account{}, err
+	}
+	defer tx.Rollback()
+
+	//Lock the account so no money can come in while it is being closed
+	sqlQuery := `
+		SELECT id, customer, type, name, balance, currency, state, opened_at, closed_at FROM accounts
+		WHERE id = $1 AND state = 'open'
+		FOR UPDATE
+	`
+	account, err := scanAccount(tx.QueryRow(sqlQuery, id))
+	if err == sql.ErrNoRows {
+		return account, store.NotFoundError{}
+	}
+	if err != nil {
+		return account, err
+	}
+
+	//Lock the customer too, so two of their accounts cannot be closed at the same time
+	_, err = tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", account.Customer)
+	if err != nil {
+		return account, err
+	}
+
+	var open int
+	err = tx.QueryRow("SELECT COUNT(*) FROM accounts WHERE customer = $1 AND state = 'open'", account.Customer).Scan(&open)
+	if err != nil {
+		return account, err
+	}
+	if open <= 1 {
+		return account, store.LastAccountError{}
+	}
+
+	if account.Balance != 0 {
+		return account, store.AccountNotEmptyError{Balance: account.Balance}
+	}
+
+	_, err = tx.Exec("UPDATE accounts SET state = 'closed', closed_at = $1 WHERE id = $2", at, id)
+	if err != nil {
+		return account, err
+	}
+
+	account.State = model.AccountClosed
+	account.ClosedAt = &at
+	return account, tx.Commit()
+}
+
+// nextAccountNumber takes the next serial, it is used up even if the account is never stored
+func nextAccountNumber(db *sql.DB, prefix string) (string, error) {
+	var serial int64
+	err := db.QueryRow("SELECT nextval('account_serial_seq')").Scan(&serial)
+	if err != nil {
+		return "", err
+	}
+
+	return model.NewAccountNumber(prefix, serial)
+}
+
+func insertAccount(tx *sql.Tx, account model.Account) error {
+	sqlQuery := `
+		INSERT INTO accounts (id, customer, type, name, balance, currency, state, opened_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := tx.Exec(sqlQuery, account.ID, account.Customer, account.Type, account.Name, account.Balance, account.Currency, account.State, account.OpenedAt)
+	return err
+}
+
+// scanner is what *sql.Row and *sql.Rows have in common
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAccount(row scanner) (model.Account, error) {
+	var (
+		account  model.Account
+		closedAt sql.NullTime
+	)
+	err := row.Scan(&account.ID, &account.Customer, &account.Type, &account.Name, &account.Balance, &account.Currency, &account.State, &account.OpenedAt, &closedAt)
+	if closedAt.Valid {
+		account.ClosedAt = &closedAt.Time
+	}
+
+	return account, err
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+// Achievements keeps the achievements customers unlocked in achievements TABLE
+type Achievements struct {
+	DB *sql.DB
+}
+
+func (a Achievements) UnlockAchievement(achievement model.Achievement) (bool, error) {
+	sqlQuery := `
+		INSERT INTO achievements (customer, achievement, unlocked_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (customer, achievement) DO NOTHING
+	`
+	result, err := a.DB.Exec(sqlQuery, achievement.Customer, achievement.ID, achievement.UnlockedAt)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
+
+func (a Achievements) Achievements(customer string) ([]model.Achievement, error) {
+	sqlQuery := `
+		SELECT achievement, customer, unlocked_at FROM achievements
+		WHERE customer = $1
+		ORDER BY unlocked_at, achievement
+	`
+	rows, err := a.DB.Query(sqlQuery, customer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	achievements := []model.Achievement{}
+	for rows.Next() {
+		var achievement model.Achievement
+		err = rows.Scan(&achievement.ID, &achievement.Customer, &achievement.UnlockedAt)
+		if err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, achievement)
+	}
+
+	return achievements, rows.Err()
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+type Admins struct {
+	DB *sql.DB
+}
+
+func (a Admins) CreateAdmin(admin model.Admin) (model.Admin, error) {
+	//Check if email has been registered in the database
+	var id string
+	err := a.DB.QueryRow("SELECT id FROM admins WHERE email = $1", admin.Email).Scan(&id)
+	if err == nil {
+		return admin, store.EmailTakenError{}
+	}
+	if err != sql.ErrNoRows {
+		return admin, err
+	}
+
+	//id is serial (auto generated)
+	sqlQuery := "INSERT INTO admins (email, password, fullname) VALUES ($1, $2, $3) RETURNING id"
+	err = a.DB.QueryRow(sqlQuery, admin.Email, admin.Password, admin.Fullname).Scan(&admin.ID)
+	if isUniqueViolation(err) {
+		return admin, store.EmailTakenError{}
+	}
+
+	return admin, err
+}
+
+func (a Admins) AdminByEmail(email string) (model.Admin, error) {
+	return a.find("email", email)
+}
+
+func (a Admins) AdminByID(id string) (model.Admin, error) {
+	return a.find("id", id)
+}
+
+func (a Admins) UpdateAdminPassword(id, hash string) error {
+	result, err := a.DB.Exec("UPDATE admins SET password = $1 WHERE id = $2", hash, id)
+	return checkUpdated(result, err)
+}
+
+func (a Admins) find(column, value string) (model.Admin, error) {
+	sqlQuery := `
+		SELECT id, email, password, fullname FROM admins
+		WHERE ` + column + ` = $1
+	`
+	var admin model.Admin
+	err := a.DB.QueryRow(sqlQuery, value).Scan(&admin.ID, &admin.Email, &admin.Password, &admin.Fullname)
+	if err == sql.ErrNoRows {
+		return admin, store.NotFoundError{}
+	}
+
+	return admin, err
+}
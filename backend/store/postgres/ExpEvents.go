@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// ExpEvents keeps every award of experience points in exp_events TABLE, users.exp is their sum
+type ExpEvents struct {
+	DB *sql.DB
+}
+
+func (e ExpEvents) AddExpEvent(event model.ExpEvent) (model.ExpEvent, error) {
+	tx, err := e.DB.Begin()
+	if err != nil {
+		return event, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE users SET exp = exp + $1 WHERE id = $2", event.Points, event.Customer)
+	if err != nil {
+		return event, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return event, err
+	}
+	if count == 0 {
+		return event, store.NotFoundError{}
+	}
+
+	sqlQuery := `
+		INSERT INTO exp_events (customer, kind, points, reference, at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err = tx.QueryRow(sqlQuery, event.Customer, event.Kind, event.Points, event.Reference, event.At).Scan(&event.ID)
+	if err != nil {
+		return event, err
+	}
+
+	return event, tx.Commit()
+}
+
+func (e ExpEvents) ExpEvents(customer string, since time.Time) ([]model.ExpEvent, error) {
+	sqlQuery := `
+		SELECT id, customer, kind, points, reference, at FROM exp_events
+		WHERE customer = $1 AND at >= $2
+		ORDER BY at, id
+	`
+	rows, err := e.DB.Query(sqlQuery, customer, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []model.ExpEvent{}
+	for rows.Next() {
+		var event model.ExpEvent
+		err = rows.Scan(&event.ID, &event.Customer, &event.Kind, &event.Points, &event.Reference, &event.At)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
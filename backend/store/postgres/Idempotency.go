@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+
+	//Import user's defined package
+	"gobank-server/store"
+)
+
+// IdempotencyKeys keeps the responses to idempotent requests in idempotency_keys TABLE
+type IdempotencyKeys struct {
+	DB *sql.DB
+}
+
+func (k IdempotencyKeys) ReserveKey(record store.IdempotencyRecord) (store.IdempotencyRecord, bool, error) {
+	//Insert the key, or take over an expired one. The primary key makes two requests with the
+	//same key race for one row, only one of them gets it back
+	sqlQuery := `
+		INSERT INTO idempotency_keys (customer, key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (customer, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status = 0, content_type = '', body = NULL,
+			created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+	`
+	result, err := k.DB.Exec(sqlQuery, record.Customer, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt)
+	if err != nil {
+		return record, false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return record, false, err
+	}
+	if rows == 1 {
+		record.Status = 0
+		record.ContentType = ""
+		record.Body = nil
+		return record, true, nil
+	}
+
+	//The key is taken, return what is stored
+	sqlQuery = `
+		SELECT customer, key, request_hash, status, content_type, body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE customer = $1 AND key = $2
+	`
+	var existing store.IdempotencyRecord
+	err = k.DB.QueryRow(sqlQuery, record.Customer, record.Key).Scan(
+		&existing.Customer, &existing.Key, &existing.RequestHash, &existing.Status,
+		&existing.ContentType, &existing.Body, &existing.CreatedAt, &existing.ExpiresAt,
+	)
+	if err != nil {
+		return existing, false, err
+	}
+
+	return existing, false, nil
+}
+
+func (k IdempotencyKeys) CompleteKey(record store.IdempotencyRecord) error {
+	sqlQuery := `
+		UPDATE idempotency_keys
+		SET status = $1, content_type = $2, body = $3
+		WHERE customer = $4 AND key = $5
+	`
+	result, err := k.DB.Exec(sqlQuery, record.Status, record.ContentType, record.Body, record.Customer, record.Key)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return store.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (k IdempotencyKeys) ReleaseKey(customer, key string) error {
+	_, err := k.DB.Exec("DELETE FROM idempotency_keys WHERE customer = $1 AND key = $2", customer, key)
+	return err
+}
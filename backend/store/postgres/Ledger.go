@@ -0,0 +1,589 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// Ledger keeps balances in accounts TABLE and the journal in postings and journal_entries TABLE
+type Ledger struct {
+	DB *sql.DB
+}
+
+// post writes a balanced set of journal entries and applies them to the accounts' balance.
+// It must run inside a database transaction so the journal and the balances never disagree
+func post(tx *sql.Tx, kind string, transactionID sql.NullInt64, entries []ledger.Entry) error {
+	//Check that the posting is balanced before touching anything
+	err := ledger.CheckEntries(entries)
+	if err != nil {
+		return err
+	}
+
+	//Create the posting that groups the entries together
+	sqlQuery := `
+		INSERT INTO postings (kind, transaction_id, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	var postingID int64
+	err = tx.QueryRow(sqlQuery, kind, transactionID, time.Now()).Scan(&postingID)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		//Write journal entry
+		sqlQuery = `
+			INSERT INTO journal_entries (posting_id, account, direction, amount, currency)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+		_, err = tx.Exec(sqlQuery, postingID, entry.Account, entry.Direction, entry.Amount, entry.Currency)
+		if err != nil {
+			return err
+		}
+
+		//The bank's own accounts have no balance column to maintain
+		if ledger.IsBankAccount(entry.Account) {
+			continue
+		}
+
+		//Debit decreases customer's balance, credit increases it
+		amount := entry.Amount
+		if entry.Direction == ledger.Debit {
+			amount = -amount
+		}
+		sqlQuery = `
+			UPDATE accounts
+			SET balance = balance + $1
+			WHERE id = $2
+		`
+		result, err := tx.Exec(sqlQuery, amount, entry.Account)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows != 1 {
+			return ledger.AccountNotFoundError{Account: entry.Account}
+		}
+	}
+
+	return nil
+}
+
+// Transfer moves money between two accounts. The debit and credit accounts are locked for the
+// duration of the database transaction so the balance check cannot race with another transfer.
+// The beneficiary's name is filled in from the database, whatever the caller sent
+func (l Ledger) Transfer(transaction model.Transaction) (model.Transaction, error) {
+	//Validate the request before opening a database transaction
+	err := ledger.CheckTransfer(transaction)
+	if err != nil {
+		return transaction, err
+	}
+
+	tx, err := l.DB.Begin()
+	if err != nil {
+		return transaction, err
+	}
+	defer tx.Rollback()
+
+	//Lock both accounts, always in the same order to avoid deadlocks between opposite transfers
+	sqlQuery := `
+		SELECT accounts.id, users.fullname, accounts.balance, accounts.currency, accounts.state FROM accounts
+		JOIN users ON users.id = accounts.customer
+		WHERE accounts.id = $1 OR accounts.id = $2
+		ORDER BY accounts.id
+		FOR UPDATE OF accounts
+	`
+	rows, err := tx.Query(sqlQuery, transaction.DebitAccount, transaction.CreditAccount)
+	if err != nil {
+		return transaction, err
+	}
+
+	var (
+		debitFound, creditFound bool
+		debitBalance            model.Money
+	)
+	for rows.Next() {
+		var (
+			id, fullname, state string
+			balance             model.Money
+			currency            model.Currency
+		)
+		err = rows.Scan(&id, &fullname, &balance, &currency, &state)
+		if err != nil {
+			rows.Close()
+			return transaction, err
+		}
+		if state != model.AccountOpen {
+			rows.Close()
+			return transaction, ledger.AccountClosedError{Account: id}
+		}
+
+		//Each side moves in its own account's currency
+		expected := transaction.CreditCurrency
+		if id == transaction.DebitAccount {
+			expected = transaction.Currency
+		}
+		if currency != expected {
+			rows.Close()
+			return transaction, ledger.CurrencyMismatchError{Account: id, Currency: currency, Amount: expected}
+		}
+
+		if id == transaction.DebitAccount {
+			debitFound = true
+			debitBalance = balance
+		} else if id == transaction.CreditAccount {
+			creditFound = true
+			transaction.Beneficiary = fullname
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return transaction, err
+	}
+
+	if !debitFound {
+		return transaction, ledger.AccountNotFoundError{Account: transaction.DebitAccount}
+	}
+
+	if !creditFound {
+		return transaction, ledger.AccountNotFoundError{Account: transaction.CreditAccount}
+	}
+
+	//A quote is good for one transfer only, the row lock makes a second use wait then fail.
+	//Using it up is rolled back with everything else if the transfer fails
+	if transaction.QuoteID != "" {
+		result, err := tx.Exec("UPDATE fx_quotes SET used_at = $1 WHERE id = $2 AND used_at IS NULL", time.Now(), transaction.QuoteID)
+		if err != nil {
+			return transaction, err
+		}
+		used, err := result.RowsAffected()
+		if err != nil {
+			return transaction, err
+		}
+		if used != 1 {
+			return transaction, store.QuoteUsedError{}
+		}
+	}
+
+	err = checkDailyLimit(tx, transaction.DebitAccount, "transfer", transaction.Currency, transaction.Amount, transaction.DailyLimit, transaction.Date)
+	if err != nil {
+		return transaction, err
+	}
+
+	if debitBalance < transaction.Amount {
+		return transaction, ledger.InsufficientFundsError{Balance: debitBalance, Amount: transaction.Amount}
+	}
+
+	//Record the transaction. A scheduled run already made breaks the UNIQUE schedule_run, a
+	//concurrent one waits for the first to commit then breaks it too
+	transaction.Type = "transfer"
+	id, err := insertTransaction(tx, transaction)
+	if err != nil {
+		if transaction.ScheduleRun != "" && isUniqueViolation(err) {
+			return transaction, store.ScheduleRunDoneError{Run: transaction.ScheduleRun}
+		}
+		return transaction, err
+	}
+
+	//Move the money
+	err = post(tx, "transfer", sql.NullInt64{Int64: id, Valid: true}, ledger.TransferEntries(transaction))
+	if err != nil {
+		return transaction, err
+	}
+
+	transaction.ID = id
+	return transaction, tx.Commit()
+}
+
+// Topup adds money from outside the bank to an account and returns the new balance
+func (l Ledger) Topup(account string, amount model.Money) (model.Money, error) {
+	err := ledger.CheckAmount(amount)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := l.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	//Lock the account so the returned balance is the one we wrote
+	fullname, credit, err := lockAccount(tx, account)
+	if err != nil {
+		return 0, err
+	}
+
+	//Record the transaction so the topup shows up in history
+	id, err := insertTransaction(tx, model.Transaction{
+		Type:           "topup",
+		Date:           time.Now(),
+		CreditAccount:  account,
+		Beneficiary:    fullname,
+		Amount:         amount,
+		Currency:       credit.Currency,
+		Description:    "Topup",
+		CreditAmount:   amount,
+		CreditCurrency: credit.Currency,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = post(tx, "topup", sql.NullInt64{Int64: id, Valid: true}, ledger.TopupEntries(account, amount, credit.Currency))
+	if err != nil {
+		return 0, err
+	}
+
+	return credit.Balance + amount, tx.Commit()
+}
+
+// Reward pays the bank's money into an account and returns the new balance
+func (l Ledger) Reward(account string, amount model.Money, description string) (model.Money, error) {
+	err := ledger.CheckAmount(amount)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := l.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	//Lock the account so the returned balance is the one we wrote
+	fullname, credit, err := lockAccount(tx, account)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := insertTransaction(tx, model.Transaction{
+		Type:           "reward",
+		Date:           time.Now(),
+		CreditAccount:  account,
+		Beneficiary:    fullname,
+		Amount:         amount,
+		Currency:       credit.Currency,
+		Description:    description,
+		CreditAmount:   amount,
+		CreditCurrency: credit.Currency,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = post(tx, "reward", sql.NullInt64{Int64: id, Valid: true}, ledger.RewardEntries(account, amount, credit.Currency))
+	if err != nil {
+		return 0, err
+	}
+
+	return credit.Balance + amount, tx.Commit()
+}
+
+// Withdraw takes money out of an account and returns the new balance
+func (l Ledger) Withdraw(account string, amount, limit model.Money) (model.Money, error) {
+	err := ledger.CheckAmount(amount)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := l.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	//Lock the account before checking the balance so two withdrawals cannot both pass the check
+	_, debit, err := lockAccount(tx, account)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	err = checkDailyLimit(tx, account, "withdrawal", debit.Currency, amount, limit, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if debit.Balance < amount {
+		return 0, ledger.InsufficientFundsError{Balance: debit.Balance, Amount: amount}
+	}
+
+	//Record the transaction so the withdrawal shows up in history
+	id, err := insertTransaction(tx, model.Transaction{
+		Type:           "withdrawal",
+		Date:           now,
+		DebitAccount:   account,
+		Amount:         amount,
+		Currency:       debit.Currency,
+		Description:    "Withdrawal",
+		CreditAmount:   amount,
+		CreditCurrency: debit.Currency,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = post(tx, "withdrawal", sql.NullInt64{Int64: id, Valid: true}, ledger.WithdrawalEntries(account, amount, debit.Currency))
+	if err != nil {
+		return 0, err
+	}
+
+	return debit.Balance - amount, tx.Commit()
+}
+
+// lockAccount locks an open account's row until the database transaction ends and returns
+// its owner's name and the account with its balance and currency
+func lockAccount(tx *sql.Tx, id string) (string, model.Account, error) {
+	sqlQuery := `
+		SELECT users.fullname, accounts.balance, accounts.currency, accounts.state FROM accounts
+		JOIN users ON users.id = accounts.customer
+		WHERE accounts.id = $1
+		FOR UPDATE OF accounts
+	`
+	var fullname string
+	account := model.Account{ID: id}
+	err := tx.QueryRow(sqlQuery, id).Scan(&fullname, &account.Balance, &account.Currency, &account.State)
+	if err == sql.ErrNoRows {
+		return "", account, ledger.AccountNotFoundError{Account: id}
+	}
+	if err != nil {
+		return "", account, err
+	}
+	if account.State != model.AccountOpen {
+		return "", account, ledger.AccountClosedError{Account: id}
+	}
+
+	return fullname, account, nil
+}
+
+// checkDailyLimit returns store.DailyLimitError when amount would take what left the account
+// owner's accounts in currency on at's day, with kind transactions, over limit. Transfers between
+// the owner's own accounts are not counted. The owner's row stays locked until the database
+// transaction ends, so their transfers and withdrawals are checked one at a time
+func checkDailyLimit(tx *sql.Tx, account, kind string, currency model.Currency, amount, limit model.Money, at time.Time) error {
+	if limit == 0 {
+		return nil
+	}
+
+	var customer string
+	err := tx.QueryRow("SELECT customer FROM accounts WHERE id = $1", account).Scan(&customer)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", customer)
+	if err != nil {
+		return err
+	}
+
+	sqlQuery := `
+		SELECT COALESCE(SUM(transactions.amount), 0) FROM transactions
+		JOIN accounts debit ON debit.id = transactions.debit
+		LEFT JOIN accounts credit ON credit.id = transactions.credit
+		WHERE debit.customer = $1 AND transactions.type = $2 AND transactions.currency = $3
+			AND transactions.date = $4::date
+			AND (credit.customer IS NULL OR credit.customer <> $1)
+	`
+	var used model.Money
+	err = tx.QueryRow(sqlQuery, customer, kind, currency, at).Scan(&used)
+	if err != nil {
+		return err
+	}
+
+	if used+amount > limit {
+		return store.DailyLimitError{Kind: kind, Limit: limit, Used: used}
+	}
+
+	return nil
+}
+
+// insertTransaction stores the transaction row. Topup has no debit account and withdrawal has
+// no credit account, those sides are left NULL, and so are the rate and quote of a transfer
+// that was not converted
+func insertTransaction(tx *sql.Tx, transaction model.Transaction) (int64, error) {
+	sqlQuery := `
+		INSERT INTO transactions (type, date, debit, credit, beneficiary, amount, description,
+			currency, credit_amount, credit_currency, rate, quote_id, schedule_run)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+	var id int64
+	err := tx.QueryRow(sqlQuery,
+		transaction.Type,
+		transaction.Date,
+		sql.NullString{String: transaction.DebitAccount, Valid: transaction.DebitAccount != ""},
+		sql.NullString{String: transaction.CreditAccount, Valid: transaction.CreditAccount != ""},
+		transaction.Beneficiary,
+		transaction.Amount,
+		transaction.Description,
+		transaction.Currency,
+		transaction.CreditAmount,
+		transaction.CreditCurrency,
+		transaction.Rate,
+		sql.NullString{String: transaction.QuoteID, Valid: transaction.QuoteID != ""},
+		sql.NullString{String: transaction.ScheduleRun, Valid: transaction.ScheduleRun != ""},
+	).Scan(&id)
+
+	return id, err
+}
+
+// Reconcile compares every account's stored balance with the balance derived from the journal
+// and returns the accounts that disagree
+func (l Ledger) Reconcile() ([]ledger.Mismatch, error) {
+	//The journal must always be balanced in every currency
+	sqlQuery := `
+		SELECT currency,
+			COALESCE(SUM(CASE WHEN direction = 'debit' THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE 0 END), 0)
+		FROM journal_entries
+		GROUP BY currency
+		ORDER BY currency
+	`
+	totals, err := l.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer totals.Close()
+
+	for totals.Next() {
+		var (
+			currency      model.Currency
+			debit, credit model.Money
+		)
+		err = totals.Scan(&currency, &debit, &credit)
+		if err != nil {
+			return nil, err
+		}
+		if debit != credit {
+			return nil, ledger.UnbalancedPostingError{Currency: currency, Debit: debit, Credit: credit}
+		}
+	}
+	if err = totals.Err(); err != nil {
+		return nil, err
+	}
+
+	//Compare each account's balance to the sum of its entries
+	sqlQuery = `
+		SELECT accounts.id, accounts.balance, COALESCE(SUM(
+			CASE WHEN journal_entries.direction = 'credit' THEN journal_entries.amount ELSE -journal_entries.amount END
+		), 0) AS journal_balance
+		FROM accounts
+		LEFT JOIN journal_entries ON journal_entries.account = accounts.id
+		GROUP BY accounts.id, accounts.balance
+		HAVING accounts.balance <> COALESCE(SUM(
+			CASE WHEN journal_entries.direction = 'credit' THEN journal_entries.amount ELSE -journal_entries.amount END
+		), 0)
+	`
+	rows, err := l.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mismatches []ledger.Mismatch
+	for rows.Next() {
+		var mismatch ledger.Mismatch
+		err = rows.Scan(&mismatch.Account, &mismatch.Balance, &mismatch.JournalBalance)
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	return mismatches, rows.Err()
+}
+
+// Transactions returns the account's incoming and outgoing transactions, newest first
+func (l Ledger) Transactions(account string, filter ledger.TransactionFilter) ([]model.Transaction, error) {
+	//Build the WHERE clause from the filter, one placeholder per value
+	args := []any{account}
+	conditions := []string{}
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.Direction == "in" {
+		conditions = append(conditions, "credit = $1")
+	} else if filter.Direction == "out" {
+		conditions = append(conditions, "debit = $1")
+	} else {
+		conditions = append(conditions, "(debit = $1 OR credit = $1)")
+	}
+	if filter.Cursor > 0 {
+		addCondition("id < ?", filter.Cursor)
+	}
+	if !filter.From.IsZero() {
+		addCondition("date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("date <= ?", filter.To)
+	}
+	if filter.Counterparty != "" {
+		addCondition("((debit = $1 AND credit = ?) OR (credit = $1 AND debit = ?))", filter.Counterparty)
+	}
+	if filter.MinAmount > 0 {
+		addCondition("amount >= ?", filter.MinAmount)
+	}
+	if filter.MaxAmount > 0 {
+		addCondition("amount <= ?", filter.MaxAmount)
+	}
+	if filter.Search != "" {
+		addCondition("description ILIKE ?", "%"+filter.Search+"%")
+	}
+	args = append(args, filter.Limit)
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT id, COALESCE(type, 'transfer'), date, COALESCE(debit, ''), COALESCE(credit, ''),
+			COALESCE(beneficiary, ''), amount, COALESCE(description, ''), currency, credit_amount,
+			credit_currency, rate, COALESCE(quote_id, '')
+		FROM transactions
+		WHERE %s
+		ORDER BY id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+	rows, err := l.DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []model.Transaction{}
+	for rows.Next() {
+		var transaction model.Transaction
+		err = rows.Scan(
+			&transaction.ID,
+			&transaction.Type,
+			&transaction.Date,
+			&transaction.DebitAccount,
+			&transaction.CreditAccount,
+			&transaction.Beneficiary,
+			&transaction.Amount,
+			&transaction.Description,
+			&transaction.Currency,
+			&transaction.CreditAmount,
+			&transaction.CreditCurrency,
+			&transaction.Rate,
+			&transaction.QuoteID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// Payees keeps customers' saved payees in payees TABLE and the cooling-off of the accounts they
+// pay in cooling_off TABLE
+type Payees struct {
+	DB *sql.DB
+}
+
+// A payee's cooling-off is the one of its account, in cooling_off TABLE
+const (
+	payeeColumns = `payees.id, payees.customer, payees.nickname, payees.account, payees.name, payees.added_at,
+		payees.last_used_at, COALESCE(cooling_off.until, payees.added_at), COALESCE(cooling_off.sent, 0)`
+	payeeTables = `payees LEFT JOIN cooling_off
+		ON cooling_off.customer = payees.customer AND cooling_off.account = payees.account`
+)
+
+func (p Payees) CreatePayee(payee model.Payee) (model.Payee, error) {
+	tx, err := p.DB.Begin()
+	if err != nil {
+		return payee, err
+	}
+	defer tx.Rollback()
+
+	sqlQuery := `
+		INSERT INTO payees (customer, nickname, account, name, added_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err = tx.QueryRow(sqlQuery,
+		payee.Customer,
+		payee.Nickname,
+		payee.Account,
+		payee.Name,
+		payee.AddedAt,
+	).Scan(&payee.ID)
+	if isUniqueViolation(err) {
+		return payee, store.PayeeExistsError{}
+	}
+	if err != nil {
+		return payee, err
+	}
+
+	//An account already paid keeps the cooling-off its first payment started
+	sqlQuery = `
+		INSERT INTO cooling_off (customer, account, until) VALUES ($1, $2, $3)
+		ON CONFLICT (customer, account) DO NOTHING
+	`
+	_, err = tx.Exec(sqlQuery, payee.Customer, payee.Account, payee.CoolingOffUntil)
+	if err != nil {
+		return payee, err
+	}
+
+	sqlQuery = `SELECT until, sent FROM cooling_off WHERE customer = $1 AND account = $2`
+	err = tx.QueryRow(sqlQuery, payee.Customer, payee.Account).Scan(&payee.CoolingOffUntil, &payee.CoolingOffSent)
+	if err != nil {
+		return payee, err
+	}
+
+	return payee, tx.Commit()
+}
+
+func (p Payees) Payees(customer string) ([]model.Payee, error) {
+	sqlQuery := `SELECT ` + payeeColumns + ` FROM ` + payeeTables + `
+		WHERE payees.customer = $1
+		ORDER BY payees.last_used_at DESC NULLS LAST, payees.id
+	`
+	rows, err := p.DB.Query(sqlQuery, customer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payees := []model.Payee{}
+	for rows.Next() {
+		payee, err := scanPayee(rows)
+		if err != nil {
+			return nil, err
+		}
+		payees = append(payees, payee)
+	}
+
+	return payees, rows.Err()
+}
+
+func (p Payees) PayeeByAccount(customer, account string) (model.Payee, error) {
+	sqlQuery := `SELECT ` + payeeColumns + ` FROM ` + payeeTables + `
+		WHERE payees.customer = $1 AND payees.account = $2
+	`
+	payee, err := scanPayee(p.DB.QueryRow(sqlQuery, customer, account))
+	if err == sql.ErrNoRows {
+		return payee, store.NotFoundError{}
+	}
+
+	return payee, err
+}
+
+func (p Payees) RenamePayee(id int64, customer, nickname string) (model.Payee, error) {
+	result, err := p.DB.Exec("UPDATE payees SET nickname = $1 WHERE id = $2 AND customer = $3", nickname, id, customer)
+	if err != nil {
+		return model.Payee{}, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return model.Payee{}, err
+	}
+	if count == 0 {
+		return model.Payee{}, store.NotFoundError{}
+	}
+
+	sqlQuery := `SELECT ` + payeeColumns + ` FROM ` + payeeTables + ` WHERE payees.id = $1`
+	return scanPayee(p.DB.QueryRow(sqlQuery, id))
+}
+
+func (p Payees) DeletePayee(id int64, customer string) error {
+	result, err := p.DB.Exec("DELETE FROM payees WHERE id = $1 AND customer = $2", id, customer)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (p Payees) ReserveCoolingOff(customer, account string, amount, limit model.Money, now, until time.Time) (bool, error) {
+	//The first payment to an account starts its cooling-off, unless saving it already did
+	sqlQuery := `
+		INSERT INTO cooling_off (customer, account, until) VALUES ($1, $2, $3)
+		ON CONFLICT (customer, account) DO NOTHING
+	`
+	_, err := p.DB.Exec(sqlQuery, customer, account, until)
+	if err != nil {
+		return false, err
+	}
+
+	//Check and add in one statement, so two transfers cannot both fit under the limit
+	sqlQuery = `
+		UPDATE cooling_off SET sent = sent + $1
+		WHERE customer = $2 AND account = $3 AND until > $4 AND sent + $1 <= $5
+	`
+	result, err := p.DB.Exec(sqlQuery, amount, customer, account, now, limit)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	var (
+		coolingOffUntil time.Time
+		sent            model.Money
+	)
+	sqlQuery = `SELECT until, sent FROM cooling_off WHERE customer = $1 AND account = $2`
+	err = p.DB.QueryRow(sqlQuery, customer, account).Scan(&coolingOffUntil, &sent)
+	if err != nil {
+		return false, err
+	}
+	if !now.Before(coolingOffUntil) {
+		return false, nil
+	}
+
+	return false, store.CoolingOffLimitError{Limit: limit, Sent: sent}
+}
+
+func (p Payees) ReleaseCoolingOff(customer, account string, amount model.Money) error {
+	_, err := p.DB.Exec("UPDATE cooling_off SET sent = sent - $1 WHERE customer = $2 AND account = $3", amount, customer, account)
+	return err
+}
+
+func (p Payees) PayeeUsed(id int64, at time.Time) error {
+	_, err := p.DB.Exec("UPDATE payees SET last_used_at = $1 WHERE id = $2", at, id)
+	return err
+}
+
+func scanPayee(row scanner) (model.Payee, error) {
+	var (
+		payee      model.Payee
+		lastUsedAt sql.NullTime
+	)
+	err := row.Scan(
+		&payee.ID,
+		&payee.Customer,
+		&payee.Nickname,
+		&payee.Account,
+		&payee.Name,
+		&payee.AddedAt,
+		&lastUsedAt,
+		&payee.CoolingOffUntil,
+		&payee.CoolingOffSent,
+	)
+	if lastUsedAt.Valid {
+		payee.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return payee, err
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+
+	//Import user's defined package
+	"gobank-server/store"
+
+	//Import 3rd party package
+	"github.com/lib/pq"
+)
+
+// New returns every store backed by the same database. New account numbers start with
+// accountPrefix
+func New(db *sql.DB, accountPrefix string) store.Stores {
+	return store.Stores{
+		Users:        Users{DB: db, Prefix: accountPrefix},
+		Accounts:     Accounts{DB: db, Prefix: accountPrefix},
+		Admins:       Admins{DB: db},
+		Ledger:       Ledger{DB: db},
+		Quotes:       Quotes{DB: db},
+		Idempotency:  IdempotencyKeys{DB: db},
+		Schedules:    Schedules{DB: db},
+		Payees:       Payees{DB: db},
+		Exp:          ExpEvents{DB: db},
+		Achievements: Achievements{DB: db},
+		Rewards:      RewardEntries{DB: db},
+		Sessions:     Sessions{DB: db},
+	}
+}
+
+// isUniqueViolation reports whether err comes from a UNIQUE constraint, e.g. users_email_key
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+	"os"
+	"testing"
+
+	//Import user's defined package
+	"gobank-server/migration"
+	"gobank-server/store"
+	"gobank-server/store/storetest"
+
+	//Import 3rd party package
+	_ "github.com/lib/pq"
+)
+
+// TestContract runs the store contract against the database GOBANK_TEST_DSN points to. The
+// migrations are applied and every table is emptied before each test, never point it to a
+// database whose data you want to keep
+func TestContract(t *testing.T) {
+	dsn := os.Getenv("GOBANK_TEST_DSN")
+	if dsn == "" {
+		t.Skip("GOBANK_TEST_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = migration.Up(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storetest.Run(t, func(t *testing.T) store.Stores {
+		_, err := db.Exec(`
+			DO $$
+			DECLARE tables TEXT;
+			BEGIN
+				SELECT string_agg(quote_ident(tablename), ', ') INTO tables
+				FROM pg_tables
+				WHERE schemaname = current_schema() AND tablename <> 'schema_migrations';
+				EXECUTE 'TRUNCATE ' || tables || ' RESTART IDENTITY CASCADE';
+			END $$`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return New(db, "")
+	})
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// Quotes keeps FX quotes in fx_quotes TABLE, the transfer that uses one sets its used_at
+type Quotes struct {
+	DB *sql.DB
+}
+
+func (q Quotes) CreateQuote(quote model.Quote) error {
+	sqlQuery := `
+		INSERT INTO fx_quotes (id, customer, debit, credit, from_currency, to_currency, rate, fee, amount, converted, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := q.DB.Exec(sqlQuery,
+		quote.ID,
+		quote.Customer,
+		quote.DebitAccount,
+		quote.CreditAccount,
+		quote.From,
+		quote.To,
+		quote.Rate,
+		quote.Fee,
+		quote.Amount,
+		quote.Converted,
+		quote.ExpiresAt,
+	)
+
+	return err
+}
+
+func (q Quotes) QuoteByID(id string) (model.Quote, error) {
+	sqlQuery := `
+		SELECT id, customer, debit, credit, from_currency, to_currency, rate, fee, amount, converted, expires_at
+		FROM fx_quotes
+		WHERE id = $1
+	`
+	var quote model.Quote
+	err := q.DB.QueryRow(sqlQuery, id).Scan(
+		&quote.ID,
+		&quote.Customer,
+		&quote.DebitAccount,
+		&quote.CreditAccount,
+		&quote.From,
+		&quote.To,
+		&quote.Rate,
+		&quote.Fee,
+		&quote.Amount,
+		&quote.Converted,
+		&quote.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return quote, store.NotFoundError{}
+	}
+
+	return quote, err
+}
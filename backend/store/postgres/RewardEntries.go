@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// RewardEntries keeps the earn and redeem entries of reward points in reward_entries TABLE
+type RewardEntries struct {
+	DB *sql.DB
+}
+
+func (e RewardEntries) AddRewardEntry(entry model.RewardEntry) (model.RewardEntry, error) {
+	sqlQuery := `
+		INSERT INTO reward_entries (customer, kind, points, reference, at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	err := e.DB.QueryRow(sqlQuery, entry.Customer, entry.Kind, entry.Points, entry.Reference, entry.At, entry.ExpiresAt).Scan(&entry.ID)
+	return entry, err
+}
+
+func (e RewardEntries) RedeemPoints(entry model.RewardEntry) (model.RewardEntry, error) {
+	tx, err := e.DB.Begin()
+	if err != nil {
+		return entry, err
+	}
+	defer tx.Rollback()
+
+	//Lock the customer so redemptions of the same wallet wait for each other
+	_, err = tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", entry.Customer)
+	if err != nil {
+		return entry, err
+	}
+
+	entries, err := rewardEntries(tx.Query, entry.Customer)
+	if err != nil {
+		return entry, err
+	}
+	wallet, _ := model.ReplayRewards(entries, entry.At)
+	if wallet.Points < entry.Points {
+		return entry, store.InsufficientPointsError{Available: wallet.Points}
+	}
+
+	entry.Kind = model.RewardRedeem
+	entry.Points = -entry.Points
+	sqlQuery := `
+		INSERT INTO reward_entries (customer, kind, points, reference, at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err = tx.QueryRow(sqlQuery, entry.Customer, entry.Kind, entry.Points, entry.Reference, entry.At).Scan(&entry.ID)
+	if err != nil {
+		return entry, err
+	}
+
+	return entry, tx.Commit()
+}
+
+func (e RewardEntries) CancelRedemption(id int64) error {
+	result, err := e.DB.Exec("DELETE FROM reward_entries WHERE id = $1 AND kind = $2", id, model.RewardRedeem)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.NotFoundError{}
+	}
+
+	return nil
+}
+
+func (e RewardEntries) RewardEntries(customer string) ([]model.RewardEntry, error) {
+	return rewardEntries(e.DB.Query, customer)
+}
+
+// rewardEntries reads the customer's entries with query, the database's or a transaction's
+func rewardEntries(query func(query string, args ...any) (*sql.Rows, error), customer string) ([]model.RewardEntry, error) {
+	sqlQuery := `
+		SELECT id, customer, kind, points, reference, at, expires_at FROM reward_entries
+		WHERE customer = $1
+		ORDER BY at, id
+	`
+	rows, err := query(sqlQuery, customer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.RewardEntry{}
+	for rows.Next() {
+		var entry model.RewardEntry
+		var expiresAt sql.NullTime
+		err = rows.Scan(&entry.ID, &entry.Customer, &entry.Kind, &entry.Points, &entry.Reference, &entry.At, &expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			entry.ExpiresAt = &expiresAt.Time
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
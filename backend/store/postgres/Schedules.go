@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// Schedules keeps scheduled transfers in schedules TABLE
+type Schedules struct {
+	DB *sql.DB
+}
+
+const scheduleColumns = `id, customer, debit, credit, amount, currency, description, frequency, day,
+	next_run, retry_at, attempts, state, last_error, created_at`
+
+func (s Schedules) CreateSchedule(schedule model.Schedule) (model.Schedule, error) {
+	sqlQuery := `
+		INSERT INTO schedules (customer, debit, credit, amount, currency, description, frequency, day, next_run, state, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+	err := s.DB.QueryRow(sqlQuery,
+		schedule.Customer,
+		schedule.DebitAccount,
+		schedule.CreditAccount,
+		schedule.Amount,
+		schedule.Currency,
+		schedule.Description,
+		schedule.Frequency,
+		schedule.Day,
+		schedule.NextRun,
+		schedule.State,
+		schedule.CreatedAt,
+	).Scan(&schedule.ID)
+
+	return schedule, err
+}
+
+func (s Schedules) Schedules(customer string) ([]model.Schedule, error) {
+	sqlQuery := `SELECT ` + scheduleColumns + ` FROM schedules
+		WHERE customer = $1
+		ORDER BY id
+	`
+	return s.query(sqlQuery, customer)
+}
+
+func (s Schedules) CancelSchedule(id int64, customer string) (model.Schedule, error) {
+	sqlQuery := `
+		UPDATE schedules SET state = 'cancelled', retry_at = NULL
+		WHERE id = $1 AND customer = $2 AND state = 'active'
+		RETURNING ` + scheduleColumns
+	schedule, err := scanSchedule(s.DB.QueryRow(sqlQuery, id, customer))
+	if err == sql.ErrNoRows {
+		return schedule, store.NotFoundError{}
+	}
+
+	return schedule, err
+}
+
+func (s Schedules) DueSchedules(now time.Time) ([]model.Schedule, error) {
+	sqlQuery := `SELECT ` + scheduleColumns + ` FROM schedules
+		WHERE state = 'active' AND COALESCE(retry_at, next_run) <= $1
+		ORDER BY id
+	`
+	return s.query(sqlQuery, now)
+}
+
+func (s Schedules) UpdateSchedule(schedule model.Schedule) error {
+	sqlQuery := `
+		UPDATE schedules SET next_run = $1, retry_at = $2, attempts = $3, state = $4, last_error = $5
+		WHERE id = $6 AND state = 'active'
+	`
+	_, err := s.DB.Exec(sqlQuery,
+		schedule.NextRun,
+		schedule.RetryAt,
+		schedule.Attempts,
+		schedule.State,
+		schedule.LastError,
+		schedule.ID,
+	)
+
+	return err
+}
+
+func (s Schedules) query(sqlQuery string, args ...any) ([]model.Schedule, error) {
+	rows, err := s.DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []model.Schedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+func scanSchedule(row scanner) (model.Schedule, error) {
+	var (
+		schedule model.Schedule
+		retryAt  sql.NullTime
+	)
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.Customer,
+		&schedule.DebitAccount,
+		&schedule.CreditAccount,
+		&schedule.Amount,
+		&schedule.Currency,
+		&schedule.Description,
+		&schedule.Frequency,
+		&schedule.Day,
+		&schedule.NextRun,
+		&retryAt,
+		&schedule.Attempts,
+		&schedule.State,
+		&schedule.LastError,
+		&schedule.CreatedAt,
+	)
+	if retryAt.Valid {
+		schedule.RetryAt = &retryAt.Time
+	}
+
+	return schedule, err
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/store"
+)
+
+type Sessions struct {
+	DB *sql.DB
+}
+
+func (s Sessions) CreateSession(session store.Session) error {
+	sqlQuery := `
+		INSERT INTO sessions (id, account, role, refresh_hash, device, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := s.DB.Exec(sqlQuery,
+		session.ID, session.Account, session.Role, session.RefreshHash, session.Device,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt,
+	)
+	return err
+}
+
+func (s Sessions) UpdateSession(id string, update func(session *store.Session) error) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	//Lock the session so two refreshes with the same token cannot both succeed
+	sqlQuery := `
+		SELECT id, account, role, refresh_hash, device, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE id = $1
+		FOR UPDATE
+	`
+	var (
+		session   store.Session
+		revokedAt sql.NullTime
+	)
+	err = tx.QueryRow(sqlQuery, id).Scan(
+		&session.ID, &session.Account, &session.Role, &session.RefreshHash, &session.Device,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &revokedAt,
+	)
+	if err == sql.ErrNoRows {
+		return store.NotFoundError{}
+	}
+	if err != nil {
+		return err
+	}
+	session.RevokedAt = revokedAt.Time
+
+	err = update(&session)
+	if err != nil {
+		return err
+	}
+
+	sqlQuery = `
+		UPDATE sessions
+		SET refresh_hash = $1, last_used_at = $2, expires_at = $3, revoked_at = $4
+		WHERE id = $5
+	`
+	_, err = tx.Exec(sqlQuery,
+		session.RefreshHash, session.LastUsedAt, session.ExpiresAt,
+		sql.NullTime{Time: session.RevokedAt, Valid: !session.RevokedAt.IsZero()}, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s Sessions) ListSessions(account, role string, now time.Time) ([]store.Session, error) {
+	sqlQuery := `
+		SELECT id, account, role, refresh_hash, device, created_at, last_used_at, expires_at FROM sessions
+		WHERE account = $1 AND role = $2 AND revoked_at IS NULL AND expires_at > $3
+		ORDER BY last_used_at DESC
+	`
+	rows, err := s.DB.Query(sqlQuery, account, role, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []store.Session{}
+	for rows.Next() {
+		var session store.Session
+		err = rows.Scan(
+			&session.ID, &session.Account, &session.Role, &session.RefreshHash, &session.Device,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (s Sessions) RevokeSession(id, account, role string, at time.Time) error {
+	sqlQuery := `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE id = $2 AND account = $3 AND role = $4 AND revoked_at IS NULL
+	`
+	result, err := s.DB.Exec(sqlQuery, at, id, account, role)
+	return checkUpdated(result, err)
+}
+
+func (s Sessions) RevokeToken(jti string, expiresAt time.Time) error {
+	sqlQuery := `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	_, err := s.DB.Exec(sqlQuery, jti, expiresAt)
+	return err
+}
+
+func (s Sessions) IsTokenRevoked(sessionID, jti string) (bool, error) {
+	sqlQuery := `
+		SELECT
+			NOT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL)
+			OR EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $2)
+	`
+	var revoked bool
+	err := s.DB.QueryRow(sqlQuery, sessionID, jti).Scan(&revoked)
+	return revoked, err
+}
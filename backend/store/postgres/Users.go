@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	//Import standard library
+	"database/sql"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+type Users struct {
+	DB *sql.DB
+	//Every new account number starts with it
+	Prefix string
+}
+
+func (u Users) CreateUser(user model.User) (model.User, error) {
+	//Check if email has been registered in database
+	var id string
+	err := u.DB.QueryRow("SELECT id FROM users WHERE email = $1", user.Email).Scan(&id)
+	if err == nil {
+		return user, store.EmailTakenError{}
+	}
+	if err != sql.ErrNoRows {
+		return user, err
+	}
+
+	user.ID, err = nextAccountNumber(u.DB, u.Prefix)
+	if err != nil {
+		return user, err
+	}
+
+	//The customer and their checking account are created together
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return user, err
+	}
+	defer tx.Rollback()
+
+	sqlQuery := `
+		INSERT INTO users (id, email, password, fullname, exp, state)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = tx.Exec(sqlQuery, user.ID, user.Email, user.Password, user.Fullname, user.Exp, user.State)
+	if isUniqueViolation(err) {
+		//Someone registered the same email between the check and the insert
+		return user, store.EmailTakenError{}
+	}
+	if err != nil {
+		return user, err
+	}
+
+	err = insertAccount(tx, model.Account{
+		ID:       user.ID,
+		Customer: user.ID,
+		Type:     model.Checking,
+		Currency: model.DefaultCurrency,
+		State:    model.AccountOpen,
+		OpenedAt: time.Now(),
+	})
+	if err != nil {
+		return user, err
+	}
+
+	return user, tx.Commit()
+}
+
+func (u Users) UserByEmail(email string) (model.User, error) {
+	return u.find("email", email)
+}
+
+func (u Users) UserByID(id string) (model.User, error) {
+	return u.find("id", id)
+}
+
+func (u Users) UpdateUserPassword(id, hash string) error {
+	result, err := u.DB.Exec("UPDATE users SET password = $1 WHERE id = $2", hash, id)
+	return checkUpdated(result, err)
+}
+
+// find looks a user up by one of its unique columns
+func (u Users) find(column, value string) (model.User, error) {
+	sqlQuery := `
+		SELECT id, email, password, fullname, exp, state FROM users
+		WHERE ` + column + ` = $1
+	`
+	var user model.User
+	err := u.DB.QueryRow(sqlQuery, value).Scan(
+		&user.ID, &user.Email, &user.Password, &user.Fullname, &user.Exp, &user.State,
+	)
+	if err == sql.ErrNoRows {
+		return user, store.NotFoundError{}
+	}
+
+	return user, err
+}
+
+// checkUpdated turns an UPDATE that matched no row into NotFoundError
+func checkUpdated(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return store.NotFoundError{}
+	}
+
+	return nil
+}
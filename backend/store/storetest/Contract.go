@@ -0,0 +1,588 @@
+package storetest
+
+import (
+	//Import standard library
+	"sync"
+	"testing"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// Run checks that stores keep the contract documented in package store. Every implementation
+// runs the same tests, so the in-memory stores the handler tests use behave like the Postgres
+// ones. open returns empty stores for each test
+func Run(t *testing.T, open func(t *testing.T) store.Stores) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, stores store.Stores)
+	}{
+		{"CreateUserRejectsTakenEmail", testCreateUserRejectsTakenEmail},
+		{"CloseAccount", testCloseAccount},
+		{"Transfer", testTransfer},
+		{"ConcurrentWithdrawNeverOverdraws", testConcurrentWithdraw},
+		{"DailyLimitIsPerCustomer", testDailyLimit},
+		{"ConvertedTransfer", testConvertedTransfer},
+		{"ScheduleRunMadeOnce", testScheduleRun},
+		{"RewardPaidByTheBank", testReward},
+		{"Schedules", testSchedules},
+		{"Idempotency", testIdempotency},
+		{"CoolingOff", testCoolingOff},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, open(t))
+		})
+	}
+}
+
+// newCustomer registers a customer, who gets a USD checking account numbered like them
+func newCustomer(t *testing.T, stores store.Stores, email, fullname string) model.User {
+	t.Helper()
+
+	user, err := stores.Users.CreateUser(model.User{Email: email, Password: "hash", Fullname: fullname, State: "active"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// balance returns the account's balance
+func balance(t *testing.T, stores store.Stores, account string) model.Money {
+	t.Helper()
+
+	stored, err := stores.Accounts.AccountByID(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return stored.Balance
+}
+
+// reconcile fails the test when the journal and the balances disagree
+func reconcile(t *testing.T, stores store.Stores) {
+	t.Helper()
+
+	mismatches, err := stores.Ledger.Reconcile()
+	if err != nil || len(mismatches) != 0 {
+		t.Fatalf("Reconcile = %v, %v", mismatches, err)
+	}
+}
+
+func testCreateUserRejectsTakenEmail(t *testing.T, stores store.Stores) {
+	newCustomer(t, stores, "alice@example.com", "Alice")
+
+	_, err := stores.Users.CreateUser(model.User{Email: "alice@example.com", Password: "hash", State: "active"})
+	if _, ok := err.(store.EmailTakenError); !ok {
+		t.Fatalf("err = %v, want EmailTakenError", err)
+	}
+}
+
+func testCloseAccount(t *testing.T, stores store.Stores) {
+	user := newCustomer(t, stores, "alice@example.com", "Alice")
+
+	//The checking account opened at registration is the only one
+	_, err := stores.Accounts.CloseAccount(user.ID, time.Now())
+	if _, ok := err.(store.LastAccountError); !ok {
+		t.Fatalf("err = %v, want LastAccountError", err)
+	}
+
+	savings, err := stores.Accounts.OpenAccount(model.Account{Customer: user.ID, Type: model.Savings, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stores.Ledger.Topup(savings.ID, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stores.Accounts.CloseAccount(savings.ID, time.Now())
+	if _, ok := err.(store.AccountNotEmptyError); !ok {
+		t.Fatalf("err = %v, want AccountNotEmptyError", err)
+	}
+
+	_, err = stores.Ledger.Transfer(model.Transaction{
+		Date: time.Now(), DebitAccount: savings.ID, CreditAccount: user.ID,
+		Amount: 500, Currency: "USD", CreditAmount: 500, CreditCurrency: "USD",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed, err := stores.Accounts.CloseAccount(savings.ID, time.Now())
+	if err != nil || closed.State != model.AccountClosed || closed.ClosedAt == nil {
+		t.Fatalf("CloseAccount = %+v, %v", closed, err)
+	}
+
+	//No money moves through a closed account
+	_, err = stores.Ledger.Topup(savings.ID, 100)
+	if _, ok := err.(ledger.AccountClosedError); !ok {
+		t.Fatalf("err = %v, want AccountClosedError", err)
+	}
+}
+
+func testTransfer(t *testing.T, stores store.Stores) {
+	alice := newCustomer(t, stores, "alice@example.com", "Alice")
+	bob := newCustomer(t, stores, "bob@example.com", "Bob")
+	_, err := stores.Ledger.Topup(alice.ID, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer := model.Transaction{
+		Date: time.Now(), DebitAccount: alice.ID, CreditAccount: bob.ID, Beneficiary: "Mallory",
+		Amount: 300, Currency: "USD", CreditAmount: 300, CreditCurrency: "USD", Description: "Lunch",
+	}
+	_, err = stores.Ledger.Transfer(model.Transaction{DebitAccount: alice.ID, CreditAccount: bob.ID})
+	if _, ok := err.(ledger.InvalidAmountError); !ok {
+		t.Fatalf("err = %v, want InvalidAmountError", err)
+	}
+
+	//The beneficiary is the credit account's owner, whatever the caller sent
+	stored, err := stores.Ledger.Transfer(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ID == 0 || stored.Type != "transfer" || stored.Beneficiary != "Bob" {
+		t.Fatalf("Transfer = %+v", stored)
+	}
+
+	transfer.Amount, transfer.CreditAmount = 701, 701
+	_, err = stores.Ledger.Transfer(transfer)
+	if _, ok := err.(ledger.InsufficientFundsError); !ok {
+		t.Fatalf("err = %v, want InsufficientFundsError", err)
+	}
+	if balance(t, stores, alice.ID) != 700 || balance(t, stores, bob.ID) != 300 {
+		t.Fatalf("balances = %s and %s, want 7.00 and 3.00", balance(t, stores, alice.ID), balance(t, stores, bob.ID))
+	}
+
+	//Both sides see the transfer, newest first, and the direction filter picks one side
+	_, err = stores.Ledger.Withdraw(alice.ID, 200, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := stores.Ledger.Transactions(alice.ID, ledger.TransactionFilter{Limit: 10})
+	if err != nil || len(history) != 3 || history[0].Type != "withdrawal" || history[1].Type != "transfer" || history[2].Type != "topup" {
+		t.Fatalf("Transactions = %+v, %v", history, err)
+	}
+	history, err = stores.Ledger.Transactions(bob.ID, ledger.TransactionFilter{Limit: 10, Direction: "out"})
+	if err != nil || len(history) != 0 {
+		t.Fatalf("Transactions out = %+v, %v", history, err)
+	}
+
+	reconcile(t, stores)
+}
+
+func testConcurrentWithdraw(t *testing.T, stores store.Stores) {
+	user := newCustomer(t, stores, "alice@example.com", "Alice")
+	_, err := stores.Ledger.Topup(user.ID, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Twenty withdrawals of 1.00 race for 10.00
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := stores.Ledger.Withdraw(user.ID, 100, 0)
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			} else if _, ok := err.(ledger.InsufficientFundsError); !ok {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 10 || balance(t, stores, user.ID) != 0 {
+		t.Fatalf("%d withdrawals succeeded and balance is %s, want 10 and 0.00", succeeded, balance(t, stores, user.ID))
+	}
+
+	reconcile(t, stores)
+}
+
+func testDailyLimit(t *testing.T, stores store.Stores) {
+	user := newCustomer(t, stores, "alice@example.com", "Alice")
+	bob := newCustomer(t, stores, "bob@example.com", "Bob")
+	savings, err := stores.Accounts.OpenAccount(model.Account{Customer: user.ID, Type: model.Savings, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range []string{user.ID, savings.ID} {
+		_, err = stores.Ledger.Topup(account, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//Moving money between the customer's own accounts does not use up the limit
+	_, err = stores.Ledger.Transfer(model.Transaction{
+		Date: time.Now(), DebitAccount: user.ID, CreditAccount: savings.ID,
+		Amount: 500, Currency: "USD", CreditAmount: 500, CreditCurrency: "USD",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Twenty withdrawals of 1.00 from both accounts race for a limit of 5.00
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(account string) {
+			defer wg.Done()
+			_, err := stores.Ledger.Withdraw(account, 100, 500)
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			} else if _, ok := err.(store.DailyLimitError); !ok {
+				t.Error(err)
+			}
+		}([]string{user.ID, savings.ID}[i%2])
+	}
+	wg.Wait()
+
+	if succeeded != 5 {
+		t.Fatalf("%d withdrawals succeeded, want 5", succeeded)
+	}
+
+	//Transfers to others from either account share their own limit
+	transfer := model.Transaction{
+		Date: time.Now(), DebitAccount: user.ID, CreditAccount: bob.ID,
+		Amount: 300, Currency: "USD", CreditAmount: 300, CreditCurrency: "USD", DailyLimit: 500,
+	}
+	_, err = stores.Ledger.Transfer(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer.DebitAccount = savings.ID
+	_, err = stores.Ledger.Transfer(transfer)
+	if e, ok := err.(store.DailyLimitError); !ok || e.Kind != "transfer" || e.Used != 300 {
+		t.Fatalf("err = %v, want DailyLimitError with 3.00 used", err)
+	}
+}
+
+func testConvertedTransfer(t *testing.T, stores store.Stores) {
+	user := newCustomer(t, stores, "alice@example.com", "Alice")
+	euros, err := stores.Accounts.OpenAccount(model.Account{Customer: user.ID, Type: model.Savings, Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stores.Ledger.Topup(user.ID, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quote := model.Quote{
+		ID: "q1", Customer: user.ID, DebitAccount: user.ID, CreditAccount: euros.ID, From: "USD", To: "EUR",
+		Rate: 92000000, Amount: 10000, Converted: 9200, ExpiresAt: time.Now().Add(time.Minute),
+	}
+	err = stores.Quotes.CreateQuote(quote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer := model.Transaction{
+		Date: time.Now(), DebitAccount: user.ID, CreditAccount: euros.ID, Amount: 10000, Currency: "USD",
+		CreditAmount: 9200, CreditCurrency: "EUR", Rate: 92000000, QuoteID: quote.ID,
+	}
+
+	//Each side must move in its account's currency
+	wrong := transfer
+	wrong.CreditCurrency, wrong.CreditAmount = "USD", 10000
+	wrong.Rate, wrong.QuoteID = 0, ""
+	_, err = stores.Ledger.Transfer(wrong)
+	if _, ok := err.(ledger.CurrencyMismatchError); !ok {
+		t.Fatalf("err = %v, want CurrencyMismatchError", err)
+	}
+
+	_, err = stores.Ledger.Transfer(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance(t, stores, euros.ID) != 9200 {
+		t.Fatalf("balance = %s, want 92.00", balance(t, stores, euros.ID))
+	}
+
+	//A quote is good for one transfer
+	_, err = stores.Ledger.Topup(user.ID, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stores.Ledger.Transfer(transfer)
+	if _, ok := err.(store.QuoteUsedError); !ok {
+		t.Fatalf("err = %v, want QuoteUsedError", err)
+	}
+
+	reconcile(t, stores)
+}
+
+func testScheduleRun(t *testing.T, stores store.Stores) {
+	alice := newCustomer(t, stores, "alice@example.com", "Alice")
+	bob := newCustomer(t, stores, "bob@example.com", "Bob")
+	_, err := stores.Ledger.Topup(alice.ID, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer := model.Transaction{
+		Date: time.Now(), DebitAccount: alice.ID, CreditAccount: bob.ID,
+		Amount: 100, Currency: "USD", CreditAmount: 100, CreditCurrency: "USD", ScheduleRun: "1/2026-01-31",
+	}
+	_, err = stores.Ledger.Transfer(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stores.Ledger.Transfer(transfer)
+	if _, ok := err.(store.ScheduleRunDoneError); !ok {
+		t.Fatalf("err = %v, want ScheduleRunDoneError", err)
+	}
+
+	//Another run of the schedule is a new transfer
+	transfer.ScheduleRun = "1/2026-02-28"
+	_, err = stores.Ledger.Transfer(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance(t, stores, bob.ID) != 200 {
+		t.Fatalf("balance = %s, want 2.00", balance(t, stores, bob.ID))
+	}
+
+	reconcile(t, stores)
+}
+
+func testReward(t *testing.T, stores store.Stores) {
+	user := newCustomer(t, stores, "alice@example.com", "Alice")
+
+	credited, err := stores.Ledger.Reward(user.ID, 250, "Cashback")
+	if err != nil || credited != 250 || balance(t, stores, user.ID) != 250 {
+		t.Fatalf("Reward = %s, %v, want a balance of 2.50", credited, err)
+	}
+	history, err := stores.Ledger.Transactions(user.ID, ledger.TransactionFilter{Limit: 10})
+	if err != nil || len(history) != 1 || history[0].Type != "reward" || history[0].Description != "Cashback" {
+		t.Fatalf("Transactions = %+v, %v", history, err)
+	}
+
+	//The rewards account only lives in the journal, like the cash account
+	_, err = stores.Accounts.AccountByID(ledger.RewardsAccount)
+	if _, ok := err.(store.NotFoundError); !ok {
+		t.Fatalf("rewards account: err = %v, want NotFoundError", err)
+	}
+
+	reconcile(t, stores)
+}
+
+func testSchedules(t *testing.T, stores store.Stores) {
+	alice := newCustomer(t, stores, "alice@example.com", "Alice")
+	bob := newCustomer(t, stores, "bob@example.com", "Bob")
+
+	today := model.Day(time.Now())
+	schedule := model.Schedule{
+		Customer: alice.ID, DebitAccount: alice.ID, CreditAccount: bob.ID, Amount: 2500, Currency: "USD",
+		Description: "Rent", Frequency: model.Monthly, Day: today.Day(), NextRun: today,
+		State: model.ScheduleActive, CreatedAt: time.Now().UTC(),
+	}
+	due, err := stores.Schedules.CreateSchedule(schedule)
+	if err != nil || due.ID == 0 {
+		t.Fatalf("CreateSchedule = %+v, %v", due, err)
+	}
+	schedule.NextRun = today.AddDate(0, 0, 7)
+	later, err := stores.Schedules.CreateSchedule(schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedules, err := stores.Schedules.Schedules(alice.ID)
+	if err != nil || len(schedules) != 2 || schedules[0].ID != due.ID || schedules[1].ID != later.ID {
+		t.Fatalf("Schedules = %+v, %v", schedules, err)
+	}
+	schedules, err = stores.Schedules.Schedules(bob.ID)
+	if err != nil || len(schedules) != 0 {
+		t.Fatalf("Schedules of another customer = %+v, %v", schedules, err)
+	}
+
+	dueSchedules, err := stores.Schedules.DueSchedules(today)
+	if err != nil || len(dueSchedules) != 1 || dueSchedules[0].ID != due.ID {
+		t.Fatalf("DueSchedules = %+v, %v", dueSchedules, err)
+	}
+
+	//A run moves the schedule on, it is no longer due
+	due.NextRun = today.AddDate(0, 1, 0)
+	due.LastError = "Insufficient funds"
+	err = stores.Schedules.UpdateSchedule(due)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dueSchedules, err = stores.Schedules.DueSchedules(today)
+	if err != nil || len(dueSchedules) != 0 {
+		t.Fatalf("DueSchedules after the run = %+v, %v", dueSchedules, err)
+	}
+
+	//Only the customer cancels their schedule, once, and a run does not bring it back
+	_, err = stores.Schedules.CancelSchedule(later.ID, bob.ID)
+	if _, ok := err.(store.NotFoundError); !ok {
+		t.Fatalf("err = %v, want NotFoundError", err)
+	}
+	cancelled, err := stores.Schedules.CancelSchedule(later.ID, alice.ID)
+	if err != nil || cancelled.State != model.ScheduleCancelled {
+		t.Fatalf("CancelSchedule = %+v, %v", cancelled, err)
+	}
+	_, err = stores.Schedules.CancelSchedule(later.ID, alice.ID)
+	if _, ok := err.(store.NotFoundError); !ok {
+		t.Fatalf("err = %v, want NotFoundError", err)
+	}
+	err = stores.Schedules.UpdateSchedule(later)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedules, err = stores.Schedules.Schedules(alice.ID)
+	if err != nil || len(schedules) != 2 || schedules[0].LastError != "Insufficient funds" || schedules[1].State != model.ScheduleCancelled {
+		t.Fatalf("Schedules = %+v, %v", schedules, err)
+	}
+	dueSchedules, err = stores.Schedules.DueSchedules(today.AddDate(0, 0, 7))
+	if err != nil || len(dueSchedules) != 0 {
+		t.Fatalf("DueSchedules with a cancelled schedule = %+v, %v", dueSchedules, err)
+	}
+}
+
+func testIdempotency(t *testing.T, stores store.Stores) {
+	user := newCustomer(t, stores, "alice@example.com", "Alice")
+
+	now := time.Now().UTC()
+	record := store.IdempotencyRecord{
+		Customer: user.ID, Key: "key-1", RequestHash: "hash-1", CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	}
+	reserved, ok, err := stores.Idempotency.ReserveKey(record)
+	if err != nil || !ok || reserved.Status != 0 {
+		t.Fatalf("ReserveKey = %+v, %t, %v", reserved, ok, err)
+	}
+
+	//While it is in progress the key is taken
+	retry := record
+	retry.CreatedAt = now.Add(time.Minute)
+	stored, ok, err := stores.Idempotency.ReserveKey(retry)
+	if err != nil || ok || stored.RequestHash != "hash-1" || stored.Status != 0 {
+		t.Fatalf("ReserveKey in progress = %+v, %t, %v", stored, ok, err)
+	}
+
+	//Once complete the key holds the response
+	record.Status, record.ContentType, record.Body = 201, "application/json", []byte(`"done"`)
+	err = stores.Idempotency.CompleteKey(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, ok, err = stores.Idempotency.ReserveKey(retry)
+	if err != nil || ok || stored.Status != 201 || stored.ContentType != "application/json" || string(stored.Body) != `"done"` {
+		t.Fatalf("ReserveKey completed = %+v, %t, %v", stored, ok, err)
+	}
+
+	//Keys are kept per customer
+	other := newCustomer(t, stores, "bob@example.com", "Bob")
+	retry.Customer = other.ID
+	_, ok, err = stores.Idempotency.ReserveKey(retry)
+	if err != nil || !ok {
+		t.Fatalf("ReserveKey of another customer = %t, %v", ok, err)
+	}
+
+	//An expired key can be used again, with a new request
+	expired := record
+	expired.RequestHash = "hash-2"
+	expired.CreatedAt, expired.ExpiresAt = now.Add(time.Hour), now.Add(2*time.Hour)
+	reserved, ok, err = stores.Idempotency.ReserveKey(expired)
+	if err != nil || !ok || reserved.Status != 0 || reserved.Body != nil {
+		t.Fatalf("ReserveKey expired = %+v, %t, %v", reserved, ok, err)
+	}
+
+	//A released key is forgotten
+	err = stores.Idempotency.ReleaseKey(user.ID, record.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok, err = stores.Idempotency.ReserveKey(record)
+	if err != nil || !ok {
+		t.Fatalf("ReserveKey released = %t, %v", ok, err)
+	}
+
+	unknown := record
+	unknown.Key = "key-2"
+	err = stores.Idempotency.CompleteKey(unknown)
+	if _, ok := err.(store.NotFoundError); !ok {
+		t.Fatalf("err = %v, want NotFoundError", err)
+	}
+}
+
+func testCoolingOff(t *testing.T, stores store.Stores) {
+	alice := newCustomer(t, stores, "alice@example.com", "Alice")
+	bob := newCustomer(t, stores, "bob@example.com", "Bob")
+	carol := newCustomer(t, stores, "carol@example.com", "Carol")
+
+	now := time.Now().UTC()
+	until := now.Add(24 * time.Hour)
+	payee, err := stores.Payees.CreatePayee(model.Payee{
+		Customer: alice.ID, Nickname: "Bob", Account: bob.ID, Name: "Bob", AddedAt: now, CoolingOffUntil: until,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reserved, err := stores.Payees.ReserveCoolingOff(alice.ID, bob.ID, 3000, 5000, now, now.Add(time.Hour))
+	if err != nil || !reserved {
+		t.Fatalf("ReserveCoolingOff = %t, %v", reserved, err)
+	}
+	_, err = stores.Payees.ReserveCoolingOff(alice.ID, bob.ID, 2001, 5000, now, now.Add(time.Hour))
+	if _, ok := err.(store.CoolingOffLimitError); !ok {
+		t.Fatalf("err = %v, want CoolingOffLimitError", err)
+	}
+
+	//Deleting the payee and saving it again keeps the account's cooling-off
+	err = stores.Payees.DeletePayee(payee.ID, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stores.Payees.ReserveCoolingOff(alice.ID, bob.ID, 2001, 5000, now, now.Add(time.Hour))
+	if _, ok := err.(store.CoolingOffLimitError); !ok {
+		t.Fatalf("err after delete = %v, want CoolingOffLimitError", err)
+	}
+	payee, err = stores.Payees.CreatePayee(model.Payee{
+		Customer: alice.ID, Nickname: "Bob", Account: bob.ID, Name: "Bob", AddedAt: now, CoolingOffUntil: now.Add(48 * time.Hour),
+	})
+	if err != nil || payee.CoolingOffSent != 3000 || payee.CoolingOffUntil.Sub(until).Abs() > time.Millisecond {
+		t.Fatalf("CreatePayee again = %+v, %v", payee, err)
+	}
+
+	//A failed transfer gives its amount back
+	err = stores.Payees.ReleaseCoolingOff(alice.ID, bob.ID, 3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reserved, err = stores.Payees.ReserveCoolingOff(alice.ID, bob.ID, 5000, 5000, now, now.Add(time.Hour))
+	if err != nil || !reserved {
+		t.Fatalf("ReserveCoolingOff after release = %t, %v", reserved, err)
+	}
+
+	//The first payment to an account never saved starts its cooling-off, once over it no
+	//longer limits anything
+	reserved, err = stores.Payees.ReserveCoolingOff(alice.ID, carol.ID, 5000, 5000, now, now.Add(time.Hour))
+	if err != nil || !reserved {
+		t.Fatalf("ReserveCoolingOff unsaved = %t, %v", reserved, err)
+	}
+	reserved, err = stores.Payees.ReserveCoolingOff(alice.ID, carol.ID, 1, 5000, now.Add(time.Hour), now.Add(2*time.Hour))
+	if err != nil || reserved {
+		t.Fatalf("ReserveCoolingOff after cooling-off = %t, %v", reserved, err)
+	}
+}
@@ -0,0 +1,184 @@
+package user
+
+import (
+	//Import standard library
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/middleware"
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+func (h Handler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	accounts, err := h.Accounts.Accounts(claims.ID)
+	if err != nil {
+		utility.InternalError(w, "Error at: ListAccounts -> Error querying accounts", err)
+		return
+	}
+
+	//Package data
+	data, err := json.MarshalIndent(accounts, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: ListAccounts -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (h Handler) OpenAccount(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: OpenAccount -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body, only the type, name and currency are taken from the client
+	var request model.Account
+	err = json.Unmarshal(data, &request)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	account := model.Account{
+		Customer: claims.ID,
+		Type:     strings.ToLower(strings.TrimSpace(request.Type)),
+		Name:     strings.TrimSpace(request.Name),
+	}
+	if !model.ValidAccountType(account.Type) {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidType, "Account type must be checking, savings or pot")
+		return
+	}
+	if account.Type == model.Pot && account.Name == "" {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "A pot must have a name")
+		return
+	}
+	if len(account.Name) > 30 {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Account name must have at most 30 characters")
+		return
+	}
+
+	//Accounts are held in the default currency unless another one is asked for
+	account.Currency = model.DefaultCurrency
+	if request.Currency != "" {
+		account.Currency, err = model.ParseCurrency(string(request.Currency))
+		if err != nil {
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidCurrency, err.Error())
+			return
+		}
+	}
+
+	account, err = h.Accounts.OpenAccount(account)
+	if err != nil {
+		utility.InternalError(w, "Error at: OpenAccount -> Error storing account", err)
+		return
+	}
+
+	//Send the new account back to client
+	data, err = json.MarshalIndent(account, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: OpenAccount -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+func (h Handler) CloseAccount(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: CloseAccount -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body
+	var id string
+	err = json.Unmarshal(data, &id)
+	if err != nil || id == "" {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	//Only the caller's own accounts can be closed
+	_, ok := h.ownAccount(w, claims.ID, id)
+	if !ok {
+		return
+	}
+
+	_, err = h.Accounts.CloseAccount(id, time.Now())
+	if err != nil {
+		switch err.(type) {
+		case store.NotFoundError:
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountClosed, "This account is already closed")
+			return
+		case store.AccountNotEmptyError:
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountNotEmpty, err.Error())
+			return
+		case store.LastAccountError:
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeLastAccount, err.Error())
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: CloseAccount -> Error closing account", err)
+		return
+	}
+
+	//Send successful message to client
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Account closed successfully"))
+}
+
+// ownAccount finds one of the customer's accounts, closed ones included. An empty id selects
+// the checking account opened at registration. Accounts of other customers are reported as not
+// found, so account numbers cannot be probed. When it returns false the problem has been sent
+func (h Handler) ownAccount(w http.ResponseWriter, customer, id string) (model.Account, bool) {
+	if id == "" {
+		id = customer
+	}
+
+	err := model.ValidateAccountNumber(id)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAccount, err.Error())
+		return model.Account{}, false
+	}
+
+	account, err := h.Accounts.AccountByID(id)
+	if err != nil {
+		if _, ok := err.(store.NotFoundError); ok {
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodeAccountNotFound, "No account was found")
+			return account, false
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: ownAccount -> Error querying account", err)
+		return account, false
+	}
+
+	if account.Customer != customer {
+		utility.WriteProblem(w, http.StatusNotFound, utility.CodeAccountNotFound, "No account was found")
+		return account, false
+	}
+
+	return account, true
+}
@@ -0,0 +1,34 @@
+package user
+
+import (
+	//Import standard library
+	"encoding/json"
+	"net/http"
+
+	//Import user's defined package
+	"gobank-server/middleware"
+	"gobank-server/utility"
+)
+
+// GetAchievements sends every achievement as a badge, the caller's unlocked ones first with
+// when they were unlocked, then the locked ones
+func (h Handler) GetAchievements(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	badges, err := h.Badges.Badges(claims.ID)
+	if err != nil {
+		utility.InternalError(w, "Error at: GetAchievements -> Error querying achievements", err)
+		return
+	}
+
+	//Package data
+	data, err := json.MarshalIndent(badges, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: GetAchievements -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,41 @@
+package user
+
+import (
+	//Import standard library
+	"time"
+
+	//Import user's defined package
+	"gobank-server/achievement"
+	"gobank-server/exp"
+	"gobank-server/fx"
+	"gobank-server/model"
+	"gobank-server/perks"
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+// Handler serves the user endpoints with the stores it is given
+type Handler struct {
+	Users         store.UserStore
+	Accounts      store.AccountStore
+	Ledger        store.LedgerStore
+	Quotes        store.QuoteStore
+	Schedules     store.ScheduleStore
+	Payees        store.PayeeStore
+	RewardEntries store.RewardStore
+	Exchange      fx.Exchange
+	CoolingOff    CoolingOff
+	Rewards       Rewards
+	Exp           exp.Engine
+	Badges        achievement.Tracker
+	Perks         perks.Table
+	Levels        utility.LevelCurve
+}
+
+// CoolingOff limits what can be sent to another customer's account in the first Period after it
+// is saved as a payee or paid, whichever comes first. The amount credited is counted, so Limit
+// is in the currency of the account paid, even when the money comes from another currency
+type CoolingOff struct {
+	Period time.Duration
+	Limit  model.Money
+}
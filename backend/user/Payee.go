@@ -0,0 +1,218 @@
+package user
+
+import (
+	//Import standard library
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/middleware"
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+func (h Handler) ListPayees(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	payees, err := h.Payees.Payees(claims.ID)
+	if err != nil {
+		utility.InternalError(w, "Error at: ListPayees -> Error querying payees", err)
+		return
+	}
+
+	//Package data
+	data, err := json.MarshalIndent(payees, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: ListPayees -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// AddPayee saves another customer's account with its owner's name. The payee starts its
+// cooling-off period
+func (h Handler) AddPayee(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: AddPayee -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body, only the account and the nickname are taken from the client
+	var request model.Payee
+	err = json.Unmarshal(data, &request)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	err = model.ValidateAccountNumber(request.Account)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAccount, err.Error())
+		return
+	}
+
+	account, err := h.Accounts.AccountByID(request.Account)
+	if err != nil {
+		if _, ok := err.(store.NotFoundError); ok {
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodeAccountNotFound, "No account was found")
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: AddPayee -> Error querying account", err)
+		return
+	}
+
+	if account.State != model.AccountOpen {
+		utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountClosed, "This account is closed")
+		return
+	}
+	if account.Customer == claims.ID {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Your own accounts cannot be saved as payees")
+		return
+	}
+
+	owner, err := h.Users.UserByID(account.Customer)
+	if err != nil {
+		utility.InternalError(w, "Error at: AddPayee -> Error querying account's owner", err)
+		return
+	}
+
+	//The owner's name stands in for a missing nickname
+	now := time.Now()
+	payee := model.Payee{
+		Customer:        claims.ID,
+		Nickname:        strings.TrimSpace(request.Nickname),
+		Account:         account.ID,
+		Name:            owner.Fullname,
+		AddedAt:         now,
+		CoolingOffUntil: now.Add(h.CoolingOff.Period),
+	}
+	if payee.Nickname == "" {
+		payee.Nickname = payee.Name
+	}
+	if len(payee.Nickname) > 30 {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Nickname must have at most 30 characters")
+		return
+	}
+
+	payee, err = h.Payees.CreatePayee(payee)
+	if err != nil {
+		if _, ok := err.(store.PayeeExistsError); ok {
+			utility.WriteProblem(w, http.StatusConflict, utility.CodePayeeExists, err.Error())
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: AddPayee -> Error storing payee", err)
+		return
+	}
+
+	//Send the payee back to client
+	data, err = json.MarshalIndent(payee, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: AddPayee -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// EditPayee renames a payee. Its account cannot change, a new payee is added instead
+func (h Handler) EditPayee(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: EditPayee -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body
+	var request model.Payee
+	err = json.Unmarshal(data, &request)
+	if err != nil || request.ID <= 0 {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	nickname := strings.TrimSpace(request.Nickname)
+	if nickname == "" || len(nickname) > 30 {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Nickname must have between 1 and 30 characters")
+		return
+	}
+
+	//Payees of other customers are reported as not found
+	payee, err := h.Payees.RenamePayee(request.ID, claims.ID, nickname)
+	if err != nil {
+		if _, ok := err.(store.NotFoundError); ok {
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodePayeeNotFound, "No payee was found")
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: EditPayee -> Error renaming payee", err)
+		return
+	}
+
+	//Send the payee back to client
+	data, err = json.MarshalIndent(payee, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: EditPayee -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (h Handler) DeletePayee(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: DeletePayee -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body
+	var id int64
+	err = json.Unmarshal(data, &id)
+	if err != nil || id <= 0 {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	err = h.Payees.DeletePayee(id, claims.ID)
+	if err != nil {
+		if _, ok := err.(store.NotFoundError); ok {
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodePayeeNotFound, "No payee was found")
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: DeletePayee -> Error deleting payee", err)
+		return
+	}
+
+	//Send successful message to client
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Payee deleted successfully"))
+}
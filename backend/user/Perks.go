@@ -0,0 +1,73 @@
+package user
+
+import (
+	//Import standard library
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	//Import user's defined package
+	"gobank-server/middleware"
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+// DailyLimitError is returned when a transfer or a withdrawal would take the customer over their
+// tier's daily limit. Used is what already left their accounts today
+type DailyLimitError struct {
+	Kind  string //"transfer" or "withdrawal"
+	Tier  string
+	Limit model.Money
+	Used  model.Money
+}
+
+func (e DailyLimitError) Error() string {
+	return fmt.Sprintf("Your %s tier allows %s of %ss a day from your accounts, %s has been used today", e.Tier, e.Limit, e.Kind, e.Used)
+}
+
+// GetPerks sends the caller's level, its progress through it and its tier's perks
+func (h Handler) GetPerks(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	perks, err := h.perks(claims.ID)
+	if err != nil {
+		utility.InternalError(w, "Error at: GetPerks -> Error querying user", err)
+		return
+	}
+
+	//Package data
+	data, err := json.MarshalIndent(perks, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: GetPerks -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// perks works out the customer's level from its current EXP, so a level reached a moment ago
+// already counts
+func (h Handler) perks(customer string) (model.Perks, error) {
+	user, err := h.Users.UserByID(customer)
+	if err != nil {
+		return model.Perks{}, err
+	}
+
+	perks := model.Perks{Exp: user.Exp}
+	perks.Level, perks.LevelMin, perks.LevelMax = h.Levels.ProgressToNext(user.Exp)
+	perks.Tier, perks.Next = h.Perks.ForLevel(perks.Level)
+	return perks, nil
+}
+
+// tierLimit names the customer's tier in a store.DailyLimitError, other errors are returned as
+// they are
+func tierLimit(err error, tier model.Tier) error {
+	if e, ok := err.(store.DailyLimitError); ok {
+		return DailyLimitError{Kind: e.Kind, Tier: tier.Name, Limit: e.Limit, Used: e.Used}
+	}
+
+	return err
+}
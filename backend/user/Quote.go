@@ -0,0 +1,168 @@
+package user
+
+import (
+	//Import standard library
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/fx"
+	"gobank-server/ledger"
+	"gobank-server/middleware"
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+// CreateQuote locks the current exchange rate for a transfer between two accounts held in
+// different currencies. The transfer is then made with the quote's ID before it expires
+func (h Handler) CreateQuote(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: CreateQuote -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body, only the accounts and the amount are taken from the client
+	var request model.Quote
+	err = json.Unmarshal(data, &request)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	err = ledger.CheckAmount(request.Amount)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAmount, err.Error())
+		return
+	}
+
+	//The debit account must be one of the caller's, the checking account by default
+	debit, ok := h.ownAccount(w, claims.ID, request.DebitAccount)
+	if !ok {
+		return
+	}
+
+	err = model.ValidateAccountNumber(request.CreditAccount)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAccount, err.Error())
+		return
+	}
+
+	credit, err := h.Accounts.AccountByID(request.CreditAccount)
+	if err != nil {
+		if _, ok := err.(store.NotFoundError); ok {
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodeAccountNotFound, "No account was found")
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: CreateQuote -> Error querying credit account", err)
+		return
+	}
+
+	if debit.State != model.AccountOpen || credit.State != model.AccountOpen {
+		utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountClosed, "This account is closed")
+		return
+	}
+
+	if debit.Currency == credit.Currency {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest,
+			fmt.Sprintf("Both accounts are held in %s, no conversion is needed", debit.Currency))
+		return
+	}
+
+	//The customer's tier takes a discount off the conversion fee
+	perks, err := h.perks(claims.ID)
+	if err != nil {
+		utility.InternalError(w, "Error at: CreateQuote -> Error querying user", err)
+		return
+	}
+
+	quote, err := h.Exchange.Quote(model.Quote{
+		Customer:      claims.ID,
+		DebitAccount:  debit.ID,
+		CreditAccount: credit.ID,
+		From:          debit.Currency,
+		To:            credit.Currency,
+		Amount:        request.Amount,
+	}, perks.Tier.FeeDiscount, time.Now())
+	if err != nil {
+		if _, ok := err.(fx.RateUnavailableError); ok {
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeRateUnavailable, err.Error())
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: CreateQuote -> Error quoting rate", err)
+		return
+	}
+
+	err = h.Quotes.CreateQuote(quote)
+	if err != nil {
+		utility.InternalError(w, "Error at: CreateQuote -> Error storing quote", err)
+		return
+	}
+
+	//Send the quote back to client
+	data, err = json.MarshalIndent(quote, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: CreateQuote -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// QuoteError is why the quote of a converted transfer was refused, Code is the problem code
+type QuoteError struct {
+	Code   string
+	Detail string
+}
+
+func (e QuoteError) Error() string {
+	return e.Detail
+}
+
+// applyQuote converts a transfer between two currencies at the rate of the quote it names. The
+// quote must be the caller's, for the same accounts and amount, and not expired
+func (h Handler) applyQuote(customer string, transaction *model.Transaction) error {
+	if transaction.QuoteID == "" {
+		return QuoteError{Code: utility.CodeQuoteRequired,
+			Detail: fmt.Sprintf("Money is converted from %s to %s, ask for a quote first", transaction.Currency, transaction.CreditCurrency)}
+	}
+
+	quote, err := h.Quotes.QuoteByID(transaction.QuoteID)
+	if err != nil {
+		if _, ok := err.(store.NotFoundError); ok {
+			return QuoteError{Code: utility.CodeInvalidQuote, Detail: "No quote was found"}
+		}
+		return err
+	}
+
+	//Someone else's quote is reported like a missing one
+	if quote.Customer != customer {
+		return QuoteError{Code: utility.CodeInvalidQuote, Detail: "No quote was found"}
+	}
+
+	if quote.DebitAccount != transaction.DebitAccount || quote.CreditAccount != transaction.CreditAccount ||
+		quote.Amount != transaction.Amount || quote.From != transaction.Currency || quote.To != transaction.CreditCurrency {
+		return QuoteError{Code: utility.CodeInvalidQuote, Detail: "The quote does not match this transaction"}
+	}
+
+	if !time.Now().Before(quote.ExpiresAt) {
+		return QuoteError{Code: utility.CodeQuoteExpired, Detail: "The quoted rate has expired, ask for a new quote"}
+	}
+
+	transaction.Rate = quote.Rate
+	transaction.CreditAmount = quote.Converted
+	return nil
+}
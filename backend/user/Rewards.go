@@ -0,0 +1,183 @@
+package user
+
+import (
+	//Import standard library
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/middleware"
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+// Rewards turns transfers into reward points. Transfers of at least MinTransfer to other
+// customers earn the tier's points, which last Expiry and are each worth PointValue once
+// redeemed, in the currency of the account they are redeemed into
+type Rewards struct {
+	Expiry      time.Duration
+	MinTransfer model.Money
+	PointValue  model.Money
+}
+
+// earnPoints adds the points a transfer to another customer earned to the customer's rewards
+// wallet
+func (h Handler) earnPoints(customer string, transaction model.Transaction, tier model.Tier) error {
+	if transaction.Amount < h.Rewards.MinTransfer {
+		return nil
+	}
+
+	points := model.RewardPoints(transaction.Amount, tier.RewardPoints)
+	if points == 0 {
+		return nil
+	}
+
+	expiresAt := transaction.Date.Add(h.Rewards.Expiry)
+	_, err := h.RewardEntries.AddRewardEntry(model.RewardEntry{
+		Customer:  customer,
+		Kind:      model.RewardEarn,
+		Points:    points,
+		Reference: strconv.FormatInt(transaction.ID, 10),
+		At:        transaction.Date,
+		ExpiresAt: &expiresAt,
+	})
+	return err
+}
+
+// GetRewards sends the caller's rewards wallet
+func (h Handler) GetRewards(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	entries, err := h.RewardEntries.RewardEntries(claims.ID)
+	if err != nil {
+		utility.InternalError(w, "Error at: GetRewards -> Error querying reward entries", err)
+		return
+	}
+	wallet, _ := model.ReplayRewards(entries, time.Now())
+	wallet.Value = h.Rewards.PointValue * model.Money(wallet.Points)
+
+	//Package data
+	data, err := json.MarshalIndent(wallet, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: GetRewards -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetRewardHistory sends every change of the caller's reward points, expirations included,
+// newest first
+func (h Handler) GetRewardHistory(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	entries, err := h.RewardEntries.RewardEntries(claims.ID)
+	if err != nil {
+		utility.InternalError(w, "Error at: GetRewardHistory -> Error querying reward entries", err)
+		return
+	}
+	_, history := model.ReplayRewards(entries, time.Now())
+
+	//Package data
+	data, err := json.MarshalIndent(history, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: GetRewardHistory -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// RedeemPoints turns the number of points in the body into money on the account picked with
+// ?account=, the checking account by default
+func (h Handler) RedeemPoints(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: RedeemPoints -> Error reading request body", err)
+		return
+	}
+	r.Body.Close()
+
+	//Unmarshal request body
+	var points int
+	err = json.Unmarshal(data, &points)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+	if points <= 0 {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAmount, "Points to redeem must be positive")
+		return
+	}
+
+	account, ok := h.ownAccount(w, claims.ID, r.URL.Query().Get("account"))
+	if !ok {
+		return
+	}
+
+	//Take the points out of the wallet first, they are given back if the money cannot be paid
+	entry, err := h.RewardEntries.RedeemPoints(model.RewardEntry{
+		Customer:  claims.ID,
+		Points:    points,
+		Reference: account.ID,
+		At:        time.Now(),
+	})
+	if err != nil {
+		if _, ok := err.(store.InsufficientPointsError); ok {
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeInsufficientPoints, err.Error())
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: RedeemPoints -> Error redeeming points", err)
+		return
+	}
+
+	amount := h.Rewards.PointValue * model.Money(points)
+	balance, err := h.Ledger.Reward(account.ID, amount, fmt.Sprintf("Redeemed %d reward points", points))
+	if err != nil {
+		cancelErr := h.RewardEntries.CancelRedemption(entry.ID)
+		if cancelErr != nil {
+			fmt.Println("Error at: RedeemPoints -> Error cancelling redemption")
+			fmt.Println(cancelErr)
+		}
+
+		if _, ok := err.(ledger.InvalidAmountError); ok {
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAmount, err.Error())
+			return
+		}
+
+		if _, ok := err.(ledger.AccountClosedError); ok {
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountClosed, err.Error())
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: RedeemPoints -> Error posting reward to ledger", err)
+		return
+	}
+
+	//Send the redemption back to client
+	data, err = json.MarshalIndent(model.Redemption{Points: points, Amount: amount, Balance: balance}, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: RedeemPoints -> Error marshal redemption", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
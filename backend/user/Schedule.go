@@ -0,0 +1,205 @@
+package user
+
+import (
+	//Import standard library
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/ledger"
+	"gobank-server/middleware"
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
+)
+
+func (h Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	schedules, err := h.Schedules.Schedules(claims.ID)
+	if err != nil {
+		utility.InternalError(w, "Error at: ListSchedules -> Error querying schedules", err)
+		return
+	}
+
+	//Package data
+	data, err := json.MarshalIndent(schedules, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: ListSchedules -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// CreateSchedule stores a transfer the scheduler makes later, once or repeatedly. It is checked
+// now like a transfer would be, except for the balance which is only known on the day
+func (h Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: CreateSchedule -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body, the run state is never taken from the client
+	var request model.Schedule
+	err = json.Unmarshal(data, &request)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	err = ledger.CheckAmount(request.Amount)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAmount, err.Error())
+		return
+	}
+
+	schedule := model.Schedule{
+		Customer:    claims.ID,
+		Amount:      request.Amount,
+		Description: strings.TrimSpace(request.Description),
+		Frequency:   strings.ToLower(strings.TrimSpace(request.Frequency)),
+		Day:         request.Day,
+		State:       model.ScheduleActive,
+		CreatedAt:   time.Now(),
+	}
+	if !model.ValidFrequency(schedule.Frequency) {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidSchedule, "Frequency must be once, daily, weekly, monthly or last-business-day")
+		return
+	}
+	if len(schedule.Description) > 255 {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Description must have at most 255 characters")
+		return
+	}
+
+	//Runs start today unless a later day is asked for
+	today := model.Day(schedule.CreatedAt)
+	start := today
+	if !request.NextRun.IsZero() {
+		start = model.Day(request.NextRun)
+		if start.Before(today) {
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidSchedule, "The first run cannot be in the past")
+			return
+		}
+	}
+
+	//A monthly schedule runs on the start's day of the month unless told otherwise
+	if schedule.Frequency == model.Monthly {
+		if schedule.Day == 0 {
+			schedule.Day = start.Day()
+		}
+		if schedule.Day < 1 || schedule.Day > 31 {
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidSchedule, "Day must be between 1 and 31")
+			return
+		}
+	} else {
+		schedule.Day = 0
+	}
+	schedule.NextRun = schedule.FirstRun(start)
+
+	//The debit account must be one of the caller's, the checking account by default
+	debit, ok := h.ownAccount(w, claims.ID, request.DebitAccount)
+	if !ok {
+		return
+	}
+
+	err = model.ValidateAccountNumber(request.CreditAccount)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAccount, err.Error())
+		return
+	}
+
+	credit, err := h.Accounts.AccountByID(request.CreditAccount)
+	if err != nil {
+		if _, ok := err.(store.NotFoundError); ok {
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodeAccountNotFound, "No account was found")
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: CreateSchedule -> Error querying credit account", err)
+		return
+	}
+
+	if debit.ID == credit.ID {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeSelfTransfer, ledger.SelfTransferError{}.Error())
+		return
+	}
+	if debit.State != model.AccountOpen || credit.State != model.AccountOpen {
+		utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountClosed, "This account is closed")
+		return
+	}
+
+	//No rate can be quoted for a day to come
+	if debit.Currency != credit.Currency {
+		utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeInvalidCurrency, "Scheduled transfers can only be made between accounts in the same currency")
+		return
+	}
+
+	schedule.DebitAccount = debit.ID
+	schedule.CreditAccount = credit.ID
+	schedule.Currency = debit.Currency
+
+	schedule, err = h.Schedules.CreateSchedule(schedule)
+	if err != nil {
+		utility.InternalError(w, "Error at: CreateSchedule -> Error storing schedule", err)
+		return
+	}
+
+	//Send the schedule back to client
+	data, err = json.MarshalIndent(schedule, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: CreateSchedule -> Error marshal data", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+func (h Handler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: CancelSchedule -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body
+	var id int64
+	err = json.Unmarshal(data, &id)
+	if err != nil || id <= 0 {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	//Schedules of other customers are reported as not found
+	_, err = h.Schedules.CancelSchedule(id, claims.ID)
+	if err != nil {
+		if _, ok := err.(store.NotFoundError); ok {
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodeScheduleNotFound, "No active schedule was found")
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: CancelSchedule -> Error cancelling schedule", err)
+		return
+	}
+
+	//Send successful message to client
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Schedule cancelled successfully"))
+}
@@ -1,62 +1,26 @@
 package user
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"gobank/model"
-	"gobank/utility"
+	"gobank-server/ledger"
+	"gobank-server/middleware"
+	"gobank-server/model"
+	"gobank-server/store"
+	"gobank-server/utility"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
-func GetFullname(w http.ResponseWriter, r *http.Request) {
-	var serverMessage, clientMessage string
-
-	//Verify token
-	err := utility.VerifyToken(r.Header.Get("token"))
-	if err != nil {
-		if _, ok := err.(utility.ExpiredTokenError); ok {
-			clientMessage = "Your token has expired"
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(clientMessage))
-			return
-		}
-
-		if _, ok := err.(utility.TokenTamperedError); ok {
-			clientMessage = "We cannot verify who you are! Your token may have been tampered"
-			w.WriteHeader(http.StatusNotAcceptable)
-			w.Write([]byte(clientMessage))
-			return
-		}
-
-		/*Other errors*/
-		serverMessage = "Error at: FindAccount -> Error verifying token"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
-	}
-
+func (h Handler) GetFullname(w http.ResponseWriter, r *http.Request) {
 	//Reading request body
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
-		serverMessage = "Error at: FindAccount -> Error reading request body"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.InternalError(w, "Error at: FindAccount -> Error reading request body", err)
 		return
 	}
 
@@ -64,299 +28,367 @@ func GetFullname(w http.ResponseWriter, r *http.Request) {
 	var id string
 	err = json.Unmarshal(data, &id)
 	if err != nil {
-		serverMessage = "Error at: FindAccount -> Error unmarshal request body"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+	//A mistyped number is refused before it is looked up
+	err = model.ValidateAccountNumber(id)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAccount, err.Error())
 		return
 	}
 
-	//Querying into database to find the account's name
-	db := utility.GetDB()
-	sqlQuery := `
-		SELECT fullname FROM users
-		WHERE id = $1
-	`
-	var fullname string
-	err = db.QueryRow(sqlQuery, id).Scan(&fullname)
+	//Querying into database to find the account's owner
+	account, err := h.Accounts.AccountByID(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if _, ok := err.(store.NotFoundError); ok {
 			//Send message warning back to client
-			clientMessage = "No account was found"
-			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte(clientMessage))
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodeAccountNotFound, "No account was found")
 			return
 		}
 
 		/*Other errors*/
-		serverMessage = "Error at: FindAccount -> Error executing sql query to find account"
-		clientMessage = "Internal server error"
+		utility.InternalError(w, "Error at: FindAccount -> Error executing sql query to find account", err)
+		return
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	if account.State != model.AccountOpen {
+		utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountClosed, "This account is closed")
+		return
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+	user, err := h.Users.UserByID(account.Customer)
+	if err != nil {
+		utility.InternalError(w, "Error at: FindAccount -> Error executing sql query to find account's owner", err)
 		return
 	}
 
 	//If found, send data back to client
-	data, err = json.MarshalIndent(fullname, "", " ")
+	data, err = json.MarshalIndent(user.Fullname, "", " ")
 	if err != nil {
-		serverMessage = "Error at: FindAccount -> Error marshal data for sending to client"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(data)
+		utility.InternalError(w, "Error at: FindAccount -> Error marshal data for sending to client", err)
+		return
 	}
 
-	w.WriteHeader(http.StatusFound)
+	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 }
 
-func MakeTransaction(w http.ResponseWriter, r *http.Request) {
-	var serverMessage, clientMessage string
+func (h Handler) MakeTransaction(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Read request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: MakeTransaction -> Error reading request body", err)
+		return
+	}
+
+	//Unmarshal request body
+	var transaction model.Transaction
+	err = json.Unmarshal(data, &transaction)
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
 
-	//Verifying token
-	err := utility.VerifyToken(r.Header.Get("token"))
+	//Add transaction to database and move the money in one database transaction
+	_, err = h.Transfer(claims.ID, transaction)
 	if err != nil {
-		if _, ok := err.(utility.ExpiredTokenError); ok {
-			clientMessage = "Your token has been expired"
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(clientMessage))
+		switch e := err.(type) {
+		case model.InvalidAccountNumberError:
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAccount, err.Error())
 			return
-		}
-
-		if _, ok := err.(utility.TokenTamperedError); ok {
-			clientMessage = "Cannot verify who you are! Your token has been tamper"
-			w.WriteHeader(http.StatusNotAcceptable)
-			w.Write([]byte(clientMessage))
+		case ledger.InvalidAmountError:
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAmount, err.Error())
+			return
+		case ledger.SelfTransferError:
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeSelfTransfer, err.Error())
+			return
+		case ledger.AccountNotFoundError:
+			utility.WriteProblem(w, http.StatusNotFound, utility.CodeAccountNotFound, "No account was found")
+			return
+		case ledger.AccountClosedError:
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountClosed, err.Error())
+			return
+		case ledger.InsufficientFundsError:
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeInsufficientFunds, "Your balance is not enough to make this transaction")
+			return
+		case QuoteError:
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, e.Code, e.Detail)
+			return
+		case store.QuoteUsedError:
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeInvalidQuote, err.Error())
+			return
+		case store.CoolingOffLimitError:
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeCoolingOffLimit, err.Error())
+			return
+		case DailyLimitError:
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeDailyLimit, err.Error())
 			return
 		}
 
 		/*Other errors*/
-		serverMessage = "Error at: MakeTransaction -> Error verifying token"
-		clientMessage = "Internal server error"
+		utility.InternalError(w, "Error at: MakeTransaction -> Error posting transaction to ledger", err)
+		return
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	//Send successful message to client
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("Transaction success"))
+}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
+// Transfer moves money from one of the customer's accounts, the checking account when none is
+// given. MakeTransaction and the scheduler both go through it, so a scheduled transfer is checked
+// like one made from the CLI. Accounts of other customers are reported as
+// ledger.AccountNotFoundError and a refused quote as QuoteError
+func (h Handler) Transfer(customer string, transaction model.Transaction) (model.Transaction, error) {
+	//The client picks which of its own accounts pays. Never trust it for when or who receives the money
+	if transaction.DebitAccount == "" {
+		transaction.DebitAccount = customer
 	}
+	transaction.Date = time.Now()
+	transaction.Beneficiary = ""
 
-	//Extracting claims from token
-	claims, err := utility.ExtractingClaims(r.Header.Get("token"))
+	err := model.ValidateAccountNumber(transaction.DebitAccount)
 	if err != nil {
-		serverMessage = "Error at: MakeTransaction -> Error extracting claims"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+		return transaction, err
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
+	account, err := h.Accounts.AccountByID(transaction.DebitAccount)
+	if err != nil {
+		if _, ok := err.(store.NotFoundError); ok {
+			return transaction, ledger.AccountNotFoundError{Account: transaction.DebitAccount}
+		}
+		return transaction, err
+	}
+	if account.Customer != customer {
+		return transaction, ledger.AccountNotFoundError{Account: transaction.DebitAccount}
 	}
 
-	//Check if role is valid
-	if claims.Role == "admin" {
-		clientMessage = "You have no authority to perform this action"
-		w.WriteHeader(http.StatusUnauthorized)
-		w.Write([]byte(clientMessage))
-		return
+	err = model.ValidateAccountNumber(transaction.CreditAccount)
+	if err != nil {
+		return transaction, err
 	}
 
-	//Read request body
-	data, err := io.ReadAll(r.Body)
+	credit, err := h.Accounts.AccountByID(transaction.CreditAccount)
 	if err != nil {
-		serverMessage = "Error at: MakeTransaction -> Error reading request body"
-		clientMessage = "Internal server error"
+		if _, ok := err.(store.NotFoundError); ok {
+			return transaction, ledger.AccountNotFoundError{Account: transaction.CreditAccount}
+		}
+		return transaction, err
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	//Each side moves in its own currency, a conversion is only made at a quoted rate
+	transaction.Currency = account.Currency
+	transaction.CreditCurrency = credit.Currency
+	transaction.CreditAmount = transaction.Amount
+	transaction.Rate = 0
+	if account.Currency == credit.Currency {
+		transaction.QuoteID = ""
+	} else {
+		err = h.applyQuote(customer, &transaction)
+		if err != nil {
+			return transaction, err
+		}
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
+	//The customer's tier limits what they send to others each day, the ledger checks it.
+	//Moving money between their own accounts is not limited
+	perks, err := h.perks(customer)
+	if err != nil {
+		return transaction, err
+	}
+	transaction.DailyLimit = 0
+	if credit.Customer != customer {
+		transaction.DailyLimit = perks.Tier.TransferLimit
 	}
 
-	//Unmarshal request body
-	var transaction model.Transaction
-	err = json.Unmarshal(data, &transaction)
+	//Money sent to another customer's account is limited until its cooling-off ends. It starts
+	//when the account is first saved as a payee or paid, deleting the payee does not end it.
+	//The amount credited is counted, so every payment adds up in the account's own currency
+	coolingOff := false
+	if credit.Customer != customer {
+		coolingOff, err = h.Payees.ReserveCoolingOff(customer, transaction.CreditAccount, transaction.CreditAmount, h.CoolingOff.Limit, transaction.Date, transaction.Date.Add(h.CoolingOff.Period))
+		if err != nil {
+			return transaction, err
+		}
+	}
+
+	transaction, err = h.Ledger.Transfer(transaction)
 	if err != nil {
-		serverMessage = "Error at: MakeTransaction -> Error unmarshal request body"
-		clientMessage = "Internal server error"
+		if coolingOff {
+			releaseErr := h.Payees.ReleaseCoolingOff(customer, transaction.CreditAccount, transaction.CreditAmount)
+			if releaseErr != nil {
+				fmt.Println("Error at: Transfer -> Error releasing cooling-off amount")
+				fmt.Println(releaseErr)
+			}
+		}
+		return transaction, tierLimit(err, perks.Tier)
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
+	//A saved payee moves up the list
+	payee, err := h.Payees.PayeeByAccount(customer, transaction.CreditAccount)
+	if err == nil {
+		err = h.Payees.PayeeUsed(payee.ID, transaction.Date)
+	}
+	if _, ok := err.(store.NotFoundError); err != nil && !ok {
+		fmt.Println("Error at: Transfer -> Error updating payee's last use")
 		fmt.Println(err)
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
+	//Paying another customer earns the tier's cashback and reward points, unless the money only
+	//goes back to an account that just paid this one. The money has moved, a failed cashback,
+	//reward, EXP award or achievement must not fail the transfer
+	rewarded := credit.Customer != customer
+	if rewarded {
+		pingPong, err := h.Exp.PingPong(transaction)
+		if err != nil {
+			fmt.Println("Error at: Transfer -> Error checking for money sent back and forth")
+			fmt.Println(err)
+		}
+		rewarded = err == nil && !pingPong
 	}
 
-	//Add transaction to database
-	db := utility.GetDB()
-	sqlQuery := `
-		INSERT INTO transactions (date, debit, credit, beneficiary, amount, description)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
-	_, err = db.Exec(sqlQuery,
-		transaction.Date,
-		transaction.DebitAccount,
-		transaction.CreditAccount,
-		transaction.Beneficiary,
-		transaction.Amount,
-		transaction.Description,
-	)
+	cashback := perks.Tier.Cashback.Of(transaction.Amount)
+	if rewarded && cashback > 0 {
+		_, err = h.Ledger.Reward(transaction.DebitAccount, cashback, "Cashback")
+		if err != nil {
+			fmt.Println("Error at: Transfer -> Error paying cashback")
+			fmt.Println(err)
+		}
+	}
 
-	if err != nil {
-		serverMessage = "Error at: MakeTransaction -> Error adding new transaction to database"
-		clientMessage = "Internal server error"
+	if rewarded {
+		err = h.earnPoints(customer, transaction, perks.Tier)
+		if err != nil {
+			fmt.Println("Error at: Transfer -> Error earning reward points")
+			fmt.Println(err)
+		}
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
+	err = h.Exp.Transfer(customer, transaction)
+	if err != nil {
+		fmt.Println("Error at: Transfer -> Error awarding EXP")
 		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
 	}
 
-	//Update debit's balance
-	sqlQuery = `
-		UPDATE users
-		SET balance = balance - $1
-		WHERE id = $2
-	`
-	_, err = db.Exec(sqlQuery, transaction.Amount, transaction.DebitAccount)
+	_, err = h.Badges.Transfer(customer, transaction)
 	if err != nil {
-		serverMessage = "Error at: MakeTransaction -> Error update debit's balance"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
+		fmt.Println("Error at: Transfer -> Error unlocking achievements")
 		fmt.Println(err)
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+	return transaction, nil
+}
+
+func (h Handler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
+	//Read filters from request params
+	filter, err := parseTransactionFilter(r.URL.Query())
+	if err != nil {
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidFilter, err.Error())
 		return
 	}
 
-	//Update credit's balance
-	sqlQuery = `
-		UPDATE users
-		SET balance = balance + $1
-		WHERE id = $2
-	`
-	_, err = db.Exec(sqlQuery, transaction.Amount, transaction.CreditAccount)
+	//History of closed accounts can still be read
+	account, ok := h.ownAccount(w, claims.ID, r.URL.Query().Get("account"))
+	if !ok {
+		return
+	}
+
+	//Get transactions history from database, one more than asked to know if there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	transactions, err := h.Ledger.Transactions(account.ID, filter)
 	if err != nil {
-		serverMessage = "Error at: MakeTransaction -> Error update credit's balance"
-		clientMessage = "Internal server error"
+		utility.InternalError(w, "Error at: GetTransactions -> Error querying transactions", err)
+		return
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	page := model.TransactionPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		page.NextCursor = strconv.FormatInt(page.Transactions[limit-1].ID, 10)
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+	//Package data
+	data, err := json.MarshalIndent(page, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: GetTransactions -> Error marshal data", err)
 		return
 	}
 
-	//Send successful message to client
-	clientMessage = "Transaction success"
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte(clientMessage))
+	//Send data back to client
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
 }
 
-func GetTransactions(w http.ResponseWriter, r *http.Request) {
-	var serverMessage, clientMessage string
+func parseTransactionFilter(params url.Values) (ledger.TransactionFilter, error) {
+	filter := ledger.TransactionFilter{Limit: 20}
+	var err error
 
-	//Verify token
-	err := utility.VerifyToken(r.Header.Get("token"))
-	if err != nil {
-		if _, ok := err.(utility.ExpiredTokenError); ok {
-			clientMessage = "Your token has been tampered"
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(clientMessage))
-			return
+	if params.Get("limit") != "" {
+		filter.Limit, err = strconv.Atoi(params.Get("limit"))
+		if err != nil || filter.Limit <= 0 || filter.Limit > 100 {
+			return filter, errors.New("Limit must be a number between 1 and 100")
 		}
+	}
 
-		if _, ok := err.(utility.TokenTamperedError); ok {
-			clientMessage = "Cannot verify who you are! Your token may have been tampered"
-			w.WriteHeader(http.StatusNotAcceptable)
-			w.Write([]byte(clientMessage))
-			return
+	if params.Get("cursor") != "" {
+		filter.Cursor, err = strconv.ParseInt(params.Get("cursor"), 10, 64)
+		if err != nil || filter.Cursor <= 0 {
+			return filter, errors.New("Invalid cursor")
 		}
+	}
 
-		/*Other errors*/
-		serverMessage = "Error at: GetTransactions -> Error verifying token"
-		clientMessage = "Internal server error"
+	if params.Get("from") != "" {
+		filter.From, err = time.Parse("2006-01-02", params.Get("from"))
+		if err != nil {
+			return filter, errors.New("Date must be in the format YYYY-MM-DD")
+		}
+	}
 
-		//Send message to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	if params.Get("to") != "" {
+		filter.To, err = time.Parse("2006-01-02", params.Get("to"))
+		if err != nil {
+			return filter, errors.New("Date must be in the format YYYY-MM-DD")
+		}
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return filter, errors.New("Start date must be before end date")
 	}
 
-	//Extracting claims
-	claims, err := utility.ExtractingClaims(r.Header.Get("token"))
-	if err != nil {
-		serverMessage = "Error at: GetTransactions -> Error extracting claims"
-		clientMessage = "Internal server error"
+	filter.Direction = params.Get("direction")
+	if filter.Direction != "" && filter.Direction != "in" && filter.Direction != "out" {
+		return filter, errors.New("Direction must be either in or out")
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	if params.Get("min") != "" {
+		filter.MinAmount, err = model.ParseMoney(params.Get("min"))
+		if err != nil || filter.MinAmount < 0 {
+			return filter, errors.New("Invalid minimum amount")
+		}
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
+	if params.Get("max") != "" {
+		filter.MaxAmount, err = model.ParseMoney(params.Get("max"))
+		if err != nil || filter.MaxAmount < 0 {
+			return filter, errors.New("Invalid maximum amount")
+		}
 	}
 
-	//Check if role is valid
-	if claims.Role == "admin" {
-		clientMessage = "You have no authority to perform this action"
-		w.WriteHeader(http.StatusUnauthorized)
-		w.Write([]byte(clientMessage))
-		return
+	if filter.MaxAmount > 0 && filter.MinAmount > filter.MaxAmount {
+		return filter, errors.New("Minimum amount must not be greater than maximum amount")
 	}
 
-	//Get transactions history from database
-	//db := utility.GetDB()
-	//sqlQuery := `
-	//	SELECT TOP 20 * FROM transactions
+	filter.Counterparty = params.Get("counterparty")
+	filter.Search = params.Get("q")
 
-	//`
+	return filter, nil
 }
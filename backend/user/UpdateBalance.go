@@ -3,128 +3,154 @@ package user
 import (
 	"encoding/json"
 	"fmt"
-	"gobank/utility"
+	"gobank-server/ledger"
+	"gobank-server/middleware"
+	"gobank-server/model"
+	"gobank-server/utility"
 	"io"
 	"net/http"
+	"time"
 )
 
-func Topup(w http.ResponseWriter, r *http.Request) {
-	var serverMessage, clientMessage string
+func (h Handler) Topup(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
 
-	//verify token
-	err := utility.VerifyToken(r.Header.Get("token"))
+	//Reading request body
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		utility.InternalError(w, "Error at: Topup -> Error reading request body", err)
+		return
+	}
+	r.Body.Close()
+
+	//Unmarshal request body
+	var amount model.Money
+	err = json.Unmarshal(data, &amount)
 	if err != nil {
-		if _, ok := err.(utility.ExpiredTokenError); ok {
-			clientMessage = "Your token has expired"
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(clientMessage))
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
+
+	//The account is picked with ?account=, the checking account by default
+	account, ok := h.ownAccount(w, claims.ID, r.URL.Query().Get("account"))
+	if !ok {
+		return
+	}
+
+	//Post the topup to the ledger to update balance
+	balance, err := h.Ledger.Topup(account.ID, amount)
+	if err != nil {
+		if _, ok := err.(ledger.InvalidAmountError); ok {
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAmount, err.Error())
 			return
 		}
 
-		if _, ok := err.(utility.TokenTamperedError); ok {
-			clientMessage = "Cannot verify who you are! Your token may have been tampered"
-			w.WriteHeader(http.StatusNotAcceptable)
-			w.Write([]byte(clientMessage))
+		if _, ok := err.(ledger.AccountClosedError); ok {
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountClosed, err.Error())
 			return
 		}
 
 		/*Other errors*/
-		serverMessage = "Error at: Topup -> Error verifying token"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.InternalError(w, "Error at: Topup -> Error posting topup to ledger", err)
 		return
 	}
 
-	//Extracting claims
-	var claims utility.Claim
-	claims, err = utility.ExtractingClaims(r.Header.Get("token"))
+	//The money has moved, a failed EXP award or achievement must not fail the topup
+	now := time.Now()
+	err = h.Exp.Topup(claims.ID, account.ID, amount, now)
 	if err != nil {
-		serverMessage = "Error at: Topup -> Error extracting claims"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
+		fmt.Println("Error at: Topup -> Error awarding EXP")
 		fmt.Println(err)
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
-		return
+	_, err = h.Badges.Topup(claims.ID, now)
+	if err != nil {
+		fmt.Println("Error at: Topup -> Error unlocking achievements")
+		fmt.Println(err)
 	}
 
-	//Check if the requester has authority to perform this action
-	if claims.Role == "admin" {
-		clientMessage = "You have no authority to perfrom this action"
-		w.WriteHeader(http.StatusUnauthorized)
-		w.Write([]byte(clientMessage))
+	//Send the new balance back to client
+	data, err = json.MarshalIndent(balance, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: Topup -> Error marshal new balance", err)
 		return
 	}
 
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (h Handler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	//Get claims verified by the auth middleware
+	claims := middleware.Claims(r)
+
 	//Reading request body
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
-		serverMessage = "Error at: Topup -> Error reading request body"
-		clientMessage = "Internal server error"
-
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
-
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		utility.InternalError(w, "Error at: Withdraw -> Error reading request body", err)
 		return
 	}
 	r.Body.Close()
 
 	//Unmarshal request body
-	var amount float64
+	var amount model.Money
 	err = json.Unmarshal(data, &amount)
 	if err != nil {
-		serverMessage = "Error at: Topup -> Error unmarshal request body"
-		clientMessage = "Internal server error"
+		utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidRequest, "Request body is not valid")
+		return
+	}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+	//The account is picked with ?account=, the checking account by default
+	account, ok := h.ownAccount(w, claims.ID, r.URL.Query().Get("account"))
+	if !ok {
+		return
+	}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+	//The customer's tier limits what can be withdrawn each day, the ledger checks it while the
+	//balance is locked
+	perks, err := h.perks(claims.ID)
+	if err != nil {
+		utility.InternalError(w, "Error at: Withdraw -> Error querying user", err)
 		return
 	}
 
-	//Query to database to update balance
-	db := utility.GetDB()
-	sqlQuery := `
-		UPDATE users
-		SET balance = balance + $1
-		WHERE id = $2
-	`
-	_, err = db.Exec(sqlQuery, amount, claims.ID)
+	//Post the withdrawal to the ledger, it checks and locks the balance
+	balance, err := h.Ledger.Withdraw(account.ID, amount, perks.Tier.WithdrawLimit)
+	err = tierLimit(err, perks.Tier)
 	if err != nil {
-		serverMessage = "Error at: Topup -> Error executing sql query to update balance"
-		clientMessage = "Internal server error"
+		if _, ok := err.(ledger.InvalidAmountError); ok {
+			utility.WriteProblem(w, http.StatusBadRequest, utility.CodeInvalidAmount, err.Error())
+			return
+		}
 
-		//Log error to server
-		fmt.Println(serverMessage)
-		fmt.Println(err)
+		if _, ok := err.(ledger.AccountClosedError); ok {
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeAccountClosed, err.Error())
+			return
+		}
 
-		//Send message to client
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(clientMessage))
+		if _, ok := err.(ledger.InsufficientFundsError); ok {
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeInsufficientFunds, "Your balance is not enough to make this withdrawal")
+			return
+		}
+
+		if _, ok := err.(DailyLimitError); ok {
+			utility.WriteProblem(w, http.StatusUnprocessableEntity, utility.CodeDailyLimit, err.Error())
+			return
+		}
+
+		/*Other errors*/
+		utility.InternalError(w, "Error at: Withdraw -> Error posting withdrawal to ledger", err)
+		return
+	}
+
+	//Send the new balance back to client
+	data, err = json.MarshalIndent(balance, "", " ")
+	if err != nil {
+		utility.InternalError(w, "Error at: Withdraw -> Error marshal new balance", err)
 		return
 	}
 
-	//Send successful message to client
-	clientMessage = "Balance update successfully"
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(clientMessage))
+	w.Write(data)
 }
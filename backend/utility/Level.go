@@ -1,16 +1,147 @@
 package utility
 
-func CalculateLevel(exp int) int {
-	//Calculate user's level based on exp
-	var min, max, base, level int = 0, 0, 100, 0
-	for i := 0; ; i++ {
-		min = base * (i*i - i)
-		max = base * (i*i + i)
-		if min <= exp && exp < max {
-			level = i
-			break
-		}
+import (
+	//Import standard library
+	"fmt"
+	"math"
+	"sort"
+
+	//Import user's defined package
+	"gobank-server/config"
+)
+
+// LevelCurve tells how much EXP each level takes. Levels go from 1, which starts at 0 EXP, to
+// MaxLevel. Negative EXP counts as 0 and EXP past the last level stays at MaxLevel
+type LevelCurve interface {
+	// ExpForLevel is the EXP the level starts at, levels out of range are clamped
+	ExpForLevel(level int) int
+	// LevelForExp is the level exp is at, the highest one starting at or below it
+	LevelForExp(exp int) int
+	// ProgressToNext returns the level of exp, the EXP that level starts at and the EXP the
+	// next one starts at. next is 0 at the max level
+	ProgressToNext(exp int) (level, start, next int)
+	MaxLevel() int
+}
+
+// DefaultLevelCurve is the curve levels always followed: level n starts at 100*(n*n-n) EXP
+var DefaultLevelCurve LevelCurve = QuadraticCurve{Base: 100, Max: 100}
+
+// progressToNext is ProgressToNext worked out from the curve's other methods, the curves of
+// this package share it
+func progressToNext(curve LevelCurve, exp int) (level, start, next int) {
+	level = curve.LevelForExp(exp)
+	start = curve.ExpForLevel(level)
+	if level < curve.MaxLevel() {
+		next = curve.ExpForLevel(level + 1)
+	}
+
+	return level, start, next
+}
+
+// NewLevelCurve builds the curve the config asks for
+func NewLevelCurve(cfg config.Level) (LevelCurve, error) {
+	switch cfg.Curve {
+	case "quadratic":
+		return QuadraticCurve{Base: cfg.Base, Max: cfg.MaxLevel}, nil
+	case "linear":
+		return LinearCurve{Step: cfg.Base, Max: cfg.MaxLevel}, nil
+	case "table":
+		return TableCurve{Thresholds: cfg.Thresholds}, nil
+	}
+
+	return nil, fmt.Errorf("unknown level curve %q", cfg.Curve)
+}
+
+// QuadraticCurve makes level n last 2*Base*n EXP, it starts at Base*(n*n-n)
+type QuadraticCurve struct {
+	Base int
+	Max  int
+}
+
+func (c QuadraticCurve) ExpForLevel(level int) int {
+	level = clampLevel(level, c.Max)
+	return c.Base * (level*level - level)
+}
+
+func (c QuadraticCurve) LevelForExp(exp int) int {
+	if exp <= 0 {
+		return 1
+	}
+
+	//Solve n*n-n = exp/Base, then make up for the float rounding
+	level := int((1 + math.Sqrt(1+4*float64(exp)/float64(c.Base))) / 2)
+	level = clampLevel(level, c.Max)
+	for level < c.Max && c.ExpForLevel(level+1) <= exp {
+		level++
+	}
+	for level > 1 && c.ExpForLevel(level) > exp {
+		level--
 	}
 
 	return level
 }
+
+func (c QuadraticCurve) ProgressToNext(exp int) (level, start, next int) {
+	return progressToNext(c, exp)
+}
+
+func (c QuadraticCurve) MaxLevel() int {
+	return c.Max
+}
+
+// LinearCurve makes every level take Step EXP
+type LinearCurve struct {
+	Step int
+	Max  int
+}
+
+func (c LinearCurve) ExpForLevel(level int) int {
+	return c.Step * (clampLevel(level, c.Max) - 1)
+}
+
+func (c LinearCurve) LevelForExp(exp int) int {
+	if exp <= 0 {
+		return 1
+	}
+
+	return clampLevel(exp/c.Step+1, c.Max)
+}
+
+func (c LinearCurve) ProgressToNext(exp int) (level, start, next int) {
+	return progressToNext(c, exp)
+}
+
+func (c LinearCurve) MaxLevel() int {
+	return c.Max
+}
+
+// TableCurve lists the EXP each level starts at, from level 1 at 0 EXP. The last entry is the
+// max level
+type TableCurve struct {
+	Thresholds []int
+}
+
+func (c TableCurve) ExpForLevel(level int) int {
+	return c.Thresholds[clampLevel(level, len(c.Thresholds))-1]
+}
+
+func (c TableCurve) LevelForExp(exp int) int {
+	//The first level starting above exp is the one after exp's level
+	next := sort.Search(len(c.Thresholds), func(i int) bool {
+		return c.Thresholds[i] > exp
+	})
+
+	return max(next, 1)
+}
+
+func (c TableCurve) ProgressToNext(exp int) (level, start, next int) {
+	return progressToNext(c, exp)
+}
+
+func (c TableCurve) MaxLevel() int {
+	return len(c.Thresholds)
+}
+
+func clampLevel(level, maxLevel int) int {
+	return min(max(level, 1), maxLevel)
+}
@@ -0,0 +1,65 @@
+package utility
+
+import (
+	//Import standard library
+	"testing"
+)
+
+// loopLevel is how levels used to be found, one level at a time
+func loopLevel(exp int) int {
+	for i := 1; ; i++ {
+		if 100*(i*i-i) <= exp && exp < 100*(i*i+i) {
+			return i
+		}
+	}
+}
+
+func TestQuadraticCurve(t *testing.T) {
+	curve := DefaultLevelCurve
+
+	//Same levels as before, down to the EXP each level starts at
+	for exp := 0; exp < 1000000; exp += 7 {
+		if got, want := curve.LevelForExp(exp), loopLevel(exp); got != want {
+			t.Fatalf("LevelForExp(%d) = %d, want %d", exp, got, want)
+		}
+	}
+	for level := 1; level <= 100; level++ {
+		start := curve.ExpForLevel(level)
+		if curve.LevelForExp(start) != level || (level > 1 && curve.LevelForExp(start-1) != level-1) {
+			t.Fatalf("level %d does not start at %d EXP", level, start)
+		}
+	}
+
+	//Negative EXP no longer loops forever, and levels stop at the max level
+	if got := curve.LevelForExp(-50); got != 1 {
+		t.Errorf("LevelForExp(-50) = %d, want 1", got)
+	}
+	if got := curve.LevelForExp(1 << 40); got != 100 {
+		t.Errorf("LevelForExp(2^40) = %d, want 100", got)
+	}
+}
+
+func TestProgressToNext(t *testing.T) {
+	tests := []struct {
+		name               string
+		curve              LevelCurve
+		exp                int
+		level, start, next int
+	}{
+		{"quadratic", DefaultLevelCurve, 250, 2, 200, 600},
+		{"quadratic max", QuadraticCurve{Base: 100, Max: 3}, 5000, 3, 600, 0},
+		{"linear", LinearCurve{Step: 500, Max: 10}, 1200, 3, 1000, 1500},
+		{"linear negative", LinearCurve{Step: 500, Max: 10}, -1, 1, 0, 500},
+		{"table", TableCurve{Thresholds: []int{0, 50, 300, 1000}}, 300, 3, 300, 1000},
+		{"table max", TableCurve{Thresholds: []int{0, 50, 300, 1000}}, 99999, 4, 1000, 0},
+		{"table negative", TableCurve{Thresholds: []int{0, 50, 300, 1000}}, -10, 1, 0, 50},
+	}
+
+	for _, test := range tests {
+		level, start, next := test.curve.ProgressToNext(test.exp)
+		if level != test.level || start != test.start || next != test.next {
+			t.Errorf("%s: ProgressToNext(%d) = %d, %d, %d; want %d, %d, %d",
+				test.name, test.exp, level, start, next, test.level, test.start, test.next)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package utility
+
+import (
+	//Import standard library
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	//Import 3rd party package
+	"golang.org/x/crypto/argon2"
+)
+
+// Passwords are hashed with argon2id and stored in the PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>
+//
+// The parameters travel with the hash, so they can be raised later and old hashes still verify.
+// Rows created before argon2id hold unsalted sha256 hex; they are upgraded on next login
+const (
+	argonTime    = 1
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+type argonParams struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		argonMemory,
+		argonTime,
+		argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// ComparePassword checks the password against the stored hash in constant time. The second
+// result tells the caller to store a fresh hash, because the stored one is legacy sha256 or uses
+// weaker parameters than the current ones
+func ComparePassword(hash, password string) (bool, bool, error) {
+	//Legacy unsalted sha256
+	if len(hash) == sha256.Size*2 && !strings.HasPrefix(hash, "$") {
+		sum := sha256.Sum256([]byte(password))
+		match := subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(hash)) == 1
+		return match, true, nil
+	}
+
+	params, salt, key, err := decodeHash(hash)
+	if err != nil {
+		return false, false, err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+	match := subtle.ConstantTimeCompare(key, otherKey) == 1
+	outdated := params.memory != argonMemory || params.time != argonTime || params.threads != argonThreads || len(key) != argonKeyLen
+	return match, outdated, nil
+}
+
+func decodeHash(hash string) (argonParams, []byte, []byte, error) {
+	var params argonParams
+
+	//"", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("unsupported password hash format")
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads)
+	if err != nil {
+		return params, nil, nil, errors.New("malformed argon2 parameters")
+	}
+	//argon2 panics on no passes or no threads, and no memory is never a hash we wrote
+	if params.time < 1 || params.threads < 1 || params.memory == 0 {
+		return params, nil, nil, errors.New("invalid argon2 parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.Strict().DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errors.New("malformed argon2 salt")
+	}
+
+	key, err := base64.RawStdEncoding.Strict().DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("malformed argon2 hash")
+	}
+
+	return params, salt, key, nil
+}
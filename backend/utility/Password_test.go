@@ -0,0 +1,73 @@
+package utility
+
+import (
+	//Import standard library
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		t.Fatalf("hash = %q, want PHC argon2id", hash)
+	}
+
+	other, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == other {
+		t.Fatal("two hashes of the same password are equal, salt is missing")
+	}
+
+	match, needsRehash, err := ComparePassword(hash, "correct horse")
+	if err != nil || !match || needsRehash {
+		t.Fatalf("ComparePassword = %v, %v, %v", match, needsRehash, err)
+	}
+
+	match, _, err = ComparePassword(hash, "wrong horse")
+	if err != nil || match {
+		t.Fatalf("wrong password matched: %v, %v", match, err)
+	}
+}
+
+func TestComparePasswordLegacy(t *testing.T) {
+	//sha256("password")
+	legacy := "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"
+
+	match, needsRehash, err := ComparePassword(legacy, "password")
+	if err != nil || !match || !needsRehash {
+		t.Fatalf("ComparePassword = %v, %v, %v; want a match that needs rehash", match, needsRehash, err)
+	}
+
+	match, _, err = ComparePassword(legacy, "Password")
+	if err != nil || match {
+		t.Fatalf("wrong password matched: %v, %v", match, err)
+	}
+}
+
+func TestComparePasswordBadParameters(t *testing.T) {
+	//Salt and key of a real hash, so only the parameters are wrong
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hash, "$")
+	salt, key := parts[4], parts[5]
+
+	hashes := map[string]string{
+		"no passes":  "$argon2id$v=19$m=65536,t=0,p=4$" + salt + "$" + key,
+		"no threads": "$argon2id$v=19$m=65536,t=1,p=0$" + salt + "$" + key,
+		"no memory":  "$argon2id$v=19$m=0,t=1,p=4$" + salt + "$" + key,
+		"no key":     "$argon2id$v=19$m=65536,t=1,p=4$" + salt + "$",
+	}
+	for name, hash := range hashes {
+		match, _, err := ComparePassword(hash, "correct horse")
+		if err == nil || match {
+			t.Errorf("%s: ComparePassword = %v, %v; want an error", name, match, err)
+		}
+	}
+}
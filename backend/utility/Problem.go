@@ -0,0 +1,82 @@
+package utility
+
+import (
+	//Import standard library
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	//Import user's defined package
+	"gobank-server/model"
+)
+
+// Error codes sent in problem responses. Clients switch on them, so never change an existing one
+const (
+	CodeInternal           = "internal_error"
+	CodeInvalidRequest     = "invalid_request"
+	CodeTokenExpired       = "token_expired"
+	CodeTokenInvalid       = "token_invalid"
+	CodeForbidden          = "forbidden"
+	CodeInvalidRole        = "invalid_role"
+	CodeInvalidCredential  = "invalid_credentials"
+	CodeEmailTaken         = "email_taken"
+	CodeSamePassword       = "same_password"
+	CodeSessionExpired     = "session_expired"
+	CodeSessionNotFound    = "session_not_found"
+	CodeAccountNotFound    = "account_not_found"
+	CodeInvalidAccount     = "invalid_account_number"
+	CodeAccountClosed      = "account_closed"
+	CodeAccountNotEmpty    = "account_not_empty"
+	CodeLastAccount        = "last_account"
+	CodeInvalidType        = "invalid_account_type"
+	CodeInvalidAmount      = "invalid_amount"
+	CodeSelfTransfer       = "self_transfer"
+	CodeInsufficientFunds  = "insufficient_funds"
+	CodeInvalidFilter      = "invalid_filter"
+	CodeInvalidCurrency    = "invalid_currency"
+	CodeQuoteRequired      = "quote_required"
+	CodeQuoteExpired       = "quote_expired"
+	CodeInvalidQuote       = "invalid_quote"
+	CodeRateUnavailable    = "rate_unavailable"
+	CodeInvalidSchedule    = "invalid_schedule"
+	CodeScheduleNotFound   = "schedule_not_found"
+	CodePayeeExists        = "payee_exists"
+	CodePayeeNotFound      = "payee_not_found"
+	CodeCoolingOffLimit    = "cooling_off_limit"
+	CodeDailyLimit         = "daily_limit_exceeded"
+	CodeInsufficientPoints = "insufficient_points"
+
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+)
+
+// WriteProblem sends an application/problem+json response
+func WriteProblem(w http.ResponseWriter, status int, code, detail string) {
+	problem := model.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+
+	data, err := json.MarshalIndent(problem, "", " ")
+	if err != nil {
+		fmt.Println("Error at: WriteProblem -> Error marshal problem")
+		fmt.Println(err)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// InternalError logs the real error on the server and sends the client a generic 500 problem
+func InternalError(w http.ResponseWriter, serverMessage string, err error) {
+	//Log error to server
+	fmt.Println(serverMessage)
+	fmt.Println(err)
+
+	//Send message to client
+	WriteProblem(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+}
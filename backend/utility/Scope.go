@@ -0,0 +1,26 @@
+package utility
+
+// Scopes granted to each role. They are written into the access token when it is issued, and
+// checked per route by middleware.Protect
+var roleScopes = map[string][]string{
+	"user": {
+		"profile:read",
+		"profile:write",
+		"sessions:manage",
+		"accounts:read",
+		"accounts:write",
+		"balance:write",
+		"transfers:write",
+		"transactions:read",
+	},
+	"admin": {
+		"profile:read",
+		"profile:write",
+		"sessions:manage",
+		"users:admin",
+	},
+}
+
+func ScopesForRole(role string) []string {
+	return roleScopes[role]
+}
@@ -0,0 +1,171 @@
+package utility
+
+import (
+	//Import standard library
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/model"
+	"gobank-server/store"
+)
+
+// A session is created on every login. It holds the refresh token (only its hash is stored)
+// that the client trades for a new access token, and a new refresh token, when the old one
+// expires. Refresh tokens have the form "<session id>.<secret>"
+var refreshTokenTTL = 30 * 24 * time.Hour
+
+type InvalidRefreshTokenError struct{}
+
+func (e InvalidRefreshTokenError) Error() string {
+	return "Invalid or expired refresh token"
+}
+
+type SessionNotFoundError struct{}
+
+func (e SessionNotFoundError) Error() string {
+	return "Session not found"
+}
+
+// CreateSession starts a new session and returns its access and refresh tokens
+func CreateSession(sessions store.SessionStore, account, role, device string) (string, string, error) {
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	err = sessions.CreateSession(store.Session{
+		ID:          sessionID,
+		Account:     account,
+		Role:        role,
+		RefreshHash: hashSecret(secret),
+		Device:      device,
+		CreatedAt:   now,
+		LastUsedAt:  now,
+		ExpiresAt:   now.Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := GenerateToken(account, role, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, sessionID + "." + secret, nil
+}
+
+// RotateSession trades a refresh token for a new access token and a new refresh token. The old
+// refresh token stops working; presenting it again means it was copied, so the whole session is
+// revoked
+func RotateSession(sessions store.SessionStore, refreshToken string) (Claim, string, string, error) {
+	sessionID, secret, found := strings.Cut(refreshToken, ".")
+	if !found || sessionID == "" || secret == "" {
+		return Claim{}, "", "", InvalidRefreshTokenError{}
+	}
+
+	newSecret, err := randomHex(32)
+	if err != nil {
+		return Claim{}, "", "", err
+	}
+
+	var (
+		claims = Claim{SessionID: sessionID}
+		reused bool
+	)
+	err = sessions.UpdateSession(sessionID, func(session *store.Session) error {
+		now := time.Now()
+		if !session.Active(now) {
+			return InvalidRefreshTokenError{}
+		}
+
+		if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(session.RefreshHash)) != 1 {
+			//Reused refresh token, kill the session
+			session.RevokedAt = now
+			reused = true
+			return nil
+		}
+
+		//Rotate the refresh token
+		session.RefreshHash = hashSecret(newSecret)
+		session.LastUsedAt = now
+		claims.ID, claims.Role = session.Account, session.Role
+		return nil
+	})
+	if _, ok := err.(store.NotFoundError); ok || reused {
+		return Claim{}, "", "", InvalidRefreshTokenError{}
+	}
+	if err != nil {
+		return Claim{}, "", "", err
+	}
+
+	accessToken, err := GenerateToken(claims.ID, claims.Role, sessionID)
+	if err != nil {
+		return Claim{}, "", "", err
+	}
+
+	return claims, accessToken, sessionID + "." + newSecret, nil
+}
+
+// RevokeSession ends one of the account's sessions
+func RevokeSession(sessions store.SessionStore, account, role, sessionID string) error {
+	err := sessions.RevokeSession(sessionID, account, role, time.Now())
+	if _, ok := err.(store.NotFoundError); ok {
+		return SessionNotFoundError{}
+	}
+
+	return err
+}
+
+// RevokeToken puts the access token on the deny list until it would have expired anyway
+func RevokeToken(sessions store.SessionStore, claims Claim) error {
+	return sessions.RevokeToken(claims.JTI, time.Unix(claims.ExpiredAt, 0))
+}
+
+// ListSessions returns the account's active sessions, most recently used first
+func ListSessions(sessions store.SessionStore, account, role, currentSessionID string) ([]model.Session, error) {
+	active, err := sessions.ListSessions(account, role, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	list := []model.Session{}
+	for _, session := range active {
+		list = append(list, model.Session{
+			ID:         session.ID,
+			Device:     session.Device,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	return list, nil
+}
+
+func randomHex(size int) (string, error) {
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
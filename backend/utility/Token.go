@@ -2,40 +2,163 @@ package utility
 
 import (
 	//Import standard library
+	"bytes"
+	"crypto/hmac"
 	"crypto/sha256"
-	"encoding/hex"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
+
+	//Import user's defined package
+	"gobank-server/config"
+	"gobank-server/store"
+)
+
+// Tokens are JWTs (RFC 7519) signed with HMAC-SHA256. Every key has an ID that is written in
+// the token's "kid" header, so a new key can be made active while tokens signed with the old
+// one stay valid until they expire
+var (
+	signingKeys = map[string][]byte{}
+	activeKeyID string
+
+	tokenIssuer   = "gobank"
+	tokenAudience = "gobank-cli"
+
+	//Access tokens are short-lived, clients get new ones with their refresh token
+	accessTokenTTL = 15 * time.Minute
 )
 
-var secretKey []byte = []byte("8DF72555912857A43FDAF8135B22A")
+const minKeyLength = 32
+
+// Tokens issued by a server whose clock is slightly ahead are still accepted
+const clockSkew = 30 * time.Second
 
 type Claim struct {
-	ID        string    `json:"id"`
-	Role      string    `json:"role"`
-	IssueAt   time.Time `json:"issueAt"`
-	ExpiredAt time.Time `json:"expiredAt"`
+	ID        string `json:"sub"`
+	Role      string `json:"role"`
+	Issuer    string `json:"iss"`
+	Audience  string `json:"aud"`
+	JTI       string `json:"jti"`
+	SessionID string `json:"sid"`
+	Scope     string `json:"scope"`
+	IssueAt   int64  `json:"iat"`
+	ExpiredAt int64  `json:"exp"`
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// SetSigningKeys replaces the key set. activeID is the key used to sign new tokens, every key
+// in keys is accepted when verifying
+func SetSigningKeys(keys map[string][]byte, activeID string) error {
+	if len(keys) == 0 {
+		return errors.New("no signing key configured")
+	}
+
+	for id, secret := range keys {
+		if id == "" {
+			return errors.New("signing key must have an ID")
+		}
+		if len(secret) < minKeyLength {
+			return fmt.Errorf("signing key %s must be at least %d bytes long", id, minKeyLength)
+		}
+	}
+
+	if _, ok := keys[activeID]; !ok {
+		return fmt.Errorf("active signing key %s is not in the key set", activeID)
+	}
+
+	signingKeys = keys
+	activeKeyID = activeID
+	return nil
+}
+
+// LoadSigningKeys sets the key set and token lifetimes from the config. Keys are written as:
+//
+//	"2024-01:first-secret,2024-06:second-secret"
+//
+// When no active key is given the last key in the list signs new tokens
+func LoadSigningKeys(cfg config.Token) error {
+	keys := map[string][]byte{}
+	var lastID string
+	for _, pair := range strings.Split(cfg.Keys, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		id, secret, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			return fmt.Errorf("signing key %q must be in the form id:secret", id)
+		}
+		keys[id] = []byte(secret)
+		lastID = id
+	}
+
+	activeID := cfg.ActiveKey
+	if activeID == "" {
+		activeID = lastID
+	}
+
+	err := SetSigningKeys(keys, activeID)
+	if err != nil {
+		return err
+	}
+
+	accessTokenTTL = time.Duration(cfg.AccessTTL)
+	refreshTokenTTL = time.Duration(cfg.RefreshTTL)
+	return nil
 }
 
-func GenerateToken(id, role string) (string, error) {
-	//Generate claim
+func GenerateToken(id, role, sessionID string) (string, error) {
+	if activeKeyID == "" {
+		return "", errors.New("no signing key configured")
+	}
+
+	//Generate a unique token ID
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	//Generate header and claim
+	header := tokenHeader{Algorithm: "HS256", Type: "JWT", KeyID: activeKeyID}
+	now := time.Now()
 	claim := Claim{
 		ID:        id,
 		Role:      role,
-		IssueAt:   time.Now(),
-		ExpiredAt: time.Now().Add(24 * time.Hour),
+		Issuer:    tokenIssuer,
+		Audience:  tokenAudience,
+		JTI:       jti,
+		SessionID: sessionID,
+		Scope:     strings.Join(ScopesForRole(role), " "),
+		IssueAt:   now.Unix(),
+		ExpiredAt: now.Add(accessTokenTTL).Unix(),
 	}
 
-	data, err := json.MarshalIndent(claim, "", " ")
+	headerData, err := json.Marshal(header)
 	if err != nil {
 		return "", err
 	}
+	claimData, err := json.Marshal(claim)
+	if err != nil {
+		return "", err
+	}
+
+	//Sign header and claims with the active key
+	signingInput := encodeSegment(headerData) + "." + encodeSegment(claimData)
+	signature := sign(signingKeys[activeKeyID], signingInput)
+	return signingInput + "." + encodeSegment(signature), nil
+}
 
-	//Genrate signature based on claims and secret key
-	sum := sha256.Sum256(append(data, secretKey...))
-	token := strings.Join([]string{hex.EncodeToString(data), hex.EncodeToString(sum[:])}, ".")
-	return token, nil
+// Scopes splits the space-separated "scope" claim
+func (c Claim) Scopes() []string {
+	return strings.Fields(c.Scope)
 }
 
 type ExpiredTokenError struct{}
@@ -44,58 +167,127 @@ func (e ExpiredTokenError) Error() string {
 	return "Token has expired"
 }
 
-type TokenTamperedError struct{}
+// TokenTamperedError is returned for every token that cannot be trusted: bad signature, unknown
+// key, wrong issuer or audience, or a token that is not a well-formed JWT at all
+type TokenTamperedError struct {
+	Reason string
+}
 
 func (e TokenTamperedError) Error() string {
-	return "Error hashing"
+	return "Invalid token: " + e.Reason
 }
 
-func VerifyToken(token string) error {
-	//Spliting the claims and signature
-	tokenInfo := strings.Split(token, ".")
+// ParseToken verifies the token and returns its claims
+func ParseToken(token string) (Claim, error) {
+	//A JWT has exactly three segments
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return Claim{}, TokenTamperedError{Reason: "malformed token"}
+	}
 
-	//Decode the claims
-	claimsData, err := hex.DecodeString(tokenInfo[0])
+	//Decode and check the header
+	var header tokenHeader
+	err := decodeSegment(segments[0], &header)
 	if err != nil {
-		return err
+		return Claim{}, TokenTamperedError{Reason: "malformed header"}
+	}
+
+	if header.Algorithm != "HS256" || (header.Type != "" && header.Type != "JWT") {
+		return Claim{}, TokenTamperedError{Reason: "unsupported algorithm"}
+	}
+
+	secret, ok := signingKeys[header.KeyID]
+	if !ok {
+		return Claim{}, TokenTamperedError{Reason: "unknown signing key"}
+	}
+
+	//Compare the signature in constant time
+	signature, err := base64.RawURLEncoding.Strict().DecodeString(segments[2])
+	if err != nil {
+		return Claim{}, TokenTamperedError{Reason: "malformed signature"}
 	}
 
-	//Hash the claims with secret key and compare to the signature
-	sum := sha256.Sum256(append(claimsData, secretKey...))
-	if hex.EncodeToString(sum[:]) != tokenInfo[1] {
-		return TokenTamperedError{}
+	if !hmac.Equal(signature, sign(secret, segments[0]+"."+segments[1])) {
+		return Claim{}, TokenTamperedError{Reason: "signature mismatch"}
 	}
 
-	//Check if the token is expired or not
+	//Decode and check the claims
 	var claims Claim
-	err = json.Unmarshal(claimsData, &claims)
+	err = decodeSegment(segments[1], &claims)
+	if err != nil {
+		return Claim{}, TokenTamperedError{Reason: "malformed claims"}
+	}
+
+	if claims.Issuer != tokenIssuer || claims.Audience != tokenAudience {
+		return Claim{}, TokenTamperedError{Reason: "wrong issuer or audience"}
+	}
+
+	if claims.ID == "" || claims.Role == "" || claims.JTI == "" || claims.SessionID == "" {
+		return Claim{}, TokenTamperedError{Reason: "missing claims"}
+	}
+
+	now := time.Now()
+	if time.Unix(claims.IssueAt, 0).After(now.Add(clockSkew)) {
+		return Claim{}, TokenTamperedError{Reason: "token issued in the future"}
+	}
+
+	if now.After(time.Unix(claims.ExpiredAt, 0)) {
+		return Claim{}, ExpiredTokenError{}
+	}
+
+	return claims, nil
+}
+
+// VerifyToken checks the token and makes sure neither it nor its session has been revoked
+func VerifyToken(sessions store.SessionStore, token string) error {
+	claims, err := ParseToken(token)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := sessions.IsTokenRevoked(claims.SessionID, claims.JTI)
 	if err != nil {
 		return err
 	}
 
-	if time.Now().After(claims.ExpiredAt) {
-		return ExpiredTokenError{}
+	if revoked {
+		return TokenTamperedError{Reason: "token has been revoked"}
 	}
 
 	return nil
 }
 
 func ExtractingClaims(token string) (Claim, error) {
-	//Spliting the claims and signature
-	tokenInfo := strings.Split(token, ".")
+	return ParseToken(token)
+}
+
+func sign(secret []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
 
-	//Decode the claims
-	claimsData, err := hex.DecodeString(tokenInfo[0])
+// decodeSegment rejects padding, unknown fields and trailing data
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.Strict().DecodeString(segment)
 	if err != nil {
-		return Claim{}, err
+		return err
 	}
 
-	//Unmarshal the claims
-	var claims Claim
-	err = json.Unmarshal(claimsData, &claims)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(v)
 	if err != nil {
-		return Claim{}, err
+		return err
 	}
 
-	return claims, nil
+	if decoder.More() {
+		return errors.New("unexpected data after JSON object")
+	}
+
+	return nil
 }
@@ -0,0 +1,97 @@
+package utility
+
+import (
+	//Import standard library
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setTestKeys(t *testing.T, active string) {
+	t.Helper()
+
+	err := SetSigningKeys(map[string][]byte{
+		"old": []byte("old-signing-key-that-is-long-enough"),
+		"new": []byte("new-signing-key-that-is-long-enough"),
+	}, active)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTokenRoundTrip(t *testing.T) {
+	setTestKeys(t, "old")
+	oldToken, err := GenerateToken("100000000", "user", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Rotating the active key keeps tokens signed with the old one valid
+	setTestKeys(t, "new")
+	claims, err := ParseToken(oldToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ID != "100000000" || claims.Role != "user" || claims.SessionID != "session" {
+		t.Fatalf("claims = %+v", claims)
+	}
+	if !strings.Contains(claims.Scope, "transfers:write") {
+		t.Fatalf("scope = %q, want the user's scopes", claims.Scope)
+	}
+}
+
+func TestParseTokenRejectsTampering(t *testing.T) {
+	setTestKeys(t, "new")
+	token, err := GenerateToken("100000000", "user", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments := strings.Split(token, ".")
+
+	//Claims of another user with the original signature
+	forged, err := GenerateToken("100000001", "user", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	forgedClaims := strings.Split(forged, ".")[1]
+
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT","kid":"new"}`))
+
+	tests := map[string]string{
+		"malformed":      "abc",
+		"swapped claims": segments[0] + "." + forgedClaims + "." + segments[2],
+		"alg none":       noneHeader + "." + segments[1] + ".",
+		"bad signature":  segments[0] + "." + segments[1] + ".AAAA",
+	}
+	for name, tampered := range tests {
+		_, err := ParseToken(tampered)
+		if _, ok := err.(TokenTamperedError); !ok {
+			t.Errorf("%s: err = %v, want TokenTamperedError", name, err)
+		}
+	}
+}
+
+func TestParseTokenExpired(t *testing.T) {
+	setTestKeys(t, "new")
+	previous := accessTokenTTL
+	accessTokenTTL = -time.Minute
+	defer func() { accessTokenTTL = previous }()
+
+	token, err := GenerateToken("100000000", "user", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseToken(token)
+	if _, ok := err.(ExpiredTokenError); !ok {
+		t.Fatalf("err = %v, want ExpiredTokenError", err)
+	}
+}
+
+func TestSetSigningKeysRejectsShortKeys(t *testing.T) {
+	err := SetSigningKeys(map[string][]byte{"short": []byte("secret")}, "short")
+	if err == nil {
+		t.Fatal("short key was accepted")
+	}
+}
@@ -0,0 +1,170 @@
+// Package e2e drives the gobank CLI against an in-process server backed by the in-memory stores,
+// so whole user journeys can be tested without Postgres or a terminal
+package e2e
+
+import (
+	//Import standard library
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	//Import user's defined package
+	"gobank-server/exp"
+	"gobank-server/fx"
+	"gobank-server/perks"
+	"gobank-server/scheduler"
+	"gobank-server/server"
+	"gobank-server/store"
+	"gobank-server/store/memory"
+	"gobank-server/user"
+	backend "gobank-server/utility"
+
+	"gobank/cli"
+	"gobank/model"
+	"gobank/utility"
+)
+
+// Config uses the built-in rates and EXP rules and lets 50.00 go to a payee in its first day
+var Config = server.Config{
+	Exchange:   fx.Exchange{Rates: fx.DefaultRates(), QuoteTTL: time.Minute},
+	CoolingOff: user.CoolingOff{Period: 24 * time.Hour, Limit: 5000},
+	Rewards:    user.Rewards{Expiry: 30 * 24 * time.Hour, MinTransfer: 1000, PointValue: 1},
+	ExpRules:   exp.DefaultRules(),
+	Levels:     backend.DefaultLevelCurve,
+	Tiers:      perks.DefaultTable(),
+
+	IdempotencyTTL: 24 * time.Hour,
+}
+
+// Harness is one server and one CLI data folder, shared by every command of a test
+type Harness struct {
+	t       *testing.T
+	Stores  store.Stores
+	URL     string
+	DataDir string
+
+	mu    sync.Mutex
+	drops map[string]int //Responses still to drop, by path
+}
+
+func New(t *testing.T) *Harness {
+	t.Helper()
+
+	err := backend.SetSigningKeys(map[string][]byte{"test": []byte(strings.Repeat("k", 32))}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Harness{t: t, Stores: memory.New(), DataDir: t.TempDir(), drops: map[string]int{}}
+	handler := server.New(h.Stores, Config)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.dropping(r.URL.Path) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		//The server does the work but the client never hears back
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	h.URL = srv.URL
+	return h
+}
+
+// DropResponses makes the server handle the next count requests to path, then close the
+// connection without answering, like a network failure after the server committed
+func (h *Harness) DropResponses(path string, count int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.drops[path] = count
+}
+
+func (h *Harness) dropping(path string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.drops[path] == 0 {
+		return false
+	}
+	h.drops[path]--
+	return true
+}
+
+// RunScheduler makes the scheduled transfers due at now, like the server's scheduler would
+func (h *Harness) RunScheduler(now time.Time) {
+	h.t.Helper()
+
+	transfers := server.UserHandler(h.Stores, Config)
+	err := scheduler.Scheduler{
+		Schedules:   h.Stores.Schedules,
+		Transfer:    transfers.Transfer,
+		Notifier:    scheduler.LogNotifier{},
+		RetryDelay:  time.Hour,
+		MaxAttempts: 3,
+	}.RunDue(now)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// Run executes one CLI command, answering its prompts with the given lines, and returns
+// everything the CLI printed
+func (h *Harness) Run(answers []string, args ...string) string {
+	h.t.Helper()
+
+	var out bytes.Buffer
+	input := strings.Join(answers, "\n")
+	if len(answers) > 0 {
+		input += "\n"
+	}
+	utility.SetConsole(strings.NewReader(input), &out)
+	defer utility.SetConsole(os.Stdin, os.Stdout)
+
+	cli.Run(append([]string{"gobank", "--server", h.URL, "--data-dir", h.DataDir}, args...))
+	return out.String()
+}
+
+// Credential returns what the CLI saved in credential.json, the zero value when logged out
+func (h *Harness) Credential() model.Credential {
+	h.t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(h.DataDir, "credential.json"))
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	var credential model.Credential
+	if len(data) == 0 {
+		return credential
+	}
+
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		h.t.Fatalf("credential.json is not valid JSON: %v\n%s", err, data)
+	}
+	return credential
+}
+
+// Expect fails the test when the output does not contain every one of the texts
+func (h *Harness) Expect(output string, texts ...string) {
+	h.t.Helper()
+
+	for _, text := range texts {
+		if !strings.Contains(output, text) {
+			h.t.Errorf("output does not contain %q:\n%s", text, output)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package e2e
+
+import (
+	//Import standard library
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const password = "Secret#Pass123"
+
+// signUp registers and logs in a user through the CLI
+func (h *Harness) signUp(fullname, email string) {
+	h.t.Helper()
+
+	output := h.Run([]string{fullname, email, password}, "register", "--user")
+	h.Expect(output, "Account created successfully")
+
+	output = h.Run([]string{email, password}, "login", "--user")
+	h.Expect(output, "Log in successfully!")
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	h := New(t)
+
+	output := h.Run(nil)
+	h.Expect(output, "Welcome to Gobank!")
+
+	//Invalid answers are asked again
+	output = h.Run([]string{"", "Alice", "not-an-email", "alice@example.com", "short", password}, "register", "--user")
+	h.Expect(output, "Fullname cannot be empty", "Invalid email format", "Password must have at least 11 characters", "Account created successfully")
+
+	output = h.Run([]string{"alice@example.com", "Wrong#Pass123"}, "login", "--user")
+	h.Expect(output, "Wrong email or password")
+	if credential := h.Credential(); credential.Token != "" {
+		t.Fatalf("credential saved after a failed login: %+v", credential)
+	}
+
+	output = h.Run([]string{"alice@example.com", password}, "login", "--user")
+	h.Expect(output, "Log in successfully!")
+
+	credential := h.Credential()
+	if credential.Token == "" || credential.RefreshToken == "" {
+		t.Fatalf("credential = %+v, want both tokens", credential)
+	}
+	if credential.Info.ID != "10000000049" || credential.Info.Fullname != "Alice" || credential.Info.Role != "user" {
+		t.Fatalf("credential info = %+v", credential.Info)
+	}
+
+	output = h.Run(nil)
+	h.Expect(output, "Welcome back, Alice")
+
+	output = h.Run(nil, "show-info")
+	h.Expect(output, "Fullname: Alice", "Account number: 10000000049", "Balance: 0.00 USD")
+
+	output = h.Run(nil, "logout")
+	if credential := h.Credential(); credential.Token != "" {
+		t.Fatalf("credential kept after logout: %+v", credential)
+	}
+
+	output = h.Run(nil, "show-info")
+	h.Expect(output, "You haven't logged in!")
+}
+
+func TestCredentialRefresh(t *testing.T) {
+	h := New(t)
+	h.DataDir = filepath.Join(h.DataDir, "data")
+	h.signUp("Alice", "alice@example.com")
+
+	//Only the user can read their tokens
+	path := filepath.Join(h.DataDir, "credential.json")
+	for file, want := range map[string]os.FileMode{h.DataDir: 0700, path: 0600} {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want {
+			t.Fatalf("%s has mode %v, want %v", file, info.Mode().Perm(), want)
+		}
+	}
+
+	//While the access token is valid commands keep the tokens and only read the information again
+	credential := h.Credential()
+	h.Run([]string{"100"}, "topup")
+	output := h.Run(nil, "show-info")
+	h.Expect(output, "Balance: 100.00 USD")
+	if saved := h.Credential(); saved.Token != credential.Token || saved.RefreshToken != credential.RefreshToken {
+		t.Fatalf("tokens changed while the access token was valid")
+	}
+
+	//An expired access token is refreshed before the command
+	credential.Token = "expired.access.token"
+	data, err := json.Marshal(credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, data, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output = h.Run(nil, "show-info")
+	h.Expect(output, "Fullname: Alice", "Balance: 100.00 USD")
+	if saved := h.Credential(); saved.Token == credential.Token || saved.RefreshToken == credential.RefreshToken {
+		t.Fatalf("expired access token was not refreshed")
+	}
+}
+
+func TestTopupAndWithdraw(t *testing.T) {
+	h := New(t)
+	h.signUp("Alice", "alice@example.com")
+
+	output := h.Run([]string{"abc", "-5", "100.50"}, "topup")
+	h.Expect(output, "Invalid value for amount!", "The amount of money must be greater than 0", "Balance update successfully!")
+
+	//Credential is refreshed before every command
+	h.Run(nil, "show-info")
+	if balance := h.Credential().Info.Balance.String(); balance != "100.50" {
+		t.Fatalf("balance after topup = %s, want 100.50", balance)
+	}
+
+	output = h.Run([]string{"500", "40.25"}, "withdraw")
+	h.Expect(output, "The amount to withdraw must be between 0 and 100.50 USD", "Balance updated successfully")
+
+	output = h.Run(nil, "show-info")
+	h.Expect(output, "Balance: 60.25 USD")
+}
+
+func TestTransfer(t *testing.T) {
+	h := New(t)
+	h.signUp("Bob", "bob@example.com")
+	h.Run(nil, "logout")
+	h.signUp("Alice", "alice@example.com")
+	h.Run([]string{"100"}, "topup")
+
+	output := h.Run([]string{"10000000048", "999999999", "10000000049", "30", "Dinner", "N"}, "make-transaction")
+	h.Expect(output, "Invalid account number, please check it again", "Cannot find any account with this ID", "Beneficiary's name: Bob", "Amount: 30.00 USD", "Description: Dinner")
+	if balance := h.Credential().Info.Balance.String(); balance != "100.00" {
+		t.Fatalf("balance after a cancelled transfer = %s, want 100.00", balance)
+	}
+
+	output = h.Run([]string{"10000000049", "30", "", "Y", "N"}, "make-transaction")
+	h.Expect(output, "Description: Alice transfer", "Transaction created successfully")
+
+	output = h.Run(nil, "get-transactions")
+	h.Expect(output, "transfer", "10000000049 (Bob)", "-30.00 USD", "topup", "+100.00 USD")
+
+	output = h.Run(nil, "show-info")
+	h.Expect(output, "Balance: 70.00 USD")
+
+	//Bob sees the money coming in
+	h.Run(nil, "logout")
+	h.Run([]string{"bob@example.com", password}, "login", "--user")
+	output = h.Run(nil, "get-transactions")
+	h.Expect(output, "10000000146", "+30.00 USD", "Alice transfer")
+	if balance := h.Credential().Info.Balance.String(); balance != "30.00" {
+		t.Fatalf("Bob's balance = %s, want 30.00", balance)
+	}
+
+	//Alice earned EXP and a badge for her topup and her transfer, receiving money earns nothing
+	output = h.Run(nil, "show-info")
+	h.Expect(output, "Exp: 0", "Badges:", "[ ] First transfer - Made your first transfer")
+	h.Run(nil, "logout")
+	h.Run([]string{"alice@example.com", password}, "login", "--user")
+	output = h.Run(nil, "show-info")
+	h.Expect(output, "Level: 1", "Exp: 15", "Progress: [#-------------------]   7% 15/200 EXP to level 2")
+	h.Expect(output, "[x] First transfer - Made your first transfer (", "[ ] Rising star - Reached level 5")
+}
+
+func TestAccounts(t *testing.T) {
+	h := New(t)
+	h.signUp("Alice", "alice@example.com")
+
+	output := h.Run([]string{"loan", "pot", "", "Holiday", ""}, "accounts", "open")
+	h.Expect(output, "Account type must be checking, savings or pot", "A pot must have a name", "Account 10000000146 opened successfully")
+
+	output = h.Run(nil, "accounts", "list")
+	h.Expect(output, "*  10000000049  checking", "10000000146  pot", "Holiday")
+	if accounts := h.Credential().Info.Accounts; len(accounts) != 2 {
+		t.Fatalf("credential accounts = %+v, want 2", accounts)
+	}
+
+	output = h.Run([]string{"80"}, "topup", "--account", "10000000146")
+	h.Expect(output, "Balance update successfully!")
+	output = h.Run(nil, "topup", "--account", "10000000048")
+	h.Expect(output, "You have no open account with number 10000000048")
+
+	//Move money from the pot to the checking account
+	output = h.Run([]string{"10000000049", "90", "50", "", "Y"}, "make-transaction", "--from", "10000000146")
+	h.Expect(output, "Debit account: 10000000146", "Amount of money must be between 0 and 80.00 USD", "Transaction created successfully")
+
+	output = h.Run(nil, "get-transactions", "--account", "10000000146")
+	h.Expect(output, "10000000049 (Alice)", "-50.00 USD", "+80.00 USD")
+
+	output = h.Run(nil, "accounts", "close", "10000000146")
+	h.Expect(output, "Account still holds 30.00")
+
+	h.Run([]string{"30"}, "withdraw", "--account", "10000000146")
+	output = h.Run(nil, "accounts", "close", "10000000146")
+	h.Expect(output, "Account closed successfully")
+
+	output = h.Run(nil, "accounts", "close", "10000000049")
+	h.Expect(output, "Cannot close your only open account")
+
+	output = h.Run(nil, "show-info")
+	h.Expect(output, "Balance: 50.00 USD", "Open accounts: 1")
+}
+
+func TestCrossCurrencyTransfer(t *testing.T) {
+	h := New(t)
+	h.signUp("Alice", "alice@example.com")
+	h.Run([]string{"100"}, "topup")
+
+	output := h.Run([]string{"savings", "", "euro", "eur"}, "accounts", "open")
+	h.Expect(output, "Currency must be a 3-letter ISO 4217 code", "Account 10000000146 opened successfully in EUR")
+
+	//The rate is confirmed before any money moves
+	output = h.Run([]string{"10000000146", "50", "", "Y", "N"}, "make-transaction")
+	h.Expect(output, "Exchange rate: 1 USD = 0.92 EUR", "You pay: 50.00 USD", "Beneficiary receives: 46.00 EUR", "Rate locked until")
+	if balance := h.Credential().Info.Balance.String(); balance != "100.00" {
+		t.Fatalf("balance after a refused rate = %s, want 100.00", balance)
+	}
+
+	output = h.Run([]string{"10000000146", "50", "", "Y", "Y"}, "make-transaction")
+	h.Expect(output, "Transaction created successfully")
+
+	output = h.Run(nil, "get-transactions", "--account", "10000000146")
+	h.Expect(output, "+46.00 EUR @ 0.92")
+
+	output = h.Run(nil, "accounts", "list")
+	h.Expect(output, "50.00 USD", "46.00 EUR")
+
+	//Only money held in the credential's currency counts towards the balance
+	output = h.Run(nil, "show-info")
+	h.Expect(output, "Balance: 50.00 USD")
+}
+
+func TestRetryAfterLostResponse(t *testing.T) {
+	h := New(t)
+	h.signUp("Bob", "bob@example.com")
+	h.Run(nil, "logout")
+	h.signUp("Alice", "alice@example.com")
+
+	//The first respond of each operation is lost, the retry must not move the money again
+	h.DropResponses("/topup", 1)
+	output := h.Run([]string{"100"}, "topup")
+	h.Expect(output, "Balance update successfully!")
+
+	h.DropResponses("/transaction", 1)
+	output = h.Run([]string{"10000000049", "30", "", "Y", "N"}, "make-transaction")
+	h.Expect(output, "Transaction created successfully")
+
+	account, err := h.Stores.Accounts.AccountByID("10000000146")
+	if err != nil || account.Balance.String() != "70.00" {
+		t.Fatalf("Alice's balance = %s, %v; want 70.00", account.Balance, err)
+	}
+	if balance := h.Credential().Info.Balance.String(); balance != "70.00" {
+		t.Fatalf("balance in credential = %s, want 70.00", balance)
+	}
+}
+
+func TestScheduledTransfer(t *testing.T) {
+	h := New(t)
+	h.signUp("Bob", "bob@example.com")
+	h.Run(nil, "logout")
+	h.signUp("Alice", "alice@example.com")
+	h.Run([]string{"100"}, "topup")
+
+	start := time.Now().UTC().AddDate(0, 0, 1)
+	output := h.Run([]string{"10000000049", "25", "yearly", "monthly", start.Format("2006-01-02"), "", "Rent", "Y"}, "schedule", "create")
+	h.Expect(output, "Frequency must be once, daily, weekly, monthly or last-business-day",
+		fmt.Sprintf("Frequency: monthly on day %d", start.Day()), "Amount: 25.00 USD",
+		"Transfer 1 scheduled, first run on "+start.Format("2006-01-02"))
+
+	//Nothing moves before the day
+	h.RunScheduler(time.Now())
+	account, _ := h.Stores.Accounts.AccountByID("10000000049")
+	if account.Balance != 0 {
+		t.Fatalf("Bob's balance before the run = %s", account.Balance)
+	}
+
+	h.RunScheduler(start)
+	account, _ = h.Stores.Accounts.AccountByID("10000000049")
+	if account.Balance.String() != "25.00" {
+		t.Fatalf("Bob's balance after the run = %s, want 25.00", account.Balance)
+	}
+
+	output = h.Run(nil, "schedule", "list")
+	h.Expect(output, "10000000146", "10000000049", "25.00 USD", "active")
+
+	output = h.Run(nil, "schedule", "cancel", "1")
+	h.Expect(output, "Schedule cancelled successfully")
+	output = h.Run(nil, "schedule", "cancel", "1")
+	h.Expect(output, "You have no active schedule with this ID")
+	output = h.Run(nil, "schedule", "list")
+	h.Expect(output, "cancelled")
+}
+
+func TestPayees(t *testing.T) {
+	h := New(t)
+	h.signUp("Bob", "bob@example.com")
+	h.Run(nil, "logout")
+	h.signUp("Alice", "alice@example.com")
+	h.Run([]string{"200"}, "topup")
+
+	//Bob is saved after the first transfer to him
+	output := h.Run([]string{"10000000049", "20", "", "Y", "Y", "Bobby"}, "make-transaction")
+	h.Expect(output, "Transaction created successfully", "Save Bob as a payee? (Y/N)", "Bobby (Bob) saved as a payee", "Transfers to a new payee are limited until")
+
+	output = h.Run(nil, "payees", "list")
+	h.Expect(output, "Bobby", "10000000049", "Bob", "until ")
+
+	//Next time he is picked from the list, and the cooling-off limit applies since the first transfer
+	output = h.Run([]string{"1", "20", "", "Y"}, "make-transaction")
+	h.Expect(output, "1. Bobby - 10000000049 (Bob)", "Enter payee number or beneficiary account number: ", "Beneficiary's name: Bob", "Transaction created successfully")
+	output = h.Run([]string{"1", "30", "", "Y"}, "make-transaction")
+	h.Expect(output, "only 50.00 can be sent to it until its cooling-off period ends (40.00 sent so far)")
+	output = h.Run([]string{"1", "10", "", "Y"}, "make-transaction")
+	h.Expect(output, "Transaction created successfully")
+	if strings.Contains(output, "as a payee?") {
+		t.Fatalf("saved payee offered to be saved again:\n%s", output)
+	}
+
+	output = h.Run([]string{"Landlord"}, "payees", "edit", "1")
+	h.Expect(output, "Payee renamed successfully")
+	output = h.Run([]string{"10000000049", ""}, "payees", "add")
+	h.Expect(output, "This account is already one of your payees")
+
+	output = h.Run(nil, "payees", "delete", "1")
+	h.Expect(output, "Payee deleted successfully")
+	output = h.Run(nil, "payees", "delete", "1")
+	h.Expect(output, "You have no payee with this ID")
+	output = h.Run(nil, "payees", "list")
+	h.Expect(output, "You have no saved payees")
+}
+
+func TestPerks(t *testing.T) {
+	h := New(t)
+	h.signUp("Alice", "alice@example.com")
+	h.Run([]string{"1000"}, "topup")
+
+	//The topup earned 5 EXP of the 200 level 1 spans
+	output := h.Run(nil, "perks")
+	h.Expect(output, "Level 1 - Bronze tier", "Exp: 5", "Progress: [--------------------]   2% 5/200 EXP to level 2",
+		"Transfers: 1000.00 a day per account", "Withdrawals: 500.00 a day per account", "Cashback on transfers: 0.00%",
+		"Next tier: Silver, from level 3", "Cashback on transfers: 0.25%")
+
+	output = h.Run([]string{"400"}, "withdraw")
+	h.Expect(output, "Balance updated successfully")
+	output = h.Run([]string{"200"}, "withdraw")
+	h.Expect(output, "Your Bronze tier allows 500.00 of withdrawals a day from your accounts, 400.00 has been used today")
+}
+
+func TestRewards(t *testing.T) {
+	h := New(t)
+	h.signUp("Bob", "bob@example.com")
+	h.Run(nil, "logout")
+	h.signUp("Alice", "alice@example.com")
+	h.Run([]string{"100"}, "topup")
+
+	output := h.Run(nil, "rewards")
+	h.Expect(output, "Reward points: 0 (worth 0.00)")
+	output = h.Run(nil, "rewards", "history")
+	h.Expect(output, "You have not earned any reward point yet")
+
+	//Bronze earns a point for each whole unit sent to another customer
+	output = h.Run([]string{"10000000049", "30", "", "Y", "N"}, "make-transaction")
+	h.Expect(output, "Transaction created successfully")
+	output = h.Run(nil, "rewards")
+	h.Expect(output, "Reward points: 30 (worth 0.30)", "30 points expire on")
+
+	output = h.Run([]string{"abc", "50"}, "rewards", "redeem")
+	h.Expect(output, "The points must be a whole number greater than 0", "Your rewards wallet only holds 30 points")
+	output = h.Run([]string{"20"}, "rewards", "redeem")
+	h.Expect(output, "Redeemed 20 points for 0.20 USD, new balance: 70.20 USD")
+
+	output = h.Run(nil, "rewards", "history")
+	h.Expect(output, "redeem  -20     10000000146", "earn    +30")
+	output = h.Run(nil, "rewards", "points")
+	h.Expect(output, "Invalid argument")
+}
@@ -0,0 +1,19 @@
+module gobank-e2e
+
+go 1.22.2
+
+require (
+	gobank v0.0.0
+	gobank-server v0.0.0
+)
+
+require (
+	github.com/lib/pq v1.10.9 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+)
+
+replace (
+	gobank => ../frontend
+	gobank-server => ../backend
+)
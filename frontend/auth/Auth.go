@@ -1,11 +1,12 @@
 package auth
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"gobank/config"
 	"gobank/model"
+	"gobank/utility"
 	"io"
 	"net/http"
 	"os"
@@ -13,25 +14,23 @@ import (
 	"strings"
 )
 
-var creFilePath string = "./data/credential.json"
-
 func Register(role string) {
 	var (
 		fullname, email, password string
 		err                       error
 		isValid                   bool
-		reader                    = bufio.NewReader(os.Stdin)
+		reader                    = utility.Stdin
 	)
 
 	//Ask for user's fullname
 	isValid = false
 	for !isValid {
 		//Read input from stdin
-		fmt.Print("Enter your fullname: ")
+		fmt.Fprint(utility.Stdout, "Enter your fullname: ")
 		fullname, err = reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: Register -> Error reading fullname from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error reading fullname from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		fullname = strings.TrimSpace(fullname)
@@ -39,7 +38,7 @@ func Register(role string) {
 		//Check if fullname is valid
 		isValid = len(fullname) > 0
 		if !isValid {
-			fmt.Println("Fullname cannot be empty")
+			fmt.Fprintln(utility.Stdout, "Fullname cannot be empty")
 		}
 	}
 
@@ -47,11 +46,11 @@ func Register(role string) {
 	isValid = false
 	for !isValid {
 		//Read input from stdin
-		fmt.Print("Enter your email: ")
+		fmt.Fprint(utility.Stdout, "Enter your email: ")
 		email, err = reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: Register -> Error reading email from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error reading email from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		email = strings.TrimSpace(email)
@@ -59,7 +58,7 @@ func Register(role string) {
 		//Check if email is valid
 		isValid = len(email) > 0 && strings.Contains(email, "@") && !strings.Contains(email, " ")
 		if !isValid {
-			fmt.Println("Invalid email format")
+			fmt.Fprintln(utility.Stdout, "Invalid email format")
 		}
 	}
 
@@ -67,11 +66,11 @@ func Register(role string) {
 	isValid = false
 	for !isValid {
 		//Read password from stdin
-		fmt.Print("Enter your password: ")
+		fmt.Fprint(utility.Stdout, "Enter your password: ")
 		password, err = reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: Register -> Error reading password from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error reading password from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		password = strings.TrimSpace(password)
@@ -79,50 +78,50 @@ func Register(role string) {
 		/*Check if password meets all requirements*/
 		isValid = len(password) >= 11
 		if !isValid {
-			fmt.Println("Password must have at least 11 characters")
+			fmt.Fprintln(utility.Stdout, "Password must have at least 11 characters")
 		}
 
 		isValid = !strings.Contains(password, " ")
 		if !isValid {
-			fmt.Println("Password must not contain any space")
+			fmt.Fprintln(utility.Stdout, "Password must not contain any space")
 		}
 
 		regex, err := regexp.Compile(".*[A-Z]+.*")
 		if err != nil {
-			fmt.Println("Error at: Register -> Error compile regex")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		isValid = regex.MatchString(password)
 		if !isValid {
-			fmt.Println("Password must have at least one uppercase letter")
+			fmt.Fprintln(utility.Stdout, "Password must have at least one uppercase letter")
 		}
 
 		regex, err = regexp.Compile(".*[a-z]+.*")
 		if err != nil {
-			fmt.Println("Error at: Register -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error compile regex")
 		}
 		isValid = regex.MatchString(password)
 		if !isValid {
-			fmt.Println("Password must have at least one lowercase letter")
+			fmt.Fprintln(utility.Stdout, "Password must have at least one lowercase letter")
 		}
 
 		regex, err = regexp.Compile(".*[0-9]+.*")
 		if err != nil {
-			fmt.Println("Error at: Register -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error compile regex")
 		}
 		isValid = regex.MatchString(password)
 		if !isValid {
-			fmt.Println("Password must have at least one number")
+			fmt.Fprintln(utility.Stdout, "Password must have at least one number")
 		}
 
 		regex, err = regexp.Compile(".*[^A-Za-z0-9]+.*")
 		if err != nil {
-			fmt.Println("Error at: Register -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error compile regex")
 		}
 		isValid = regex.MatchString(password)
 		if !isValid {
-			fmt.Println("Password must have at least one special character")
+			fmt.Fprintln(utility.Stdout, "Password must have at least one special character")
 		}
 	}
 
@@ -138,52 +137,53 @@ func Register(role string) {
 	}
 
 	if err != nil {
-		fmt.Println("Error at: Register -> Error marshal data")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Register -> Error marshal data")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
 	//Make request to server
 	var url string
 	if role == "admin" {
-		url = "http://localhost:8800/register?role=admin"
+		url = config.URL("/register?role=admin")
 	} else if role == "user" {
-		url = "http://localhost:8800/register?role=user"
+		url = config.URL("/register?role=user")
 	}
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
 	if err != nil {
-		fmt.Println("Error at: Register -> Error create new request")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Register -> Error create new request")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
-	client := &http.Client{}
+	client := config.Client()
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error at: Register -> Error sending request to server or failed to receive respond")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Register -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	defer resp.Body.Close()
 
 	//Handle each respond status
-	if resp.StatusCode == http.StatusInternalServerError {
-		fmt.Println("Internal server error :(")
+	message, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: Register -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
-	if resp.StatusCode == http.StatusBadRequest {
-		message, err := io.ReadAll(resp.Body)
-		if err != nil {
-			fmt.Println("Error at: Register -> Error reading respond body")
-			fmt.Println(err)
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, message)
+		if problem.Code == utility.CodeInternal {
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
 			return
 		}
-		fmt.Println(string(message))
+		fmt.Fprintln(utility.Stdout, problem.Detail)
 		return
 	}
 
 	if resp.StatusCode == http.StatusCreated {
-		fmt.Println("Account created successfully")
+		fmt.Fprintln(utility.Stdout, "Account created successfully")
 		return
 	}
 }
@@ -193,18 +193,18 @@ func Login(role string) {
 		email, password string
 		err             error
 		isValid         bool
-		reader          = bufio.NewReader(os.Stdin)
+		reader          = utility.Stdin
 	)
 
 	//Ask for user's email
 	isValid = false
 	for !isValid {
 		//Read email from stdin
-		fmt.Print("Enter your email: ")
+		fmt.Fprint(utility.Stdout, "Enter your email: ")
 		email, err = reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: Login -> Error readinge email from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Login -> Error readinge email from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		email = strings.TrimSpace(email)
@@ -212,7 +212,7 @@ func Login(role string) {
 		//Check if email is valid format
 		isValid = len(email) > 0 && strings.Contains(email, "@") && !strings.Contains(email, " ")
 		if !isValid {
-			fmt.Println("Invalid email format")
+			fmt.Fprintln(utility.Stdout, "Invalid email format")
 		}
 	}
 
@@ -220,11 +220,11 @@ func Login(role string) {
 	isValid = false
 	for !isValid {
 		//Read password from stdin
-		fmt.Print("Enter your password: ")
+		fmt.Fprint(utility.Stdout, "Enter your password: ")
 		password, err = reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: Login -> Error reading password from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Login -> Error reading password from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		password = strings.TrimSpace(password)
@@ -233,38 +233,38 @@ func Login(role string) {
 		isValid = len(password) >= 11 && !strings.Contains(password, " ")
 		regex, err := regexp.Compile(".*[A-Z]+.*")
 		if err != nil {
-			fmt.Println("Error at: Login -> Error compile regex")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Login -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		isValid = isValid && regex.MatchString(password)
 
 		regex, err = regexp.Compile(".*[a-z]+.*")
 		if err != nil {
-			fmt.Println("Error at: Login -> Error compile regex")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Login -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		isValid = isValid && regex.MatchString(password)
 
 		regex, err = regexp.Compile(".*[0-9]+.*")
 		if err != nil {
-			fmt.Println("Error at: Login -> Error compile regex")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Login -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		isValid = isValid && regex.MatchString(password)
 
 		regex, err = regexp.Compile(".*[^a-zA-Z0-9]+.*")
 		if err != nil {
-			fmt.Println("Error at: Login -> Error compile regex")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Login -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		isValid = isValid && regex.MatchString(password)
 
 		if !isValid {
-			fmt.Println("Wrong password format")
+			fmt.Fprintln(utility.Stdout, "Wrong password format")
 		}
 	}
 
@@ -273,81 +273,85 @@ func Login(role string) {
 	var data []byte
 	data, err = json.MarshalIndent(loginInfo, "", " ")
 	if err != nil {
-		fmt.Println("Error at: Login -> Error marshal login data")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Login -> Error marshal login data")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
 	//Make new request to server
 	var url string
 	if role == "admin" {
-		url = "http://localhost:8800/login?role=admin"
+		url = config.URL("/login?role=admin")
 	} else if role == "user" {
-		url = "http://localhost:8800/login?role=user"
+		url = config.URL("/login?role=user")
 	}
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
 	if err != nil {
-		fmt.Println("Error at: Login -> Error making new request to server")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Login -> Error making new request to server")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
-	client := &http.Client{}
+	//Name this device so the user can recognise the session later
+	device, err := os.Hostname()
+	if err != nil {
+		device = "unknown device"
+	}
+	req.Header.Set("device", device)
+	client := config.Client()
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error at: Login -> Error sending request or failed to receive respond")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Login -> Error sending request or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	defer resp.Body.Close()
 
 	//Handle each respond status
-	if resp.StatusCode == http.StatusInternalServerError {
-		fmt.Println("Internal server error :(")
-		return
-	}
-
-	if resp.StatusCode == http.StatusBadRequest {
-		fmt.Println("Bad request")
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: Login -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
-	if resp.StatusCode == http.StatusNotAcceptable {
-		fmt.Println("Wrong email or password")
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, data)
+		switch problem.Code {
+		case utility.CodeInvalidCredential:
+			fmt.Fprintln(utility.Stdout, "Wrong email or password")
+		case utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
 		return
 	}
 
-	if resp.StatusCode == http.StatusAccepted {
-		//Read respond body
-		data, err = io.ReadAll(resp.Body)
-		if err != nil {
-			fmt.Println("Error at: Login -> Error reading respond body")
-			fmt.Println(err)
-			return
-		}
+	if resp.StatusCode == http.StatusOK {
 		//Write data to credential.json
-		err = os.WriteFile(creFilePath, data, 0644)
+		err = os.WriteFile(config.CredentialPath(), data, config.CredentialPerm)
 		if err != nil {
-			fmt.Println("Error at: Login -> Error writing data to file")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Login -> Error writing data to file")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		//Send message to client
-		fmt.Println("Log in successfully!")
+		fmt.Fprintln(utility.Stdout, "Log in successfully!")
 		return
 	}
 }
 
 func UpdatePassword() {
 	//Read data from credential.json
-	data, err := os.ReadFile(creFilePath)
+	data, err := os.ReadFile(config.CredentialPath())
 	if err != nil {
-		fmt.Println("Error at: UpdatePassword -> Error reading credential data")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: UpdatePassword -> Error reading credential data")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
 	if len(data) == 0 {
-		fmt.Println("You haven't logged in! This service required you to logged in to continue")
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to logged in to continue")
 		return
 	}
 
@@ -355,8 +359,8 @@ func UpdatePassword() {
 	var credential model.Credential
 	err = json.Unmarshal(data, &credential)
 	if err != nil {
-		fmt.Println("Error at: UpdatePassword -> Error unmarshal credential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: UpdatePassword -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	role := credential.Info.Role
@@ -364,18 +368,18 @@ func UpdatePassword() {
 	var (
 		password string
 		isValid  bool
-		reader   = bufio.NewReader(os.Stdin)
+		reader   = utility.Stdin
 	)
 
 	//Ask user's for their new password
 	isValid = false
 	for !isValid {
 		//Read new password from stdin
-		fmt.Print("Enter new password: ")
+		fmt.Fprint(utility.Stdout, "Enter new password: ")
 		password, err = reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: UpdatePassword -> Error reading new password from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: UpdatePassword -> Error reading new password from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		password = strings.TrimSpace(password)
@@ -383,80 +387,80 @@ func UpdatePassword() {
 		//Check if new password is valid
 		isValid = len(password) >= 11
 		if !isValid {
-			fmt.Println("Password must have at least 11 characters")
+			fmt.Fprintln(utility.Stdout, "Password must have at least 11 characters")
 		}
 
 		isValid = !strings.Contains(password, " ")
 		if !isValid {
-			fmt.Println("Password must not contain any space")
+			fmt.Fprintln(utility.Stdout, "Password must not contain any space")
 		}
 
 		regex, err := regexp.Compile(".*[A-Z]+.*")
 		if err != nil {
-			fmt.Println("Error at: Register -> Error compile regex")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		isValid = regex.MatchString(password)
 		if !isValid {
-			fmt.Println("Password must have at least one uppercase letter")
+			fmt.Fprintln(utility.Stdout, "Password must have at least one uppercase letter")
 		}
 
 		regex, err = regexp.Compile(".*[a-z]+.*")
 		if err != nil {
-			fmt.Println("Error at: Register -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error compile regex")
 		}
 		isValid = regex.MatchString(password)
 		if !isValid {
-			fmt.Println("Password must have at least one lowercase letter")
+			fmt.Fprintln(utility.Stdout, "Password must have at least one lowercase letter")
 		}
 
 		regex, err = regexp.Compile(".*[0-9]+.*")
 		if err != nil {
-			fmt.Println("Error at: Register -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error compile regex")
 		}
 		isValid = regex.MatchString(password)
 		if !isValid {
-			fmt.Println("Password must have at least one number")
+			fmt.Fprintln(utility.Stdout, "Password must have at least one number")
 		}
 
 		regex, err = regexp.Compile(".*[^A-Za-z0-9]+.*")
 		if err != nil {
-			fmt.Println("Error at: Register -> Error compile regex")
+			fmt.Fprintln(utility.Stdout, "Error at: Register -> Error compile regex")
 		}
 		isValid = regex.MatchString(password)
 		if !isValid {
-			fmt.Println("Password must have at least one special character")
+			fmt.Fprintln(utility.Stdout, "Password must have at least one special character")
 		}
 	}
 
 	//Package data before sending to server
 	data, err = json.MarshalIndent(password, "", " ")
 	if err != nil {
-		fmt.Println("Error at: UpdatePassword -> Error marshal data before sending to server")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: UpdatePassword -> Error marshal data before sending to server")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
 	//Making new request
 	var url string
 	if role == "admin" {
-		url = "http://localhost:8800/update-password?role=admin"
+		url = config.URL("/update-password?role=admin")
 	} else if role == "user" {
-		url = "http://localhost:8800/update-password?role=user"
+		url = config.URL("/update-password?role=user")
 	}
 	req, err := http.NewRequest("UPDATE", url, bytes.NewBuffer(data))
 	if err != nil {
-		fmt.Println("Error at: UpdatePassword -> Error making new request")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: UpdatePassword -> Error making new request")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	req.Header.Set("token", credential.Token)
-	client := &http.Client{}
+	client := config.Client()
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error at: UpdatePassword -> Error sending request to server or failed to received respond")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: UpdatePassword -> Error sending request to server or failed to received respond")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	defer resp.Body.Close()
@@ -464,44 +468,43 @@ func UpdatePassword() {
 	//Handle each respond status
 	message, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error at: UpdatePassword -> Error reading respond body")
-		fmt.Println(err)
-		return
-	}
-
-	if resp.StatusCode == http.StatusInternalServerError {
-		fmt.Println("Internal server error :(")
+		fmt.Fprintln(utility.Stdout, "Error at: UpdatePassword -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
-	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotAcceptable {
-		fmt.Println(string(message))
-		Logout()
-		return
-	}
-
-	if resp.StatusCode == http.StatusBadRequest {
-		fmt.Println("Bad request")
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, message)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
 		return
 	}
 
 	if resp.StatusCode == http.StatusOK {
-		fmt.Println("Password changed successfully!")
+		fmt.Fprintln(utility.Stdout, "Password changed successfully!")
 		return
 	}
 }
 
 func ShowInfo() {
 	//Read data from credential.json
-	data, err := os.ReadFile(creFilePath)
+	data, err := os.ReadFile(config.CredentialPath())
 	if err != nil {
-		fmt.Println("Error at: ShowInfo -> Error reading credential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: ShowInfo -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
 	if len(data) == 0 {
-		fmt.Println("You haven't logged in! This service required you to log in to continue")
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
 		return
 	}
 
@@ -509,27 +512,111 @@ func ShowInfo() {
 	var credential model.Credential
 	err = json.Unmarshal(data, &credential)
 	if err != nil {
-		fmt.Println("Error at: ShowInfo -> Error unmarshal credential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: ShowInfo -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
 	//Display information
-	fmt.Printf("Fullname: %s\n", credential.Info.Fullname)
+	fmt.Fprintf(utility.Stdout, "Fullname: %s\n", credential.Info.Fullname)
 	if credential.Info.Role == "user" {
-		fmt.Printf("Account number: %s\n", credential.Info.ID)
-		fmt.Printf("Balance: %f\n", credential.Info.Balance)
-		fmt.Printf("Level: %d\n", credential.Info.Level)
-		fmt.Printf("Exp: %d\n", credential.Info.Exp)
+		fmt.Fprintf(utility.Stdout, "Account number: %s\n", credential.Info.ID)
+		fmt.Fprintf(utility.Stdout, "Balance: %s\n", credential.Info.Balance.Format(credential.Info.Currency))
+		fmt.Fprintf(utility.Stdout, "Open accounts: %d (run './gobank accounts list' for details)\n", len(credential.Info.Accounts))
+		fmt.Fprintf(utility.Stdout, "Level: %d\n", credential.Info.Level)
+		fmt.Fprintf(utility.Stdout, "Exp: %d\n", credential.Info.Exp)
+		fmt.Fprintf(utility.Stdout, "Progress: %s\n", utility.LevelProgress(credential.Info.Level, credential.Info.Exp, credential.Info.LevelMin, credential.Info.LevelMax))
+		showBadges(credential.Token)
+	}
+}
+
+// showBadges lists the user's achievements, the unlocked ones first
+func showBadges(token string) {
+	//Make new request
+	url := config.URL("/achievements")
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowInfo -> Error making new request")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	req.Header.Set("token", token)
+	client := config.Client()
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowInfo -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowInfo -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	var badges []model.Badge
+	err = json.Unmarshal(data, &badges)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowInfo -> Error unmarshal badges")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Unlocked badges are ticked, with the day they were unlocked
+	fmt.Fprintln(utility.Stdout, "Badges:")
+	for _, badge := range badges {
+		if badge.UnlockedAt != nil {
+			fmt.Fprintf(utility.Stdout, "	[x] %s - %s (%s)\n", badge.Name, badge.Description, badge.UnlockedAt.Local().Format("2006-01-02"))
+		} else {
+			fmt.Fprintf(utility.Stdout, "	[ ] %s - %s\n", badge.Name, badge.Description)
+		}
 	}
 }
 
 func Logout() {
-	var data []byte = make([]byte, 0)
-	err := os.WriteFile(creFilePath, data, 0644)
+	//Revoke the session on server, best effort: the local credential is cleared anyway
+	data, err := os.ReadFile(config.CredentialPath())
+	if err == nil && len(data) > 0 {
+		var credential model.Credential
+		err = json.Unmarshal(data, &credential)
+		if err == nil {
+			url := config.URL("/logout")
+			req, err := http.NewRequest("POST", url, nil)
+			if err == nil {
+				req.Header.Set("token", credential.Token)
+				client := config.Client()
+				resp, err := client.Do(req)
+				if err == nil {
+					resp.Body.Close()
+				}
+			}
+		}
+	}
+
+	//Clear local credential
+	data = make([]byte, 0)
+	err = os.WriteFile(config.CredentialPath(), data, config.CredentialPerm)
 	if err != nil {
-		fmt.Println("Error at: Logout -> Error update credential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Logout -> Error update credential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 }
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gobank/config"
+	"gobank/model"
+	"gobank/utility"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// expiryMargin is how long before it expires an access token is already refreshed, so it does
+// not expire on the way to the server
+const expiryMargin = 30 * time.Second
+
+// TokenExpired tells whether the access token has expired or is about to. Only its exp claim is
+// read, the server checks the rest. A token that cannot be read counts as expired
+func TokenExpired(token string) bool {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return true
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(segments[1])
+	if err != nil {
+		return true
+	}
+
+	var claims struct {
+		ExpiredAt int64 `json:"exp"`
+	}
+	err = json.Unmarshal(data, &claims)
+	if err != nil || claims.ExpiredAt == 0 {
+		return true
+	}
+
+	return time.Now().Add(expiryMargin).After(time.Unix(claims.ExpiredAt, 0))
+}
+
+// Refresh trades the saved refresh token for a new pair of tokens and fresh info, and saves
+// them. Each refresh rotates the refresh token, so it is only done once the access token
+// expired. ok is false when no new token was received, the user is logged out when their
+// session is over
+func Refresh() (model.Credential, bool, error) {
+	var credential model.Credential
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil || len(data) == 0 {
+		return credential, false, err
+	}
+
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		return credential, false, err
+	}
+
+	//Old credential without refresh token cannot be refreshed, user has to log in again
+	if credential.RefreshToken == "" {
+		fmt.Fprintln(utility.Stdout, "Your session has expired! Please log in again")
+		Logout()
+		return credential, false, nil
+	}
+
+	//Trade refresh token for a new access token and new credential data
+	data, err = json.MarshalIndent(credential.RefreshToken, "", " ")
+	if err != nil {
+		return credential, false, err
+	}
+	url := config.URL("/refresh")
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+	if err != nil {
+		return credential, false, err
+	}
+	client := config.Client()
+	resp, err := client.Do(req)
+	if err != nil {
+		return credential, false, err
+	}
+	defer resp.Body.Close()
+
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return credential, false, err
+	}
+
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, data)
+		if utility.MustLogin(problem) {
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			Logout()
+			return credential, false, nil
+		}
+
+		fmt.Fprintln(utility.Stdout, "Failed to refresh credential from server")
+		return credential, false, nil
+	}
+
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		return credential, false, err
+	}
+
+	//Write data to credential.json
+	err = os.WriteFile(config.CredentialPath(), data, config.CredentialPerm)
+	if err != nil {
+		return credential, false, err
+	}
+
+	return credential, true, nil
+}
+
+// SyncInfo saves the user's up-to-date information from the server, without refreshing their
+// tokens
+func SyncInfo(token string) error {
+	//Make new request
+	url := config.URL("/info")
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("token", token)
+	client := config.Client()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, data)
+		if utility.MustLogin(problem) {
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			Logout()
+			return nil
+		}
+
+		fmt.Fprintln(utility.Stdout, "Failed to synchronize data from server")
+		return nil
+	}
+
+	var info model.Info
+	err = json.Unmarshal(data, &info)
+	if err != nil {
+		return err
+	}
+
+	return SaveInfo(info)
+}
+
+// SaveInfo saves the user's info in the credential file. The saved tokens are kept, a request
+// may have refreshed them since the credential was read
+func SaveInfo(info model.Info) error {
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		return err
+	}
+
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		return err
+	}
+
+	credential.Info = info
+	data, err = json.MarshalIndent(credential, "", " ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(config.CredentialPath(), data, config.CredentialPerm)
+}
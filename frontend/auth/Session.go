@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gobank/config"
+	"gobank/model"
+	"gobank/utility"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func ListSessions() {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListSessions -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListSessions -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Make new request
+	url := config.URL("/sessions")
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListSessions -> Error making new request")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	req.Header.Set("token", credential.Token)
+	client := config.Client()
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListSessions -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	//Handle each respond status
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListSessions -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		var sessions []model.Session
+		err = json.Unmarshal(data, &sessions)
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: ListSessions -> Error unmarshal sessions")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+
+		//Display sessions as a table, the current one is marked with *
+		writer := tabwriter.NewWriter(utility.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "\tID\tDEVICE\tSIGNED IN\tLAST USED")
+		for _, session := range sessions {
+			current := ""
+			if session.Current {
+				current = "*"
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n",
+				current,
+				session.ID,
+				session.Device,
+				session.CreatedAt.Format("2006-01-02 15:04"),
+				session.LastUsedAt.Format("2006-01-02 15:04"),
+			)
+		}
+		writer.Flush()
+	}
+}
+
+func RevokeSession(sessionID string) {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RevokeSession -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RevokeSession -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Package data before sending to server
+	data, err = json.MarshalIndent(sessionID, "", " ")
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RevokeSession -> Error marshal session ID")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Make new request
+	url := config.URL("/sessions/revoke")
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RevokeSession -> Error making new request")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	req.Header.Set("token", credential.Token)
+	client := config.Client()
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RevokeSession -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	//Handle each respond status
+	message, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RevokeSession -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, message)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		//Revoking the current session is the same as logging out
+		if sessionID == currentSessionID(credential.RefreshToken) {
+			Logout()
+		}
+		fmt.Fprintln(utility.Stdout, "Session revoked successfully")
+	}
+}
+
+// currentSessionID reads the session ID in front of the refresh token
+func currentSessionID(refreshToken string) string {
+	sessionID, _, _ := strings.Cut(refreshToken, ".")
+	return sessionID
+}
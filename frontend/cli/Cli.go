@@ -0,0 +1,421 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"gobank/auth"
+	"gobank/config"
+	"gobank/model"
+	"gobank/user"
+	"gobank/utility"
+	"os"
+	"strings"
+)
+
+func intializeDataFile() error {
+	dirPath, filePath := config.Get().DataDir, config.CredentialPath()
+
+	//Create data folder
+	if _, err := os.Stat(dirPath); err != nil {
+		err = os.MkdirAll(dirPath, config.DataDirPerm)
+		if err != nil {
+			return err
+		}
+	}
+
+	//Create credential.json file, one made by an older version may still be readable by others
+	if _, err := os.Stat(filePath); err != nil {
+		return os.WriteFile(filePath, nil, config.CredentialPerm)
+	}
+
+	return os.Chmod(filePath, config.CredentialPerm)
+}
+
+func syncData() error {
+	//Check if client has been logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	//Unmarshal credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		return err
+	}
+
+	//Refreshing rotates the refresh token, doing it on every command would make two commands
+	//run at once look like a stolen token to the server. It is only done once the access token
+	//expired, until then only the information is read again
+	if credential.RefreshToken == "" || auth.TokenExpired(credential.Token) {
+		_, _, err = auth.Refresh()
+		return err
+	}
+
+	return auth.SyncInfo(credential.Token)
+}
+
+func welcome() {
+	//Read data from credential.json
+	filePath := config.CredentialPath()
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: welcome -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	//Check if data is empty
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "Welcome to Gobank! Log in to continue with our service. Or create new account if you are new here")
+		fmt.Fprintln(utility.Stdout, "Run './gobank help' for further assistant")
+		return
+	}
+	//If data is not empty
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: welcome -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	fmt.Fprintf(utility.Stdout, "Welcome back, %s\n", credential.Info.Fullname)
+}
+
+// Run executes one command. args are the program's arguments, starting with the program name
+// like os.Args, so the e2e tests can drive the CLI in-process
+func Run(programArgs []string) {
+	//Load config from defaults, config file, environment variables and global flags
+	rest, err := config.Load(programArgs[1:])
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: Run -> Invalid configuration")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	args := append([]string{programArgs[0]}, rest...)
+
+	//Intialize data folder
+	err = intializeDataFile()
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: Run -> Error intialize folders")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Read user's information again every time user issue a command
+	err = syncData()
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: Run -> Error synchronize data from server")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//if len(args) == 1; call welcome()
+	if len(args) == 1 {
+		welcome()
+		return
+	}
+
+	/*----If len(args) != 1----*/
+
+	//auth function (both user and admin)
+	command := strings.ToLower(args[1])
+
+	if command == "register" || command == "regs" {
+		if len(args) == 2 {
+			fmt.Fprintln(utility.Stdout, "Missing argument")
+			return
+		}
+
+		if len(args) == 3 {
+			flag := strings.ToLower(args[2])
+			if flag == "--admin" {
+				auth.Register("admin")
+				return
+			}
+
+			if flag == "--user" {
+				auth.Register("user")
+				return
+			}
+
+			fmt.Fprintln(utility.Stdout, "Invalid argument")
+			return
+		}
+
+		if len(args) > 3 {
+			fmt.Fprintln(utility.Stdout, "Too many arguments")
+			return
+		}
+	}
+
+	if command == "login" {
+		if len(args) == 2 {
+			fmt.Fprintln(utility.Stdout, "Missing arguments")
+			return
+		}
+
+		if len(args) == 3 {
+			flag := strings.ToLower(args[2])
+			if flag == "--admin" {
+				auth.Login("admin")
+				return
+			}
+
+			if flag == "--user" {
+				auth.Login("user")
+				return
+			}
+
+			fmt.Fprintln(utility.Stdout, "Invalid argument")
+			return
+		}
+
+		if len(args) > 3 {
+			fmt.Fprintln(utility.Stdout, "Too many arguments")
+			return
+		}
+	}
+
+	if command == "update-password" || command == "upt-pass" {
+		if len(args) > 2 {
+			fmt.Fprintln(utility.Stdout, "Too many arguments")
+			return
+		}
+
+		auth.UpdatePassword()
+		return
+	}
+
+	if command == "logout" {
+		if len(args) > 2 {
+			fmt.Fprintln(utility.Stdout, "Too many arguments")
+			return
+		}
+		auth.Logout()
+		return
+	}
+
+	if command == "sessions" {
+		if len(args) == 2 {
+			fmt.Fprintln(utility.Stdout, "Missing argument")
+			return
+		}
+
+		subcommand := strings.ToLower(args[2])
+		if subcommand == "list" {
+			if len(args) > 3 {
+				fmt.Fprintln(utility.Stdout, "Too many arguments")
+				return
+			}
+			auth.ListSessions()
+			return
+		}
+
+		if subcommand == "revoke" {
+			if len(args) == 3 {
+				fmt.Fprintln(utility.Stdout, "Missing session ID")
+				return
+			}
+			if len(args) > 4 {
+				fmt.Fprintln(utility.Stdout, "Too many arguments")
+				return
+			}
+			auth.RevokeSession(args[3])
+			return
+		}
+
+		fmt.Fprintln(utility.Stdout, "Invalid argument")
+		return
+	}
+
+	if command == "show-info" {
+		if len(args) > 2 {
+			fmt.Fprintln(utility.Stdout, "Too many arguments")
+			return
+		}
+
+		auth.ShowInfo()
+		return
+	}
+
+	//user function
+	if command == "perks" {
+		if len(args) > 2 {
+			fmt.Fprintln(utility.Stdout, "Too many arguments")
+			return
+		}
+
+		user.ShowPerks()
+		return
+	}
+
+	if command == "rewards" {
+		if len(args) == 2 {
+			user.ShowRewards()
+			return
+		}
+
+		subcommand := strings.ToLower(args[2])
+		if subcommand == "history" {
+			if len(args) > 3 {
+				fmt.Fprintln(utility.Stdout, "Too many arguments")
+				return
+			}
+			user.RewardHistory()
+			return
+		}
+
+		if subcommand == "redeem" {
+			user.RedeemPoints(args[3:])
+			return
+		}
+
+		fmt.Fprintln(utility.Stdout, "Invalid argument")
+		return
+	}
+
+	if command == "accounts" {
+		if len(args) == 2 {
+			fmt.Fprintln(utility.Stdout, "Missing argument")
+			return
+		}
+
+		subcommand := strings.ToLower(args[2])
+		if subcommand == "list" || subcommand == "open" {
+			if len(args) > 3 {
+				fmt.Fprintln(utility.Stdout, "Too many arguments")
+				return
+			}
+			if subcommand == "list" {
+				user.ListAccounts()
+			} else {
+				user.OpenAccount()
+			}
+			return
+		}
+
+		if subcommand == "close" {
+			if len(args) == 3 {
+				fmt.Fprintln(utility.Stdout, "Missing account number")
+				return
+			}
+			if len(args) > 4 {
+				fmt.Fprintln(utility.Stdout, "Too many arguments")
+				return
+			}
+			user.CloseAccount(args[3])
+			return
+		}
+
+		fmt.Fprintln(utility.Stdout, "Invalid argument")
+		return
+	}
+
+	if command == "payees" {
+		if len(args) == 2 {
+			fmt.Fprintln(utility.Stdout, "Missing argument")
+			return
+		}
+
+		subcommand := strings.ToLower(args[2])
+		if subcommand == "list" || subcommand == "add" {
+			if len(args) > 3 {
+				fmt.Fprintln(utility.Stdout, "Too many arguments")
+				return
+			}
+			if subcommand == "list" {
+				user.ListPayees()
+			} else {
+				user.AddPayee()
+			}
+			return
+		}
+
+		if subcommand == "edit" || subcommand == "delete" {
+			if len(args) == 3 {
+				fmt.Fprintln(utility.Stdout, "Missing payee ID")
+				return
+			}
+			if len(args) > 4 {
+				fmt.Fprintln(utility.Stdout, "Too many arguments")
+				return
+			}
+			if subcommand == "edit" {
+				user.EditPayee(args[3])
+			} else {
+				user.DeletePayee(args[3])
+			}
+			return
+		}
+
+		fmt.Fprintln(utility.Stdout, "Invalid argument")
+		return
+	}
+
+	if command == "schedule" {
+		if len(args) == 2 {
+			fmt.Fprintln(utility.Stdout, "Missing argument")
+			return
+		}
+
+		subcommand := strings.ToLower(args[2])
+		if subcommand == "create" {
+			user.CreateSchedule(args[3:])
+			return
+		}
+
+		if subcommand == "list" {
+			if len(args) > 3 {
+				fmt.Fprintln(utility.Stdout, "Too many arguments")
+				return
+			}
+			user.ListSchedules()
+			return
+		}
+
+		if subcommand == "cancel" {
+			if len(args) == 3 {
+				fmt.Fprintln(utility.Stdout, "Missing schedule ID")
+				return
+			}
+			if len(args) > 4 {
+				fmt.Fprintln(utility.Stdout, "Too many arguments")
+				return
+			}
+			user.CancelSchedule(args[3])
+			return
+		}
+
+		fmt.Fprintln(utility.Stdout, "Invalid argument")
+		return
+	}
+
+	//Money commands take --account (or --from) to pick one of the user's accounts
+	if command == "topup" {
+		user.Topup(args[2:])
+		return
+	}
+
+	if command == "withdraw" {
+		user.Withdraw(args[2:])
+		return
+	}
+
+	if command == "make-transaction" || command == "mktrs" {
+		user.MakeTransaction(args[2:])
+		return
+	}
+
+	if command == "get-transactions" || command == "gettrs" {
+		user.GetTransactions(args[2:])
+		return
+	}
+	//admin function
+
+	/*Unsupported command*/
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config is built in layers, each one overriding the previous: defaults, then the JSON config
+// file, then GOBANK_* environment variables, then flags given before the command
+type Config struct {
+	ServerURL string   `json:"server_url"`
+	Timeout   Duration `json:"timeout"`
+	DataDir   string   `json:"data_dir"`
+}
+
+// Duration is a time.Duration written as a string ("10s") in the config file
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	err := json.Unmarshal(data, &text)
+	if err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %w", err)
+	}
+
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+}
+
+const defaultConfigPath = "./data/config.json"
+
+var current = Default()
+
+func Default() Config {
+	return Config{
+		ServerURL: "http://localhost:8800",
+		Timeout:   Duration(10 * time.Second),
+		DataDir:   "./data",
+	}
+}
+
+// Load builds the config from every layer, validates it and makes it the current config.
+// It returns the arguments left after the global flags, starting with the command
+func Load(args []string) ([]string, error) {
+	cfg := Default()
+
+	//Global flags come before the command, e.g. gobank --server https://bank.example.com login --user
+	fs := flag.NewFlagSet("gobank", flag.ContinueOnError)
+	path := fs.String("config", "", "path to a JSON config file")
+	server := fs.String("server", "", "URL of the Gobank server")
+	timeout := fs.Duration("timeout", 0, "timeout of every request to the server")
+	dataDir := fs.String("data-dir", "", "folder where the credential is kept")
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	//Config file, the default one is optional
+	filePath := os.Getenv("GOBANK_CLI_CONFIG")
+	if *path != "" {
+		filePath = *path
+	}
+	if filePath == "" {
+		if _, err := os.Stat(defaultConfigPath); err == nil {
+			filePath = defaultConfigPath
+		}
+	}
+	if filePath != "" {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+		err = json.Unmarshal(data, &cfg)
+		if err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", filePath, err)
+		}
+	}
+
+	//Environment variables
+	if value, ok := os.LookupEnv("GOBANK_SERVER_URL"); ok {
+		cfg.ServerURL = value
+	}
+	if value, ok := os.LookupEnv("GOBANK_TIMEOUT"); ok {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, errors.New("GOBANK_TIMEOUT must be a duration like 10s")
+		}
+		cfg.Timeout = Duration(duration)
+	}
+	if value, ok := os.LookupEnv("GOBANK_DATA_DIR"); ok {
+		cfg.DataDir = value
+	}
+
+	//Flags
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "server":
+			cfg.ServerURL = *server
+		case "timeout":
+			cfg.Timeout = Duration(*timeout)
+		case "data-dir":
+			cfg.DataDir = *dataDir
+		}
+	})
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ServerURL = strings.TrimRight(cfg.ServerURL, "/")
+	current = cfg
+	return fs.Args(), nil
+}
+
+func (cfg Config) Validate() error {
+	var errs []error
+
+	serverURL, err := url.Parse(cfg.ServerURL)
+	if err != nil || (serverURL.Scheme != "http" && serverURL.Scheme != "https") || serverURL.Host == "" {
+		errs = append(errs, fmt.Errorf("server URL %q must be an http or https URL", cfg.ServerURL))
+	}
+	if cfg.Timeout <= 0 {
+		errs = append(errs, errors.New("request timeout must be positive"))
+	}
+	if cfg.DataDir == "" {
+		errs = append(errs, errors.New("data folder is required"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func Get() Config {
+	return current
+}
+
+// URL returns the full URL of a server endpoint, path starts with "/"
+func URL(path string) string {
+	return current.ServerURL + path
+}
+
+// The credential holds the user's tokens, only its owner may read the file and its folder
+const (
+	DataDirPerm    = 0700
+	CredentialPerm = 0600
+)
+
+// CredentialPath is the file where the logged in user's credential is kept
+func CredentialPath() string {
+	return filepath.Join(current.DataDir, "credential.json")
+}
+
+// Client returns the HTTP client every request to the server goes through
+func Client() *http.Client {
+	return &http.Client{Timeout: time.Duration(current.Timeout)}
+}
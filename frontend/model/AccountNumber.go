@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Account numbers end with two ISO 7064 MOD 97-10 check digits, so the CLI can catch a typo
+// before asking the server. Numbers given out before check digits existed are 9 digits long
+const (
+	legacyDigits = 9
+	minDigits    = 11
+	maxDigits    = 17
+)
+
+type InvalidAccountNumberError struct {
+	Number string
+	Reason string
+}
+
+func (e InvalidAccountNumberError) Error() string {
+	return fmt.Sprintf("Invalid account number %q: %s", e.Number, e.Reason)
+}
+
+func ValidateAccountNumber(number string) error {
+	if number == "" {
+		return InvalidAccountNumberError{Number: number, Reason: "account number is empty"}
+	}
+	if strings.Trim(number, "0123456789") != "" {
+		return InvalidAccountNumberError{Number: number, Reason: "account number must only contain digits"}
+	}
+
+	//Legacy numbers have no check digits
+	if len(number) == legacyDigits && number[0] != '0' {
+		return nil
+	}
+
+	if len(number) < minDigits || len(number) > maxDigits {
+		return InvalidAccountNumberError{Number: number, Reason: "account number has the wrong length"}
+	}
+
+	remainder := 0
+	for _, digit := range number {
+		remainder = (remainder*10 + int(digit-'0')) % 97
+	}
+	if remainder != 1 {
+		return InvalidAccountNumberError{Number: number, Reason: "check digits do not match"}
+	}
+
+	return nil
+}
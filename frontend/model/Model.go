@@ -3,13 +3,24 @@ package model
 import "time"
 
 type User struct {
-	ID       string  `json:"id"`
-	Email    string  `json:"email"`
-	Password string  `json:"password"`
-	Fullname string  `json:"fullname"`
-	Balance  float64 `json:"balance"`
-	Exp      int     `json:"exp"`
-	State    string  `json:"state"`
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Fullname string `json:"fullname"`
+	Exp      int    `json:"exp"`
+	State    string `json:"state"`
+}
+
+type Account struct {
+	ID       string     `json:"id"`
+	Customer string     `json:"customer"`
+	Type     string     `json:"type"`
+	Name     string     `json:"name"`
+	Balance  Money      `json:"balance"`
+	Currency Currency   `json:"currency"`
+	State    string     `json:"state"`
+	OpenedAt time.Time  `json:"opened at"`
+	ClosedAt *time.Time `json:"closed at,omitempty"`
 }
 
 type Admin struct {
@@ -20,24 +31,164 @@ type Admin struct {
 }
 
 type Info struct {
-	ID       string  `json:"id"`
-	Fullname string  `json:"fullname"`
-	Role     string  `json:"role"`
-	Balance  float64 `json:"balance"`
-	Level    int     `json:"level"`
-	Exp      int     `json:"exp"`
+	ID       string    `json:"id"`
+	Fullname string    `json:"fullname"`
+	Role     string    `json:"role"`
+	Balance  Money     `json:"balance"`
+	Currency Currency  `json:"currency"`
+	Level    int       `json:"level"`
+	Exp      int       `json:"exp"`
+	LevelMin int       `json:"level min"` //EXP the level starts at
+	LevelMax int       `json:"level max"` //EXP the next level starts at, 0 at the max level
+	Accounts []Account `json:"accounts"`
+}
+
+// Badge is an achievement, UnlockedAt is nil while it is locked
+type Badge struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	UnlockedAt  *time.Time `json:"unlocked at,omitempty"`
 }
 
 type Credential struct {
-	Token string `json:"token"`
-	Info  Info   `json:"info"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh token"`
+	Info         Info   `json:"info"`
+}
+
+type Session struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	CreatedAt  time.Time `json:"created at"`
+	LastUsedAt time.Time `json:"last used at"`
+	ExpiresAt  time.Time `json:"expires at"`
+	Current    bool      `json:"current"`
 }
 
 type Transaction struct {
+	ID            int64     `json:"id"`
+	Type          string    `json:"type"`
 	Date          time.Time `json:"date transfer"`
 	DebitAccount  string    `json:"debit account"`
 	CreditAccount string    `json:"credit account"`
 	Beneficiary   string    `json:"beneficiary"`
-	Amount        float64   `json:"amount"`
+	Amount        Money     `json:"amount"`
+	Currency      Currency  `json:"currency"`
 	Description   string    `json:"description"`
+	//What the credit account received, converted at Rate when the currencies differ
+	CreditAmount   Money    `json:"credit amount"`
+	CreditCurrency Currency `json:"credit currency"`
+	Rate           string   `json:"rate,omitempty"`
+	QuoteID        string   `json:"quote id,omitempty"`
+}
+
+// Quote is an exchange rate the server locked for one transfer until ExpiresAt
+type Quote struct {
+	ID            string    `json:"id"`
+	DebitAccount  string    `json:"debit account"`
+	CreditAccount string    `json:"credit account"`
+	From          Currency  `json:"from"`
+	To            Currency  `json:"to"`
+	Rate          string    `json:"rate,omitempty"`
+	Fee           string    `json:"fee,omitempty"` //Percentage already taken off Rate
+	Amount        Money     `json:"amount"`
+	Converted     Money     `json:"converted"`
+	ExpiresAt     time.Time `json:"expires at"`
+}
+
+// Payee is an account the user saved to send money to, Name is its owner's name checked by the
+// server. Only a limited amount can be sent to it until CoolingOffUntil
+type Payee struct {
+	ID              int64      `json:"id"`
+	Nickname        string     `json:"nickname"`
+	Account         string     `json:"account"`
+	Name            string     `json:"name"`
+	AddedAt         time.Time  `json:"added at"`
+	LastUsedAt      *time.Time `json:"last used at,omitempty"`
+	CoolingOffUntil time.Time  `json:"cooling off until"`
+	CoolingOffSent  Money      `json:"cooling off sent"`
+}
+
+// Schedule is a transfer the server makes later, once or every day, week or month
+type Schedule struct {
+	ID            int64      `json:"id"`
+	DebitAccount  string     `json:"debit account"`
+	CreditAccount string     `json:"credit account"`
+	Amount        Money      `json:"amount"`
+	Currency      Currency   `json:"currency"`
+	Description   string     `json:"description"`
+	Frequency     string     `json:"frequency"`
+	Day           int        `json:"day,omitempty"`
+	NextRun       time.Time  `json:"next run"`
+	RetryAt       *time.Time `json:"retry at,omitempty"`
+	Attempts      int        `json:"attempts"`
+	State         string     `json:"state"`
+	LastError     string     `json:"last error,omitempty"`
+	CreatedAt     time.Time  `json:"created at"`
+}
+
+// Tier is the perks of the levels from MinLevel to MaxLevel, no upper bound when MaxLevel is 0.
+// Limits apply to each account in its own currency, 0 means no limit. Percentages are strings
+// like "0.25"
+type Tier struct {
+	Name          string `json:"name"`
+	MinLevel      int    `json:"min level"`
+	MaxLevel      int    `json:"max level,omitempty"`
+	TransferLimit Money  `json:"daily transfer limit"`
+	WithdrawLimit Money  `json:"daily withdraw limit"`
+	FeeDiscount   string `json:"fee discount"`
+	Cashback      string `json:"cashback"`
+	RewardPoints  int    `json:"reward points"`
+}
+
+// Perks is the user's level, the EXP range of that level and what its tier gives. LevelMax is 0
+// at the max level
+type Perks struct {
+	Level    int   `json:"level"`
+	Exp      int   `json:"exp"`
+	LevelMin int   `json:"level min"`
+	LevelMax int   `json:"level max"`
+	Tier     Tier  `json:"tier"`
+	Next     *Tier `json:"next tier,omitempty"`
+}
+
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next cursor"`
+}
+
+// Problem is an RFC 7807 problem details body. Code is stable and meant for programs, Detail is
+// meant for people
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+}
+
+// RewardWallet is the user's reward points, kept apart from the accounts' balance. NextExpiry
+// is when the points expiring first, Expiring of them, are lost
+type RewardWallet struct {
+	Points     int        `json:"points"`
+	Value      Money      `json:"value"`
+	Expiring   int        `json:"expiring,omitempty"`
+	NextExpiry *time.Time `json:"next expiry,omitempty"`
+}
+
+// RewardEntry is a change of the reward points: "earn", "redeem" or "expire"
+type RewardEntry struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"`
+	Points    int        `json:"points"`
+	Reference string     `json:"reference"`
+	At        time.Time  `json:"at"`
+	ExpiresAt *time.Time `json:"expires at,omitempty"`
+}
+
+type Redemption struct {
+	Points  int   `json:"points"`
+	Amount  Money `json:"amount"`
+	Balance Money `json:"balance"`
 }
@@ -0,0 +1,385 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gobank/auth"
+	"gobank/config"
+	"gobank/model"
+	"gobank/utility"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func ListAccounts() {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListAccounts -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListAccounts -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Make new request
+	url := config.URL("/accounts")
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListAccounts -> Error making new request")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	req.Header.Set("token", credential.Token)
+	client := config.Client()
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListAccounts -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	//Handle each respond status
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListAccounts -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		var accounts []model.Account
+		err = json.Unmarshal(data, &accounts)
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: ListAccounts -> Error unmarshal accounts")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+
+		//Display accounts as a table, the default one is marked with *
+		writer := tabwriter.NewWriter(utility.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "\tID\tTYPE\tNAME\tBALANCE\tSTATE\tOPENED")
+		for _, account := range accounts {
+			mark := ""
+			if account.ID == credential.Info.ID {
+				mark = "*"
+			}
+			name := account.Name
+			if name == "" {
+				name = "-"
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				mark,
+				account.ID,
+				account.Type,
+				name,
+				account.Balance.Format(account.Currency),
+				account.State,
+				account.OpenedAt.Format("2006-01-02"),
+			)
+		}
+		writer.Flush()
+	}
+}
+
+func OpenAccount() {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	var (
+		account model.Account
+		isValid bool
+		reader  = utility.Stdin
+	)
+
+	//Ask for account's type
+	for !isValid {
+		fmt.Fprint(utility.Stdout, "Enter account type (checking/savings/pot): ")
+		account.Type, err = reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error reading account type from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		account.Type = strings.ToLower(strings.TrimSpace(account.Type))
+
+		isValid = account.Type == "checking" || account.Type == "savings" || account.Type == "pot"
+		if !isValid {
+			fmt.Fprintln(utility.Stdout, "Account type must be checking, savings or pot")
+		}
+	}
+
+	//Ask for account's name, pots must have one
+	isValid = false
+	for !isValid {
+		if account.Type == "pot" {
+			fmt.Fprint(utility.Stdout, "Enter pot's name: ")
+		} else {
+			fmt.Fprint(utility.Stdout, "Enter account name (optional): ")
+		}
+		account.Name, err = reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error reading account name from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		account.Name = strings.TrimSpace(account.Name)
+
+		if account.Type == "pot" && account.Name == "" {
+			fmt.Fprintln(utility.Stdout, "A pot must have a name")
+			continue
+		}
+		if len(account.Name) > 30 {
+			fmt.Fprintln(utility.Stdout, "Account name must have at most 30 characters")
+			continue
+		}
+		isValid = true
+	}
+
+	//Ask for account's currency, the server tells which ones it supports
+	isValid = false
+	for !isValid {
+		fmt.Fprintf(utility.Stdout, "Enter currency (default %s): ", model.DefaultCurrency)
+		currency, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error reading currency from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		account.Currency = model.Currency(strings.ToUpper(strings.TrimSpace(currency)))
+		if account.Currency == "" {
+			account.Currency = model.DefaultCurrency
+		}
+
+		isValid = len(account.Currency) == 3 && strings.Trim(string(account.Currency), "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == ""
+		if !isValid {
+			fmt.Fprintln(utility.Stdout, "Currency must be a 3-letter ISO 4217 code such as USD or EUR")
+		}
+	}
+
+	//Package data
+	data, err = json.MarshalIndent(account, "", " ")
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error marshal data")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Make new request
+	url := config.URL("/accounts/open")
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error making new request")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	req.Header.Set("token", credential.Token)
+	client := config.Client()
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	//Handle each respond status
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if resp.StatusCode == http.StatusCreated {
+		err = json.Unmarshal(data, &account)
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: OpenAccount -> Error unmarshal account")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		fmt.Fprintf(utility.Stdout, "Account %s opened successfully in %s\n", account.ID, account.Currency)
+	}
+}
+
+func CloseAccount(id string) {
+	//Catch typos before asking the server
+	if model.ValidateAccountNumber(id) != nil {
+		fmt.Fprintln(utility.Stdout, "Invalid account number, please check it again")
+		return
+	}
+
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CloseAccount -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CloseAccount -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Package data
+	data, err = json.MarshalIndent(id, "", " ")
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CloseAccount -> Error marshal data")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Make new request
+	url := config.URL("/accounts/close")
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CloseAccount -> Error making new request")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	req.Header.Set("token", credential.Token)
+	client := config.Client()
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CloseAccount -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	//Handle each respond status
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CloseAccount -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeAccountNotFound:
+			fmt.Fprintln(utility.Stdout, "You have no account with this number")
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		fmt.Fprintln(utility.Stdout, "Account closed successfully")
+	}
+}
+
+// pickAccount finds one of the open accounts in the credential. An empty number picks the
+// checking account opened at registration. It tells the user when there is no such account
+func pickAccount(credential model.Credential, number string) (model.Account, bool) {
+	if number == "" {
+		number = credential.Info.ID
+	}
+
+	for _, account := range credential.Info.Accounts {
+		if account.ID == number {
+			return account, true
+		}
+	}
+
+	fmt.Fprintf(utility.Stdout, "You have no open account with number %s, run './gobank accounts list' to see your accounts\n", number)
+	return model.Account{}, false
+}
+
+// setBalance saves the balance the server computed for one account, and the new total of the
+// accounts held in the credential's currency
+func setBalance(credential *model.Credential, number string, balance model.Money) {
+	credential.Info.Balance = 0
+	for i := range credential.Info.Accounts {
+		if credential.Info.Accounts[i].ID == number {
+			credential.Info.Accounts[i].Balance = balance
+		}
+		if credential.Info.Accounts[i].Currency == credential.Info.Currency {
+			credential.Info.Balance += credential.Info.Accounts[i].Balance
+		}
+	}
+}
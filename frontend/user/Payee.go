@@ -0,0 +1,347 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"gobank/auth"
+	"gobank/config"
+	"gobank/model"
+	"gobank/utility"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func ListPayees() {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListPayees -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListPayees -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	payees, ok := fetchPayees(credential.Token)
+	if !ok {
+		return
+	}
+
+	if len(payees) == 0 {
+		fmt.Fprintln(utility.Stdout, "You have no saved payees")
+		return
+	}
+
+	//Display payees as a table, new ones show when their cooling-off period ends
+	writer := tabwriter.NewWriter(utility.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tNICKNAME\tACCOUNT\tNAME\tLAST USED\tCOOLING OFF")
+	for _, payee := range payees {
+		lastUsed := "-"
+		if payee.LastUsedAt != nil {
+			lastUsed = payee.LastUsedAt.Local().Format("2006-01-02")
+		}
+		coolingOff := "-"
+		if time.Now().Before(payee.CoolingOffUntil) {
+			coolingOff = "until " + payee.CoolingOffUntil.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			payee.ID,
+			payee.Nickname,
+			payee.Account,
+			payee.Name,
+			lastUsed,
+			coolingOff,
+		)
+	}
+	writer.Flush()
+}
+
+func AddPayee() {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: AddPayee -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: AddPayee -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	var (
+		account string
+		isValid bool
+		reader  = utility.Stdin
+	)
+
+	//Ask for payee's account, the server checks it exists and who owns it
+	for !isValid {
+		fmt.Fprint(utility.Stdout, "Enter payee's account number: ")
+		account, err = reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: AddPayee -> Error reading account number from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		account = strings.TrimSpace(account)
+
+		isValid = model.ValidateAccountNumber(account) == nil
+		if !isValid {
+			fmt.Fprintln(utility.Stdout, "Invalid account number, please check it again")
+		}
+	}
+
+	nickname, err := readNickname("Enter nickname (optional): ")
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: AddPayee -> Error reading nickname from stdin")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	addPayee(credential.Token, account, nickname)
+}
+
+func EditPayee(id string) {
+	number, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || number <= 0 {
+		fmt.Fprintln(utility.Stdout, "Invalid payee ID, run './gobank payees list' to see your payees")
+		return
+	}
+
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: EditPayee -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: EditPayee -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	var nickname string
+	for nickname == "" {
+		nickname, err = readNickname("Enter new nickname: ")
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: EditPayee -> Error reading nickname from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+	}
+
+	status, data, err := send("POST", "/payees/edit", credential.Token, "", model.Payee{ID: number, Nickname: nickname})
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: EditPayee -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodePayeeNotFound:
+			fmt.Fprintln(utility.Stdout, "You have no payee with this ID")
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if status == http.StatusOK {
+		fmt.Fprintln(utility.Stdout, "Payee renamed successfully")
+	}
+}
+
+func DeletePayee(id string) {
+	number, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || number <= 0 {
+		fmt.Fprintln(utility.Stdout, "Invalid payee ID, run './gobank payees list' to see your payees")
+		return
+	}
+
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: DeletePayee -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: DeletePayee -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	status, data, err := send("POST", "/payees/delete", credential.Token, "", number)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: DeletePayee -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodePayeeNotFound:
+			fmt.Fprintln(utility.Stdout, "You have no payee with this ID")
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if status == http.StatusOK {
+		fmt.Fprintln(utility.Stdout, "Payee deleted successfully")
+	}
+}
+
+// fetchPayees gets the user's payees, most recently used first. It tells the user when they
+// cannot be fetched
+func fetchPayees(token string) ([]model.Payee, bool) {
+	status, data, err := send("GET", "/payees", token, "", nil)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: fetchPayees -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return nil, false
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return nil, false
+	}
+
+	var payees []model.Payee
+	err = json.Unmarshal(data, &payees)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: fetchPayees -> Error unmarshal payees")
+		fmt.Fprintln(utility.Stdout, err)
+		return nil, false
+	}
+
+	return payees, true
+}
+
+// addPayee saves the account as a payee and tells the user how it went
+func addPayee(token, account, nickname string) {
+	status, data, err := send("POST", "/payees/add", token, "", model.Payee{Account: account, Nickname: nickname})
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: addPayee -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeAccountNotFound:
+			fmt.Fprintln(utility.Stdout, "Cannot find any account with this ID")
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if status == http.StatusCreated {
+		var payee model.Payee
+		err = json.Unmarshal(data, &payee)
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: addPayee -> Error unmarshal payee")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		fmt.Fprintf(utility.Stdout, "%s (%s) saved as a payee\n", payee.Nickname, payee.Name)
+		fmt.Fprintf(utility.Stdout, "Transfers to a new payee are limited until %s\n", payee.CoolingOffUntil.Local().Format("2006-01-02 15:04"))
+	}
+}
+
+// readNickname asks for a payee's nickname, at most 30 characters
+func readNickname(prompt string) (string, error) {
+	for {
+		fmt.Fprint(utility.Stdout, prompt)
+		nickname, err := utility.Stdin.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		nickname = strings.TrimSpace(nickname)
+
+		if len(nickname) <= 30 {
+			return nickname, nil
+		}
+		fmt.Fprintln(utility.Stdout, "Nickname must have at most 30 characters")
+	}
+}
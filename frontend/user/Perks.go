@@ -0,0 +1,94 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"gobank/auth"
+	"gobank/config"
+	"gobank/model"
+	"gobank/utility"
+	"net/http"
+	"os"
+)
+
+func ShowPerks() {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowPerks -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowPerks -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	status, data, err := send("GET", "/perks", credential.Token, "", nil)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowPerks -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	var perks model.Perks
+	err = json.Unmarshal(data, &perks)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowPerks -> Error unmarshal perks")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	fmt.Fprintf(utility.Stdout, "Level %d - %s tier\n", perks.Level, perks.Tier.Name)
+	fmt.Fprintf(utility.Stdout, "Exp: %d\n", perks.Exp)
+	fmt.Fprintf(utility.Stdout, "Progress: %s\n", utility.LevelProgress(perks.Level, perks.Exp, perks.LevelMin, perks.LevelMax))
+
+	fmt.Fprintln(utility.Stdout, "Your benefits:")
+	showTier(perks.Tier)
+
+	if perks.Next != nil {
+		fmt.Fprintf(utility.Stdout, "Next tier: %s, from level %d\n", perks.Next.Name, perks.Next.MinLevel)
+		showTier(*perks.Next)
+	}
+}
+
+// showTier prints a tier's limits, per account and in the account's currency, and its rates
+func showTier(tier model.Tier) {
+	limit := func(amount model.Money) string {
+		if amount == 0 {
+			return "no limit"
+		}
+		return amount.String() + " a day per account"
+	}
+
+	fmt.Fprintf(utility.Stdout, "	Transfers: %s\n", limit(tier.TransferLimit))
+	fmt.Fprintf(utility.Stdout, "	Withdrawals: %s\n", limit(tier.WithdrawLimit))
+	fmt.Fprintf(utility.Stdout, "	Conversion fee discount: %s%%\n", tier.FeeDiscount)
+	fmt.Fprintf(utility.Stdout, "	Cashback on transfers: %s%%\n", tier.Cashback)
+	fmt.Fprintf(utility.Stdout, "	Reward points: %d per unit sent to other customers\n", tier.RewardPoints)
+}
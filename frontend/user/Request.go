@@ -0,0 +1,84 @@
+package user
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"gobank/auth"
+	"gobank/config"
+	"gobank/utility"
+	"io"
+	"net/http"
+	"time"
+)
+
+// retryDelays are the waits before each new attempt of a request sent with an idempotency key
+var retryDelays = []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second}
+
+// newIdempotencyKey returns a random key for one operation the user confirmed
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(key), nil
+}
+
+// send sends body as JSON to a server endpoint and returns the respond status and body. With
+// an idempotency key the request is sent again, with the same key, when no respond comes back
+// or while the server is still busy with an earlier attempt. The server answers a retry of a
+// finished request with the first respond, so the money only moves once. A token that expired
+// since the command started is refreshed once and the request sent again with the new one
+func send(method, path, token, key string, body any) (int, []byte, error) {
+	data, err := json.MarshalIndent(body, "", " ")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		status, message, err := sendOnce(method, path, token, key, data)
+		if err == nil && !refreshed && status == http.StatusUnauthorized && utility.ParseProblem(status, message).Code == utility.CodeTokenExpired {
+			refreshed = true
+			credential, ok, refreshErr := auth.Refresh()
+			if refreshErr != nil || !ok {
+				return status, message, refreshErr
+			}
+			token = credential.Token
+			status, message, err = sendOnce(method, path, token, key, data)
+		}
+
+		retry := err != nil
+		if err == nil && status == http.StatusConflict {
+			retry = utility.ParseProblem(status, message).Code == utility.CodeIdempotencyInProgress
+		}
+
+		if key == "" || !retry || attempt == len(retryDelays) {
+			return status, message, err
+		}
+		time.Sleep(retryDelays[attempt])
+	}
+}
+
+func sendOnce(method, path, token, key string, data []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, config.URL(path), bytes.NewBuffer(data))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("token", token)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	resp, err := config.Client().Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err = io.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
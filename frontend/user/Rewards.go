@@ -0,0 +1,265 @@
+package user
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gobank/auth"
+	"gobank/config"
+	"gobank/model"
+	"gobank/utility"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func ShowRewards() {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowRewards -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowRewards -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	status, data, err := send("GET", "/rewards", credential.Token, "", nil)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowRewards -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	var wallet model.RewardWallet
+	err = json.Unmarshal(data, &wallet)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ShowRewards -> Error unmarshal rewards")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	fmt.Fprintf(utility.Stdout, "Reward points: %d (worth %s)\n", wallet.Points, wallet.Value)
+	if wallet.NextExpiry != nil {
+		fmt.Fprintf(utility.Stdout, "%d points expire on %s\n", wallet.Expiring, wallet.NextExpiry.Local().Format("2006-01-02"))
+	}
+	fmt.Fprintln(utility.Stdout, "Run './gobank rewards redeem' to turn them into money")
+}
+
+func RewardHistory() {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RewardHistory -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RewardHistory -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	status, data, err := send("GET", "/rewards/history", credential.Token, "", nil)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RewardHistory -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	var entries []model.RewardEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RewardHistory -> Error unmarshal history")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(utility.Stdout, "You have not earned any reward point yet")
+		return
+	}
+
+	//Earned points show when they expire, redeemed ones the account they went to
+	writer := tabwriter.NewWriter(utility.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DATE\tKIND\tPOINTS\tREFERENCE\tEXPIRES")
+	for _, entry := range entries {
+		expires := "-"
+		if entry.ExpiresAt != nil {
+			expires = entry.ExpiresAt.Local().Format("2006-01-02")
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%+d\t%s\t%s\n",
+			entry.At.Local().Format("2006-01-02 15:04"),
+			entry.Kind,
+			entry.Points,
+			entry.Reference,
+			expires,
+		)
+	}
+	writer.Flush()
+}
+
+func RedeemPoints(args []string) {
+	//Parse flags
+	flags := flag.NewFlagSet("rewards redeem", flag.ContinueOnError)
+	number := flags.String("account", "", "Account to pay the points into, your checking account by default")
+	err := flags.Parse(args)
+	if err != nil {
+		return
+	}
+
+	if flags.NArg() > 0 {
+		fmt.Fprintln(utility.Stdout, "Too many arguments")
+		return
+	}
+
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RedeemPoints -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RedeemPoints -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	account, ok := pickAccount(credential, *number)
+	if !ok {
+		return
+	}
+
+	//Ask for the number of points
+	var points int
+	for points <= 0 {
+		fmt.Fprint(utility.Stdout, "Enter the points to redeem: ")
+		temp, err := utility.Stdin.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: RedeemPoints -> Error reading points from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		points, err = strconv.Atoi(strings.TrimSpace(temp))
+		if err != nil || points <= 0 {
+			fmt.Fprintln(utility.Stdout, "The points must be a whole number greater than 0")
+			points = 0
+		}
+	}
+
+	//One key for this redemption, so retrying it never pays the points twice
+	key, err := newIdempotencyKey()
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RedeemPoints -> Error generating idempotency key")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	status, data, err := send("POST", "/rewards/redeem?account="+account.ID, credential.Token, key, points)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RedeemPoints -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	var redemption model.Redemption
+	err = json.Unmarshal(data, &redemption)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RedeemPoints -> Error unmarshal redemption")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Update balance in credential with the balance the server computed
+	setBalance(&credential, account.ID, redemption.Balance)
+	err = auth.SaveInfo(credential.Info)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: RedeemPoints -> Error update credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	fmt.Fprintf(utility.Stdout, "Redeemed %d points for %s, new balance: %s\n",
+		redemption.Points,
+		redemption.Amount.Format(account.Currency),
+		redemption.Balance.Format(account.Currency),
+	)
+}
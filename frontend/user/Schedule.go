@@ -0,0 +1,399 @@
+package user
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gobank/auth"
+	"gobank/config"
+	"gobank/model"
+	"gobank/utility"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func CreateSchedule(args []string) {
+	//Parse flags
+	flags := flag.NewFlagSet("schedule create", flag.ContinueOnError)
+	from := flags.String("from", "", "Account to pay from, your checking account by default")
+	err := flags.Parse(args)
+	if err != nil {
+		return
+	}
+
+	if flags.NArg() > 0 {
+		fmt.Fprintln(utility.Stdout, "Too many arguments")
+		return
+	}
+
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	account, ok := pickAccount(credential, *from)
+	if !ok {
+		return
+	}
+
+	var (
+		schedule = model.Schedule{DebitAccount: account.ID}
+		isValid  bool
+		reader   = utility.Stdin
+	)
+
+	//Ask for beneficiary's account, the server checks it exists
+	for !isValid {
+		fmt.Fprint(utility.Stdout, "Enter beneficiary account number: ")
+		schedule.CreditAccount, err = reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error reading credit account from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		schedule.CreditAccount = strings.TrimSpace(schedule.CreditAccount)
+
+		isValid = model.ValidateAccountNumber(schedule.CreditAccount) == nil
+		if !isValid {
+			fmt.Fprintln(utility.Stdout, "Invalid account number, please check it again")
+		}
+	}
+
+	//Ask for amount, the balance is only checked on the day of the transfer
+	isValid = false
+	for !isValid {
+		fmt.Fprint(utility.Stdout, "Enter amount of money: ")
+		temp, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error reading amount from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		schedule.Amount, err = model.ParseMoney(strings.TrimSpace(temp))
+
+		isValid = err == nil && schedule.Amount > 0
+		if !isValid {
+			fmt.Fprintln(utility.Stdout, "Invalid value for amount")
+		}
+	}
+
+	//Ask how often the transfer is made
+	isValid = false
+	for !isValid {
+		fmt.Fprint(utility.Stdout, "Enter frequency (once/daily/weekly/monthly/last-business-day): ")
+		schedule.Frequency, err = reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error reading frequency from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		schedule.Frequency = strings.ToLower(strings.TrimSpace(schedule.Frequency))
+
+		switch schedule.Frequency {
+		case "once", "daily", "weekly", "monthly", "last-business-day":
+			isValid = true
+		default:
+			fmt.Fprintln(utility.Stdout, "Frequency must be once, daily, weekly, monthly or last-business-day")
+		}
+	}
+
+	//Ask for the first day, today by default
+	isValid = false
+	for !isValid {
+		fmt.Fprint(utility.Stdout, "Enter first date (YYYY-MM-DD, default today): ")
+		temp, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error reading first date from stdin")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		temp = strings.TrimSpace(temp)
+		if temp == "" {
+			break
+		}
+
+		schedule.NextRun, err = time.Parse("2006-01-02", temp)
+		isValid = err == nil
+		if !isValid {
+			fmt.Fprintln(utility.Stdout, "Date must be in the format YYYY-MM-DD")
+		}
+	}
+
+	//A monthly transfer runs on the first date's day of the month unless told otherwise
+	if schedule.Frequency == "monthly" {
+		isValid = false
+		for !isValid {
+			fmt.Fprint(utility.Stdout, "Enter day of the month (1-31, default the first date's): ")
+			temp, err := reader.ReadString('\n')
+			if err != nil {
+				fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error reading day from stdin")
+				fmt.Fprintln(utility.Stdout, err)
+				return
+			}
+			temp = strings.TrimSpace(temp)
+			if temp == "" {
+				schedule.Day = time.Now().UTC().Day()
+				if !schedule.NextRun.IsZero() {
+					schedule.Day = schedule.NextRun.Day()
+				}
+				break
+			}
+
+			schedule.Day, err = strconv.Atoi(temp)
+			isValid = err == nil && 1 <= schedule.Day && schedule.Day <= 31
+			if !isValid {
+				fmt.Fprintln(utility.Stdout, "Day must be between 1 and 31")
+			}
+		}
+	}
+
+	//Ask for description (no need for a loop since there's no edge cases)
+	fmt.Fprint(utility.Stdout, "Enter transaction's description (optional): ")
+	schedule.Description, err = reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error reading description from stdin")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	schedule.Description = strings.TrimSpace(schedule.Description)
+	if len(schedule.Description) == 0 {
+		schedule.Description = fmt.Sprintf("%s transfer", credential.Info.Fullname)
+	}
+
+	//Ask user for confirmation
+	fmt.Fprintln(utility.Stdout, strings.Repeat("*", 20))
+	fmt.Fprintln(utility.Stdout, "SCHEDULED TRANSFER")
+	fmt.Fprintf(utility.Stdout, "\tDebit account: %s\n", schedule.DebitAccount)
+	fmt.Fprintf(utility.Stdout, "\tCredit account: %s\n", schedule.CreditAccount)
+	fmt.Fprintf(utility.Stdout, "\tAmount: %s\n", schedule.Amount.Format(account.Currency))
+	fmt.Fprintf(utility.Stdout, "\tFrequency: %s\n", describeFrequency(schedule))
+	fmt.Fprintf(utility.Stdout, "\tDescription: %s\n", schedule.Description)
+	for {
+		fmt.Fprint(utility.Stdout, "Confirmed? (Y/N) ")
+		option, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at CreateSchedule -> Error reading user's option")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		option = strings.ToUpper(strings.TrimSpace(option))
+
+		if option == "N" {
+			return
+		}
+		if option == "Y" {
+			break
+		}
+	}
+
+	status, data, err := send("POST", "/schedules/create", credential.Token, "", schedule)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if status == http.StatusCreated {
+		err = json.Unmarshal(data, &schedule)
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: CreateSchedule -> Error unmarshal schedule")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		fmt.Fprintf(utility.Stdout, "Transfer %d scheduled, first run on %s\n", schedule.ID, schedule.NextRun.Format("2006-01-02"))
+	}
+}
+
+func ListSchedules() {
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListSchedules -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListSchedules -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	status, data, err := send("GET", "/schedules", credential.Token, "", nil)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: ListSchedules -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if status == http.StatusOK {
+		var schedules []model.Schedule
+		err = json.Unmarshal(data, &schedules)
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: ListSchedules -> Error unmarshal schedules")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+
+		if len(schedules) == 0 {
+			fmt.Fprintln(utility.Stdout, "You have no scheduled transfers")
+			return
+		}
+
+		//Display schedules as a table, with why the last run failed if it did
+		writer := tabwriter.NewWriter(utility.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tFROM\tTO\tAMOUNT\tFREQUENCY\tNEXT RUN\tSTATE\tLAST ERROR")
+		for _, schedule := range schedules {
+			nextRun := "-"
+			if schedule.State == "active" {
+				nextRun = schedule.NextRun.Format("2006-01-02")
+				if schedule.RetryAt != nil {
+					nextRun = "retry " + schedule.RetryAt.Local().Format("2006-01-02 15:04")
+				}
+			}
+			lastError := schedule.LastError
+			if lastError == "" {
+				lastError = "-"
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				schedule.ID,
+				schedule.DebitAccount,
+				schedule.CreditAccount,
+				schedule.Amount.Format(schedule.Currency),
+				describeFrequency(schedule),
+				nextRun,
+				schedule.State,
+				lastError,
+			)
+		}
+		writer.Flush()
+	}
+}
+
+func CancelSchedule(id string) {
+	number, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || number <= 0 {
+		fmt.Fprintln(utility.Stdout, "Invalid schedule ID, run './gobank schedule list' to see your schedules")
+		return
+	}
+
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CancelSchedule -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CancelSchedule -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	status, data, err := send("POST", "/schedules/cancel", credential.Token, "", number)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: CancelSchedule -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeScheduleNotFound:
+			fmt.Fprintln(utility.Stdout, "You have no active schedule with this ID")
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return
+	}
+
+	if status == http.StatusOK {
+		fmt.Fprintln(utility.Stdout, "Schedule cancelled successfully")
+	}
+}
+
+// describeFrequency says when the schedule runs, e.g. "monthly on day 15"
+func describeFrequency(schedule model.Schedule) string {
+	switch {
+	case schedule.Frequency == "monthly" && schedule.Day > 0:
+		return fmt.Sprintf("monthly on day %d", schedule.Day)
+	case schedule.Frequency == "once" && !schedule.NextRun.IsZero():
+		return "once on " + schedule.NextRun.Format("2006-01-02")
+	}
+	return schedule.Frequency
+}
@@ -1,32 +1,47 @@
 package user
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"gobank/auth"
+	"gobank/config"
 	"gobank/model"
+	"gobank/utility"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 )
 
-var creFilePath string = "./data/credential.json"
+func MakeTransaction(args []string) {
+	//Parse flags
+	flags := flag.NewFlagSet("make-transaction", flag.ContinueOnError)
+	from := flags.String("from", "", "Account to pay from, your checking account by default")
+	err := flags.Parse(args)
+	if err != nil {
+		return
+	}
+
+	if flags.NArg() > 0 {
+		fmt.Fprintln(utility.Stdout, "Too many arguments")
+		return
+	}
 
-func MakeTransaction() {
 	//Check if client has logged in
-	data, err := os.ReadFile(creFilePath)
+	data, err := os.ReadFile(config.CredentialPath())
 	if err != nil {
-		fmt.Println("Error at: MakeTransaction -> Error reading credential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
 	if len(data) == 0 {
-		fmt.Println("you haven't logged in! This service required you to log in to continue")
+		fmt.Fprintln(utility.Stdout, "you haven't logged in! This service required you to log in to continue")
 		return
 	}
 
@@ -34,62 +49,99 @@ func MakeTransaction() {
 	var credential model.Credential
 	err = json.Unmarshal(data, &credential)
 	if err != nil {
-		fmt.Println("Error at: MakeTransaction -> Error unmarshal ccredential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error unmarshal ccredential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	token := credential.Token
 
+	account, ok := pickAccount(credential, *from)
+	if !ok {
+		return
+	}
+
 	var (
-		transaction model.Transaction = model.Transaction{DebitAccount: credential.Info.ID}
+		transaction model.Transaction = model.Transaction{DebitAccount: account.ID}
 		isValid     bool
-		reader      = bufio.NewReader(os.Stdin)
+		reader      = utility.Stdin
 	)
 
 	/*Get transaction's data*/
 
 	//Display source account information
-	fmt.Println("Account information")
-	fmt.Printf("\tDebit account: %s\n", credential.Info.ID)
-	fmt.Printf("\tBalance: %f\n", credential.Info.Balance)
-	fmt.Println(strings.Repeat("*", 20))
+	fmt.Fprintln(utility.Stdout, "Account information")
+	fmt.Fprintf(utility.Stdout, "\tDebit account: %s\n", account.ID)
+	fmt.Fprintf(utility.Stdout, "\tBalance: %s\n", account.Balance.Format(account.Currency))
+	fmt.Fprintln(utility.Stdout, strings.Repeat("*", 20))
+
+	//Saved payees can be picked by their number instead of typing the account number
+	payees, ok := fetchPayees(token)
+	if !ok {
+		return
+	}
+	prompt := "Enter beneficiary account number: "
+	if len(payees) > 0 {
+		fmt.Fprintln(utility.Stdout, "Saved payees")
+		for i, payee := range payees {
+			fmt.Fprintf(utility.Stdout, "\t%d. %s - %s (%s)\n", i+1, payee.Nickname, payee.Account, payee.Name)
+		}
+		prompt = "Enter payee number or beneficiary account number: "
+	}
 
 	//Ask for beneficiary's information
 	isValid = false
-	fmt.Println("Beneficiary information")
+	fmt.Fprintln(utility.Stdout, "Beneficiary information")
 	for !isValid {
 		//Read dest account number from stdin
-		fmt.Print("Enter beneficiary account number: ")
+		fmt.Fprint(utility.Stdout, prompt)
 		transaction.CreditAccount, err = reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: MakeTransaction -> Error reading destination account from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error reading destination account from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		transaction.CreditAccount = strings.TrimSpace(transaction.CreditAccount)
 
+		//Payee numbers are short, no account number can be mistaken for one. The payee's owner was
+		//checked when it was saved
+		index, err := strconv.Atoi(transaction.CreditAccount)
+		if err == nil && 1 <= index && index <= len(payees) {
+			transaction.CreditAccount = payees[index-1].Account
+			transaction.Beneficiary = payees[index-1].Name
+			fmt.Fprintf(utility.Stdout, "Beneficiary's name: %s\n", transaction.Beneficiary)
+			fmt.Fprintln(utility.Stdout, strings.Repeat("*", 20))
+			isValid = true
+			continue
+		}
+
+		//Catch typos before asking the server
+		if model.ValidateAccountNumber(transaction.CreditAccount) != nil {
+			fmt.Fprintln(utility.Stdout, "Invalid account number, please check it again")
+			continue
+		}
+
 		//Package credit account id to send to server
 		data, err = json.MarshalIndent(transaction.CreditAccount, "", " ")
 		if err != nil {
-			fmt.Println("Error at: MakeTransaction -> Error marhshal credit account")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error marhshal credit account")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 
 		//Find account from server
-		url := "http://localhost:8800/fullname"
+		url := config.URL("/fullname")
 		req, err := http.NewRequest("GET", url, bytes.NewBuffer(data))
 		if err != nil {
-			fmt.Println("Error at: MakeTransaction -> Error making new request")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error making new request")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		req.Header.Set("token", token)
-		client := &http.Client{}
+		client := config.Client()
 		resp, err := client.Do(req)
 		if err != nil {
-			fmt.Println("Error at: MakeTransaction -> Error sending request to server or failed to receive respond")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error sending request to server or failed to receive respond")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		defer resp.Body.Close()
@@ -97,68 +149,81 @@ func MakeTransaction() {
 		//Handle each respond status
 		data, err = io.ReadAll(resp.Body)
 		if err != nil {
-			fmt.Println("Error at: MakeTransaction -> Error reading respond body")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error reading respond body")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 
-		if resp.StatusCode == http.StatusInternalServerError {
-			fmt.Println("Internal server error :(")
-			return
+		//Unknown account number, let user try again
+		if resp.StatusCode >= http.StatusBadRequest {
+			problem := utility.ParseProblem(resp.StatusCode, data)
+			if problem.Code == utility.CodeAccountNotFound || problem.Code == utility.CodeInvalidAccount {
+				fmt.Fprintln(utility.Stdout, "Cannot find any account with this ID")
+				continue
+			}
 		}
 
-		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotAcceptable {
-			fmt.Println(string(data))
-			auth.Logout()
+		//Handle error respond by its code
+		if resp.StatusCode >= http.StatusBadRequest {
+			problem := utility.ParseProblem(resp.StatusCode, data)
+			switch {
+			case utility.MustLogin(problem):
+				fmt.Fprintln(utility.Stdout, problem.Detail)
+				auth.Logout()
+			case problem.Code == utility.CodeInternal:
+				fmt.Fprintln(utility.Stdout, "Internal server error :(")
+			default:
+				fmt.Fprintln(utility.Stdout, problem.Detail)
+			}
 			return
 		}
 
-		if resp.StatusCode == http.StatusNotFound {
-			fmt.Println("Cannot find any account with this ID")
-			continue
-		}
-
-		if resp.StatusCode == http.StatusFound {
+		if resp.StatusCode == http.StatusOK {
 			//Display beneficiary's name
-			transaction.Beneficiary = string(data)
-			fmt.Printf("Beneficiary's name: %s\n", transaction.Beneficiary)
-			fmt.Println(strings.Repeat("*", 20))
+			err = json.Unmarshal(data, &transaction.Beneficiary)
+			if err != nil {
+				fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error unmarshal beneficiary's name")
+				fmt.Fprintln(utility.Stdout, err)
+				return
+			}
+			fmt.Fprintf(utility.Stdout, "Beneficiary's name: %s\n", transaction.Beneficiary)
+			fmt.Fprintln(utility.Stdout, strings.Repeat("*", 20))
 			isValid = true
 		}
 	}
 
 	//Ask for transaction's amount
-	fmt.Println("Transaction's information")
+	fmt.Fprintln(utility.Stdout, "Transaction's information")
 	isValid = false
 	for !isValid {
 		//Read amount from stdin
-		fmt.Print("Enter amount of money: ")
+		fmt.Fprint(utility.Stdout, "Enter amount of money: ")
 		temp, err := reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: MakeTransaction -> Error reading amount from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error reading amount from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		temp = strings.TrimSpace(temp)
-		transaction.Amount, err = strconv.ParseFloat(temp, 64)
+		transaction.Amount, err = model.ParseMoney(temp)
 		if err != nil {
-			fmt.Println("Invalid value for amount")
+			fmt.Fprintln(utility.Stdout, "Invalid value for amount")
 			continue
 		}
 
 		//Check if amount is a valid value
-		isValid = 0 < transaction.Amount && transaction.Amount <= credential.Info.Balance
+		isValid = 0 < transaction.Amount && transaction.Amount <= account.Balance
 		if !isValid {
-			fmt.Printf("Amount of money must be between 0 and %f\n", credential.Info.Balance)
+			fmt.Fprintf(utility.Stdout, "Amount of money must be between 0 and %s\n", account.Balance.Format(account.Currency))
 		}
 	}
 
 	//Ask for transaction's description (no need for a loop since there's no edge cases)
-	fmt.Print("Enter transaction's description (optional): ")
+	fmt.Fprint(utility.Stdout, "Enter transaction's description (optional): ")
 	transaction.Description, err = reader.ReadString('\n')
 	if err != nil {
-		fmt.Println("Error at: MakeTransaction -> Error reading description from stdin")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error reading description from stdin")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	transaction.Description = strings.TrimSpace(transaction.Description)
@@ -169,23 +234,23 @@ func MakeTransaction() {
 	}
 
 	//Ask user for confirmation
-	fmt.Println(strings.Repeat("*", 20))
-	fmt.Println("TRANSACTION'S DETAIL")
-	fmt.Printf("\tDebit account: %s\n", transaction.DebitAccount)
-	fmt.Printf("\tCredit account: %s\n", transaction.CreditAccount)
-	fmt.Printf("\tBeneficiary's name: %s\n", transaction.Beneficiary)
-	fmt.Printf("\tAmount: %f\n", transaction.Amount)
-	fmt.Printf("\tDescription: %s\n", transaction.Description)
+	fmt.Fprintln(utility.Stdout, strings.Repeat("*", 20))
+	fmt.Fprintln(utility.Stdout, "TRANSACTION'S DETAIL")
+	fmt.Fprintf(utility.Stdout, "\tDebit account: %s\n", transaction.DebitAccount)
+	fmt.Fprintf(utility.Stdout, "\tCredit account: %s\n", transaction.CreditAccount)
+	fmt.Fprintf(utility.Stdout, "\tBeneficiary's name: %s\n", transaction.Beneficiary)
+	fmt.Fprintf(utility.Stdout, "\tAmount: %s\n", transaction.Amount.Format(account.Currency))
+	fmt.Fprintf(utility.Stdout, "\tDescription: %s\n", transaction.Description)
 
 	//Get user's option
 	isValid = false
 	var option string
 	for !isValid {
-		fmt.Print("Confirmed? (Y/N) ")
+		fmt.Fprint(utility.Stdout, "Confirmed? (Y/N) ")
 		option, err = reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at MakeTransaction -> Error reading user's option")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at MakeTransaction -> Error reading user's option")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		option = strings.ToUpper(strings.TrimSpace(option))
@@ -204,73 +269,355 @@ func MakeTransaction() {
 		}
 	}
 
-	//Package data before sending to server
-	data, err = json.MarshalIndent(transaction, "", " ")
+	//Send the transaction. Money going into another currency is only converted at a quoted rate,
+	//which the user confirms before it is sent again with the quote. Every confirmation gets its
+	//own idempotency key, so retrying never moves the money twice
+	for {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error generating idempotency key")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+
+		status, message, err := send("POST", "/transaction", token, key, transaction)
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error sending request to server or failed to receive respond")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+
+		//Handle error respond by its code
+		if status >= http.StatusBadRequest {
+			problem := utility.ParseProblem(status, message)
+			switch {
+			case problem.Code == utility.CodeQuoteRequired || problem.Code == utility.CodeQuoteExpired:
+				if problem.Code == utility.CodeQuoteExpired {
+					fmt.Fprintln(utility.Stdout, "The quoted rate has expired, here is a new one")
+				}
+				quote, ok := requestQuote(token, transaction)
+				if !ok {
+					return
+				}
+				confirmed, err := confirmQuote(quote)
+				if err != nil {
+					fmt.Fprintln(utility.Stdout, "Error at MakeTransaction -> Error reading user's option")
+					fmt.Fprintln(utility.Stdout, err)
+					return
+				}
+				if !confirmed {
+					return
+				}
+				transaction.QuoteID = quote.ID
+				transaction.CreditAmount = quote.Converted
+				transaction.CreditCurrency = quote.To
+				continue
+			case utility.MustLogin(problem):
+				fmt.Fprintln(utility.Stdout, problem.Detail)
+				auth.Logout()
+			case problem.Code == utility.CodeInternal:
+				fmt.Fprintln(utility.Stdout, "Internal server error :(")
+			default:
+				fmt.Fprintln(utility.Stdout, problem.Detail)
+			}
+			return
+		}
+
+		if status == http.StatusCreated {
+			break
+		}
+	}
+
+	//Update credential, the money may have gone to another of the user's accounts
+	setBalance(&credential, account.ID, account.Balance-transaction.Amount)
+	for _, own := range credential.Info.Accounts {
+		if own.ID == transaction.CreditAccount {
+			received := transaction.Amount
+			if transaction.QuoteID != "" {
+				received = transaction.CreditAmount
+			}
+			setBalance(&credential, own.ID, own.Balance+received)
+		}
+	}
+	err = auth.SaveInfo(credential.Info)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error update credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+	//Send message to client
+	fmt.Fprintln(utility.Stdout, "Transaction created successfully")
+
+	//Offer to save someone else's account the user has not saved yet
+	for _, own := range credential.Info.Accounts {
+		if own.ID == transaction.CreditAccount {
+			return
+		}
+	}
+	for _, payee := range payees {
+		if payee.Account == transaction.CreditAccount {
+			return
+		}
+	}
+	for {
+		fmt.Fprintf(utility.Stdout, "Save %s as a payee? (Y/N) ", transaction.Beneficiary)
+		option, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(utility.Stdout, "Error at MakeTransaction -> Error reading user's option")
+			fmt.Fprintln(utility.Stdout, err)
+			return
+		}
+		option = strings.ToUpper(strings.TrimSpace(option))
+
+		if option == "N" {
+			return
+		}
+		if option == "Y" {
+			break
+		}
+	}
+
+	nickname, err := readNickname(fmt.Sprintf("Enter nickname (default %s): ", transaction.Beneficiary))
 	if err != nil {
-		fmt.Println("Error at: MakeTransaction -> Error marshal transaction data")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: MakeTransaction -> Error reading nickname from stdin")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
+	addPayee(token, transaction.CreditAccount, nickname)
+}
+
+// requestQuote asks the server to lock a rate for the transaction. It tells the user when no
+// quote can be given
+func requestQuote(token string, transaction model.Transaction) (model.Quote, bool) {
+	var quote model.Quote
+	status, data, err := send("POST", "/quotes", token, "", model.Quote{
+		DebitAccount:  transaction.DebitAccount,
+		CreditAccount: transaction.CreditAccount,
+		Amount:        transaction.Amount,
+	})
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: requestQuote -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
+		return quote, false
+	}
+
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
+		return quote, false
+	}
+
+	err = json.Unmarshal(data, &quote)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: requestQuote -> Error unmarshal quote")
+		fmt.Fprintln(utility.Stdout, err)
+		return quote, false
+	}
+
+	return quote, true
+}
+
+// confirmQuote shows the locked rate and what the beneficiary receives, and asks the user to
+// accept it
+func confirmQuote(quote model.Quote) (bool, error) {
+	fmt.Fprintln(utility.Stdout, strings.Repeat("*", 20))
+	fmt.Fprintln(utility.Stdout, "CURRENCY CONVERSION")
+	fmt.Fprintf(utility.Stdout, "	Exchange rate: 1 %s = %s %s\n", quote.From, quote.Rate, quote.To)
+	if quote.Fee != "" && quote.Fee != "0.00" {
+		fmt.Fprintf(utility.Stdout, "	Conversion fee: %s%% (included in the rate)\n", quote.Fee)
+	}
+	fmt.Fprintf(utility.Stdout, "	You pay: %s\n", quote.Amount.Format(quote.From))
+	fmt.Fprintf(utility.Stdout, "	Beneficiary receives: %s\n", quote.Converted.Format(quote.To))
+	fmt.Fprintf(utility.Stdout, "	Rate locked until: %s\n", quote.ExpiresAt.Local().Format("15:04:05"))
+
+	for {
+		fmt.Fprint(utility.Stdout, "Accept this rate? (Y/N) ")
+		option, err := utility.Stdin.ReadString('\n')
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToUpper(strings.TrimSpace(option)) {
+		case "Y":
+			return true, nil
+		case "N":
+			return false, nil
+		}
+	}
+}
+
+func GetTransactions(args []string) {
+	//Parse filter flags
+	flags := flag.NewFlagSet("get-transactions", flag.ContinueOnError)
+	from := flags.String("from", "", "Only show transactions from this date (YYYY-MM-DD)")
+	to := flags.String("to", "", "Only show transactions until this date (YYYY-MM-DD)")
+	counterparty := flags.String("counterparty", "", "Only show transactions with this account number")
+	direction := flags.String("direction", "", "Only show incoming (in) or outgoing (out) transactions")
+	min := flags.String("min", "", "Minimum amount")
+	max := flags.String("max", "", "Maximum amount")
+	search := flags.String("search", "", "Search text in description")
+	limit := flags.Int("limit", 20, "Number of transactions per page")
+	cursor := flags.String("cursor", "", "Show the page after this cursor")
+	account := flags.String("account", "", "Account to show, your checking account by default")
+	err := flags.Parse(args)
+	if err != nil {
+		return
+	}
+
+	if flags.NArg() > 0 {
+		fmt.Fprintln(utility.Stdout, "Too many arguments")
+		return
+	}
+
+	//Check if client has logged in
+	data, err := os.ReadFile(config.CredentialPath())
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: GetTransactions -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	if len(data) == 0 {
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
+		return
+	}
+
+	//Get token from credential
+	var credential model.Credential
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		fmt.Fprintln(utility.Stdout, "Error at: GetTransactions -> Error unmarshal credential")
+		fmt.Fprintln(utility.Stdout, err)
+		return
+	}
+
+	//Build request params from flags, empty flags are left out
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(*limit))
+	filters := map[string]string{
+		"from":         *from,
+		"to":           *to,
+		"counterparty": *counterparty,
+		"direction":    *direction,
+		"min":          *min,
+		"max":          *max,
+		"q":            *search,
+		"cursor":       *cursor,
+		"account":      *account,
+	}
+	for key, value := range filters {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
 
 	//Make new request
-	url := "http://localhost:8800/transaction"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+	url := config.URL("/transactions?" + params.Encode())
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		fmt.Println("Error at: MakeTransaction -> Error making new request")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: GetTransactions -> Error making new request")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
-	req.Header.Set("token", token)
-	client := &http.Client{}
+	req.Header.Set("token", credential.Token)
+	client := config.Client()
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error at: MakeTransaction -> Error sending request to server or failed to receive respond")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: GetTransactions -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	defer resp.Body.Close()
 
 	//Handle each respond status
-	message, err := io.ReadAll(resp.Body)
+	data, err = io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error at: MakeTransaction -> Error reading request body")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: GetTransactions -> Error reading respond body")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
-	if resp.StatusCode == http.StatusInternalServerError {
-		fmt.Println("Internal server error :(")
-		return
-	}
-
-	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotAcceptable {
-		fmt.Println(string(message))
-		auth.Logout()
+	//Handle error respond by its code
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := utility.ParseProblem(resp.StatusCode, data)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
 		return
 	}
 
-	if resp.StatusCode == http.StatusCreated {
-		//Update credential
-		credential.Info.Balance -= transaction.Amount
-		data, err = json.MarshalIndent(credential, "", " ")
+	if resp.StatusCode == http.StatusOK {
+		var page model.TransactionPage
+		err = json.Unmarshal(data, &page)
 		if err != nil {
-			fmt.Println("Error at: MakeTransaction -> Error marshal credential")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: GetTransactions -> Error unmarshal transactions")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
-		err = os.WriteFile(creFilePath, data, 0644)
-		if err != nil {
-			fmt.Println("Error at: MakeTransaction -> Error update crdential")
-			fmt.Println(err)
+
+		if len(page.Transactions) == 0 {
+			fmt.Fprintln(utility.Stdout, "No transaction found")
 			return
 		}
-		//Send message to client
-		fmt.Println("Transaction created successfully")
-		return
-	}
-
-}
 
-func GetTransactions() {
+		//Display transactions as a table
+		shown := *account
+		if shown == "" {
+			shown = credential.Info.ID
+		}
+		writer := tabwriter.NewWriter(utility.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tDATE\tTYPE\tCOUNTERPARTY\tAMOUNT\tDESCRIPTION")
+		for _, transaction := range page.Transactions {
+			//Outgoing money is shown as negative amount
+			counterparty, sign := transaction.CreditAccount, "-"
+			if transaction.CreditAccount == shown {
+				counterparty, sign = transaction.DebitAccount, "+"
+			}
+			if counterparty == "" {
+				counterparty = "-"
+			}
+			if transaction.Type == "transfer" && sign == "-" {
+				counterparty = fmt.Sprintf("%s (%s)", counterparty, transaction.Beneficiary)
+			}
+
+			//Each side sees the amount in its own account's currency
+			amount := transaction.Amount.Format(transaction.Currency)
+			if sign == "+" && transaction.CreditCurrency != "" {
+				amount = transaction.CreditAmount.Format(transaction.CreditCurrency)
+			}
+			if transaction.Rate != "" {
+				amount = fmt.Sprintf("%s @ %s", amount, transaction.Rate)
+			}
+
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s%s\t%s\n",
+				transaction.ID,
+				transaction.Date.Format("2006-01-02"),
+				transaction.Type,
+				counterparty,
+				sign,
+				amount,
+				transaction.Description,
+			)
+		}
+		writer.Flush()
 
+		if page.NextCursor != "" {
+			fmt.Fprintf(utility.Stdout, "More transactions available, run again with --cursor %s\n", page.NextCursor)
+		}
+	}
 }
@@ -1,30 +1,42 @@
 package user
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"gobank/auth"
+	"gobank/config"
 	"gobank/model"
-	"io"
+	"gobank/utility"
 	"net/http"
 	"os"
-	"strconv"
 	"strings"
 )
 
-func Topup() {
+func Topup(args []string) {
+	//Parse flags
+	flags := flag.NewFlagSet("topup", flag.ContinueOnError)
+	number := flags.String("account", "", "Account to top up, your checking account by default")
+	err := flags.Parse(args)
+	if err != nil {
+		return
+	}
+
+	if flags.NArg() > 0 {
+		fmt.Fprintln(utility.Stdout, "Too many arguments")
+		return
+	}
+
 	//Check if client has been logged in
-	data, err := os.ReadFile("./data/credential.json")
+	data, err := os.ReadFile(config.CredentialPath())
 	if err != nil {
-		fmt.Println("Error at: Topup -> Error reading credential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Topup -> Error reading credential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
 	if len(data) == 0 {
-		fmt.Println("You haven't logged in! This service required you to log in to continue")
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
 		return
 	}
 
@@ -32,120 +44,125 @@ func Topup() {
 	var credential model.Credential
 	err = json.Unmarshal(data, &credential)
 	if err != nil {
-		fmt.Println("Error at: Topup -> Error unmarshal crdential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Topup -> Error unmarshal crdential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	token := credential.Token
 
+	account, ok := pickAccount(credential, *number)
+	if !ok {
+		return
+	}
+
 	var (
-		amount  float64
+		amount  model.Money
 		temp    string
 		isValid bool
-		reader  = bufio.NewReader(os.Stdin)
+		reader  = utility.Stdin
 	)
 
 	//Ask for user's amount of money
 	isValid = false
 	for !isValid {
 		//Read amount from stdin
-		fmt.Print("Enter your amount of money: ")
+		fmt.Fprint(utility.Stdout, "Enter your amount of money: ")
 		temp, err = reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: Topup -> Error reading amount from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Topup -> Error reading amount from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		temp = strings.TrimSpace(temp)
-		amount, err = strconv.ParseFloat(temp, 64)
+		amount, err = model.ParseMoney(temp)
 		if err != nil {
-			fmt.Println("Invalid value for amount!")
+			fmt.Fprintln(utility.Stdout, "Invalid value for amount!")
 			continue
 		}
 
 		//Check if amount is valid number
 		isValid = amount > 0
 		if !isValid {
-			fmt.Println("The amount of money must be greater than 0")
+			fmt.Fprintln(utility.Stdout, "The amount of money must be greater than 0")
 		}
 	}
 
-	//Package data before sending to server
-	data, err = json.MarshalIndent(amount, "", " ")
+	//One key for this topup, so retrying it never moves the money twice
+	key, err := newIdempotencyKey()
 	if err != nil {
-		fmt.Println("Error at: Topup -> Error marshal data")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Topup -> Error generating idempotency key")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
-	//Make new request to server
-	url := "http://localhost:8800/topup"
-	req, err := http.NewRequest("UPDATE", url, bytes.NewBuffer(data))
+	//Send request to server, it is retried when no respond comes back
+	status, message, err := send("UPDATE", "/topup?account="+account.ID, token, key, amount)
 	if err != nil {
-		fmt.Println("Error at: Topup -> Error making new request to server")
-		fmt.Println(err)
-		return
-	}
-	req.Header.Set("token", token)
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println("Error at: Topup -> Error sending request to server or failed to receive respond")
-		fmt.Println(err)
-		return
-	}
-	defer resp.Body.Close()
-
-	//Handle each respond status
-	message, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error at: Topup -> Error reading respond body")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Topup -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
-	if resp.StatusCode == http.StatusInternalServerError {
-		fmt.Println("Internal server error :(")
-		return
-	}
-
-	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotAcceptable {
-		fmt.Println(string(message))
-		auth.Logout()
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, message)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
 		return
 	}
 
-	if resp.StatusCode == http.StatusOK {
-		//Update balance in credential
-		credential.Info.Balance += amount
-		data, err = json.MarshalIndent(credential, "", " ")
+	if status == http.StatusOK {
+		//Update balance in credential with the balance the server computed
+		var balance model.Money
+		err = json.Unmarshal(message, &balance)
 		if err != nil {
-			fmt.Println("Error at: Topup -> Error marshal credential")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Topup -> Error unmarshal new balance")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
-		err = os.WriteFile("./data/credential.json", data, 0644)
+		setBalance(&credential, account.ID, balance)
+		err = auth.SaveInfo(credential.Info)
 		if err != nil {
-			fmt.Println("Error at: Topup -> Error update credetial")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Topup -> Error update credential")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		//Print message
-		fmt.Println("Balance update successfully!")
+		fmt.Fprintln(utility.Stdout, "Balance update successfully!")
 	}
 }
 
-func Withdraw() {
+func Withdraw(args []string) {
+	//Parse flags
+	flags := flag.NewFlagSet("withdraw", flag.ContinueOnError)
+	number := flags.String("account", "", "Account to withdraw from, your checking account by default")
+	err := flags.Parse(args)
+	if err != nil {
+		return
+	}
+
+	if flags.NArg() > 0 {
+		fmt.Fprintln(utility.Stdout, "Too many arguments")
+		return
+	}
+
 	//Check if client has been logged in
-	data, err := os.ReadFile("./data/credential.json")
+	data, err := os.ReadFile(config.CredentialPath())
 	if err != nil {
-		fmt.Println("Error at: Withdraw -> Error reading crdential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Withdraw -> Error reading crdential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
 	if len(data) == 0 {
-		fmt.Println("You haven't logged in! This service required you to log in to continue")
+		fmt.Fprintln(utility.Stdout, "You haven't logged in! This service required you to log in to continue")
 		return
 	}
 
@@ -153,105 +170,97 @@ func Withdraw() {
 	var credential model.Credential
 	err = json.Unmarshal(data, &credential)
 	if err != nil {
-		fmt.Println("Error at: Withdraw -> Error unmarshal crdential")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Withdraw -> Error unmarshal crdential")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 	token := credential.Token
 
+	account, ok := pickAccount(credential, *number)
+	if !ok {
+		return
+	}
+
 	var (
-		amount  float64
+		amount  model.Money
 		isValid bool
-		reader  = bufio.NewReader(os.Stdin)
+		reader  = utility.Stdin
 	)
 
 	//Ask user's for amount of money
 	isValid = false
 	for !isValid {
 		//Read amount from stdin
-		fmt.Print("Enter your amount of withdraw: ")
+		fmt.Fprint(utility.Stdout, "Enter your amount of withdraw: ")
 		temp, err := reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error at: Withdraw -> Error reading amount from stdin")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Withdraw -> Error reading amount from stdin")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		temp = strings.TrimSpace(temp)
-		amount, err = strconv.ParseFloat(temp, 64)
+		amount, err = model.ParseMoney(temp)
 		if err != nil {
-			fmt.Println("Invalid value for amount!")
+			fmt.Fprintln(utility.Stdout, "Invalid value for amount!")
 			continue
 		}
 
 		//Check if amount is valid
-		isValid = 0 < amount && amount <= credential.Info.Balance
+		isValid = 0 < amount && amount <= account.Balance
 		if !isValid {
-			fmt.Printf("The amount to withdraw must be between 0 and %f\n", credential.Info.Balance)
+			fmt.Fprintf(utility.Stdout, "The amount to withdraw must be between 0 and %s\n", account.Balance.Format(account.Currency))
 		}
 	}
 
-	//Package data before sending
-	data, err = json.MarshalIndent(amount, "", " ")
+	//One key for this withdraw, so retrying it never moves the money twice
+	key, err := newIdempotencyKey()
 	if err != nil {
-		fmt.Println("Error at: Withdraw -> Error marshal data before sending to server")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Withdraw -> Error generating idempotency key")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
-	//Make new request
-	url := "http://localhost:8800/withdraw"
-	req, err := http.NewRequest("UPDATE", url, bytes.NewBuffer(data))
+	//Send request to server, it is retried when no respond comes back
+	status, message, err := send("UPDATE", "/withdraw?account="+account.ID, token, key, amount)
 	if err != nil {
-		fmt.Println("Error at: Withdraw -> Error making new request")
-		fmt.Println(err)
-		return
-	}
-	req.Header.Set("token", token)
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println("Error at: Withdraw -> Error sending request to server or failed to receive respond")
-		fmt.Println(err)
-		return
-	}
-	defer resp.Body.Close()
-
-	//Handele each respond status
-	message, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error at: Withdraw -> Error reading respond body")
-		fmt.Println(err)
+		fmt.Fprintln(utility.Stdout, "Error at: Withdraw -> Error sending request to server or failed to receive respond")
+		fmt.Fprintln(utility.Stdout, err)
 		return
 	}
 
-	if resp.StatusCode == http.StatusInternalServerError {
-		fmt.Println("Internal server error :(")
-		return
-	}
-
-	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotAcceptable {
-		fmt.Println(string(message))
-		auth.Logout()
+	//Handle error respond by its code
+	if status >= http.StatusBadRequest {
+		problem := utility.ParseProblem(status, message)
+		switch {
+		case utility.MustLogin(problem):
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+			auth.Logout()
+		case problem.Code == utility.CodeInternal:
+			fmt.Fprintln(utility.Stdout, "Internal server error :(")
+		default:
+			fmt.Fprintln(utility.Stdout, problem.Detail)
+		}
 		return
 	}
 
-	if resp.StatusCode == http.StatusOK {
-		//Update balance in credential
-		credential.Info.Balance -= amount
-		data, err = json.MarshalIndent(credential, "", " ")
+	if status == http.StatusOK {
+		//Update balance in credential with the balance the server computed
+		var balance model.Money
+		err = json.Unmarshal(message, &balance)
 		if err != nil {
-			fmt.Println("Error at: Withdraw -> Error marshal credential")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Withdraw -> Error unmarshal new balance")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
-		err = os.WriteFile("./data/credential.json", data, 0644)
+		setBalance(&credential, account.ID, balance)
+		err = auth.SaveInfo(credential.Info)
 		if err != nil {
-			fmt.Println("Error at: Withdraw > Error update credential")
-			fmt.Println(err)
+			fmt.Fprintln(utility.Stdout, "Error at: Withdraw -> Error update credential")
+			fmt.Fprintln(utility.Stdout, err)
 			return
 		}
 		//Print message to client
-		fmt.Println("Balance updated successfully")
+		fmt.Fprintln(utility.Stdout, "Balance updated successfully")
 	}
 
 }
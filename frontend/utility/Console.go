@@ -0,0 +1,20 @@
+package utility
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// Every prompt reads from Stdin and every message goes to Stdout, so tests can script the answers
+// and check what the user sees
+var (
+	Stdin            = bufio.NewReader(os.Stdin)
+	Stdout io.Writer = os.Stdout
+)
+
+// SetConsole replaces the terminal with in and out
+func SetConsole(in io.Reader, out io.Writer) {
+	Stdin = bufio.NewReader(in)
+	Stdout = out
+}
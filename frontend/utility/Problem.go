@@ -0,0 +1,73 @@
+package utility
+
+import (
+	"encoding/json"
+	"gobank/model"
+	"net/http"
+)
+
+// Error codes sent by the server in problem responses
+const (
+	CodeInternal           = "internal_error"
+	CodeInvalidRequest     = "invalid_request"
+	CodeTokenExpired       = "token_expired"
+	CodeTokenInvalid       = "token_invalid"
+	CodeForbidden          = "forbidden"
+	CodeInvalidRole        = "invalid_role"
+	CodeInvalidCredential  = "invalid_credentials"
+	CodeEmailTaken         = "email_taken"
+	CodeSamePassword       = "same_password"
+	CodeSessionExpired     = "session_expired"
+	CodeSessionNotFound    = "session_not_found"
+	CodeAccountNotFound    = "account_not_found"
+	CodeInvalidAccount     = "invalid_account_number"
+	CodeAccountClosed      = "account_closed"
+	CodeAccountNotEmpty    = "account_not_empty"
+	CodeLastAccount        = "last_account"
+	CodeInvalidType        = "invalid_account_type"
+	CodeInvalidAmount      = "invalid_amount"
+	CodeSelfTransfer       = "self_transfer"
+	CodeInsufficientFunds  = "insufficient_funds"
+	CodeInvalidFilter      = "invalid_filter"
+	CodeInvalidCurrency    = "invalid_currency"
+	CodeQuoteRequired      = "quote_required"
+	CodeQuoteExpired       = "quote_expired"
+	CodeInvalidQuote       = "invalid_quote"
+	CodeRateUnavailable    = "rate_unavailable"
+	CodeInvalidSchedule    = "invalid_schedule"
+	CodeScheduleNotFound   = "schedule_not_found"
+	CodePayeeExists        = "payee_exists"
+	CodePayeeNotFound      = "payee_not_found"
+	CodeCoolingOffLimit    = "cooling_off_limit"
+	CodeDailyLimit         = "daily_limit_exceeded"
+	CodeInsufficientPoints = "insufficient_points"
+
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+
+	//Used when the server did not send a problem body (proxy error page, server too old,...)
+	CodeUnknown = "unknown"
+)
+
+// ParseProblem reads the body of an error respond
+func ParseProblem(status int, data []byte) model.Problem {
+	var problem model.Problem
+	err := json.Unmarshal(data, &problem)
+	if err != nil || problem.Code == "" {
+		problem = model.Problem{Status: status, Code: CodeUnknown, Detail: string(data)}
+		if status >= http.StatusInternalServerError {
+			problem.Code = CodeInternal
+		}
+	}
+
+	if problem.Detail == "" {
+		problem.Detail = http.StatusText(status)
+	}
+
+	return problem
+}
+
+// MustLogin tells whether the problem means the credential is no longer usable
+func MustLogin(problem model.Problem) bool {
+	return problem.Code == CodeTokenExpired || problem.Code == CodeTokenInvalid || problem.Code == CodeSessionExpired
+}
@@ -0,0 +1,27 @@
+package utility
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProgressBar draws how far done is towards total, e.g. "[#####---------------]  25%"
+func ProgressBar(done, total, width int) string {
+	percent := 0
+	if total > 0 {
+		percent = min(max(done*100/total, 0), 100)
+	}
+	filled := percent * width / 100
+
+	return fmt.Sprintf("[%s%s] %3d%%", strings.Repeat("#", filled), strings.Repeat("-", width-filled), percent)
+}
+
+// LevelProgress draws the progress through a level, measured from the EXP it starts at to the
+// EXP the next one starts at (next, 0 at the max level)
+func LevelProgress(level, exp, start, next int) string {
+	if next <= start {
+		return "maximum level reached"
+	}
+
+	return fmt.Sprintf("%s %d/%d EXP to level %d", ProgressBar(exp-start, next-start, 20), exp-start, next-start, level+1)
+}